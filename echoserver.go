@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/echoserver"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/echotemplate"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/logging"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+	"strings"
+	"time"
+)
+
+const echoServerCommand = "echoserver"
+
+// runEchoServer runs the echoserver subcommand, which serves ECHO_MESSAGE and ECHO_ROUTES, or the content of
+// ECHO_MESSAGE_FILE and ECHO_ROUTES_FILE, until SIGTERM. Spans of requests link to ECHO_TRACE_PARENT.
+func runEchoServer(args []string) {
+	flagSet := flag.NewFlagSet(echoServerCommand, flag.ExitOnError)
+	loggingOptions := logging.DefaultOptions()
+	loggingOptions.AddFlags(flagSet)
+	tracingOptions := tracing.DefaultOptions()
+	tracingOptions.ServiceName = echoServerCommand
+	tracingOptions.AddFlags(flagSet)
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	options := echoserver.Options{
+		Message:     os.Getenv("ECHO_MESSAGE"),
+		MessageFile: os.Getenv("ECHO_MESSAGE_FILE"),
+		RoutesFile:  os.Getenv("ECHO_ROUTES_FILE"),
+		Template:    os.Getenv("ECHO_MESSAGE_FORMAT") == "Template",
+		TraceParent: os.Getenv("ECHO_TRACE_PARENT"),
+		Pod: echotemplate.Pod{
+			Name:      os.Getenv("POD_NAME"),
+			Namespace: os.Getenv("POD_NAMESPACE"),
+			Node:      os.Getenv("NODE_NAME"),
+		},
+	}
+	if headers := os.Getenv("ECHO_TEMPLATE_HEADERS"); headers != "" {
+		options.TemplateHeaders = strings.Split(headers, ",")
+	}
+	flagSet.StringVar(&options.Address, "address", ":"+port, "address the echo server listens on")
+	flagSet.DurationVar(&options.DrainDelay, "drain-delay", echoserver.DefaultDrainDelay,
+		"time to keep serving after SIGTERM while reporting not ready")
+	flagSet.DurationVar(&options.ReloadInterval, "reload-interval", echoserver.DefaultReloadInterval,
+		"how often the message file is checked for changes")
+	flagSet.DurationVar(&options.ShutdownTimeout, "shutdown-timeout", echoserver.DefaultShutdownTimeout,
+		"maximum time to wait for in-flight requests on shutdown")
+	_ = flagSet.Parse(args)
+
+	err := logging.Setup(loggingOptions)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger := log.Log.WithName("echoserver")
+	options.Logger = logger
+
+	ctx := signals.SetupSignalHandler()
+	shutdownTracing, err := tracing.Setup(ctx, tracingOptions)
+	if err != nil {
+		logger.Error(err, "failed to set up tracing")
+		os.Exit(1)
+	}
+
+	routes, err := echoserver.ParseRoutes([]byte(os.Getenv("ECHO_ROUTES")))
+	if err != nil {
+		logger.Error(err, "invalid ECHO_ROUTES")
+		os.Exit(1)
+	}
+	options.Routes = routes
+
+	server, err := echoserver.New(options)
+	if err != nil {
+		logger.Error(err, "failed to create echo server")
+		os.Exit(1)
+	}
+
+	err = server.Run(ctx)
+	if err != nil {
+		logger.Error(err, "echo server failed")
+		os.Exit(1)
+	}
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = shutdownTracing(flushCtx)
+	if err != nil {
+		logger.Error(err, "failed to flush traces")
+	}
+}
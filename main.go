@@ -1,58 +1,153 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/myresource"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/myresourcequota"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/myresourceset"
 	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/pod"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
 	"github.com/reshnm/k8s-sample-controller-crd/pkg/crdmanager"
-	"k8s.io/klog/v2"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/debug"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/logging"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+	"os"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+	"time"
 )
 
 var (
 	kubeconfig string
+
+	myresourceOptions = myresource.DefaultOptions()
+	podOptions        = reconcileutil.DefaultOptions()
+	setOptions        = reconcileutil.DefaultOptions()
+	quotaOptions      = reconcileutil.DefaultOptions()
+	tracingOptions    = tracing.DefaultOptions()
+	loggingOptions    = logging.DefaultOptions()
+	debugOptions      = debug.Options{}
+
+	setupLog = ctrl.Log.WithName("setup")
 )
 
 func createControllerManager() manager.Manager {
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), manager.Options{})
 	if err != nil {
-		klog.Fatal("failed to create new controller manager", err)
+		setupLog.Error(err, "failed to create new controller manager")
+		os.Exit(1)
 	}
 
 	return mgr
 }
 
+// addControllers adds the controllers to mgr. It runs once the CRDs are established, watches of
+// custom resources the API server doesn't serve yet would fail.
+func addControllers(mgr manager.Manager) error {
+	err := myresource.AddControllerToManager(mgr, myresourceOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create MyResource controller: %w", err)
+	}
+	if myresourceOptions.DryRun {
+		// the other controllers only write, their changes are left to the controller that applies the plans
+		setupLog.Info("running in dry-run mode, only the MyResource controller plans changes")
+		return nil
+	}
+
+	err = pod.AddControllerToManager(mgr, podOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create Pod controller: %w", err)
+	}
+	err = myresourceset.AddControllerToManager(mgr, setOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create MyResourceSet controller: %w", err)
+	}
+	err = myresourcequota.AddControllerToManager(mgr, quotaOptions)
+	if err != nil {
+		return fmt.Errorf("failed to create MyResourceQuota controller: %w", err)
+	}
+	return nil
+}
+
 func main() {
-	klog.InitFlags(nil)
+	if len(os.Args) > 1 && os.Args[1] == echoServerCommand {
+		runEchoServer(os.Args[2:])
+		return
+	}
+
+	loggingOptions.AddFlags(flag.CommandLine)
+	myresourceOptions.AddFlags(flag.CommandLine)
+	podOptions.AddFlags(flag.CommandLine, "pod")
+	setOptions.AddFlags(flag.CommandLine, "myresourceset")
+	quotaOptions.AddFlags(flag.CommandLine, "myresourcequota")
+	tracingOptions.AddFlags(flag.CommandLine)
+	debugOptions.AddFlags(flag.CommandLine)
 	flag.Parse()
 
+	err := logging.Setup(loggingOptions)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	err = myresourceOptions.Validate()
+	if err != nil {
+		setupLog.Error(err, "invalid options of the MyResource controller")
+		os.Exit(1)
+	}
+
+	ctx := signals.SetupSignalHandler()
+	shutdownTracing, err := tracing.Setup(ctx, tracingOptions)
+	if err != nil {
+		setupLog.Error(err, "failed to set up tracing")
+		os.Exit(1)
+	}
+
 	mgr := createControllerManager()
 
-	crdManager, err := crdmanager.CreateCrdManager(mgr)
+	crdManager, err := crdmanager.CreateCrdManager(mgr, crdmanager.Options{DryRun: myresourceOptions.DryRun})
 	if err != nil {
-		klog.Fatal("failed to create CRD manager: ", err)
+		setupLog.Error(err, "failed to create CRD manager")
+		os.Exit(1)
 	}
 
-	err = crdManager.EnsureCRDs()
+	err = mgr.Add(crdManager.Runnable(func() error {
+		return addControllers(mgr)
+	}))
 	if err != nil {
-		klog.Fatal("failed to ensure CRDs: ", err)
+		setupLog.Error(err, "failed to add CRD manager")
+		os.Exit(1)
+	}
+
+	if debugOptions.Enabled() {
+		myresourceOptions.Recorder = debug.NewRecorder()
+		err = mgr.Add(debug.NewServer(debugOptions.Address, mgr.GetAPIReader(), myresourceOptions.Recorder))
+		if err != nil {
+			setupLog.Error(err, "failed to add debug server")
+			os.Exit(1)
+		}
 	}
 
 	myresource.Install(mgr.GetScheme())
-	err = myresource.AddControllerToManager(mgr)
+
+	setupLog.Info("starting the controller")
+
+	err = mgr.Start(ctx)
 	if err != nil {
-		klog.Fatalf("error creating MyResource controller: %w", err)
+		setupLog.Error(err, "failed to run the controller")
+		os.Exit(1)
 	}
-	pod.AddControllerToManager(mgr)
-
-	klog.Info("starting the controller")
 
-	err = mgr.Start(signals.SetupSignalHandler())
+	// the signal context is done by now, pending spans get a few seconds to be flushed
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = shutdownTracing(flushCtx)
 	if err != nil {
-		klog.Fatalf("error starting the controller: %w", err)
+		setupLog.Error(err, "failed to flush traces")
 	}
 
-	klog.Info("controller stopped")
+	setupLog.Info("controller stopped")
 }
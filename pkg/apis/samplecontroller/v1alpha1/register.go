@@ -27,6 +27,12 @@ func addKnownTypes(schema *runtime.Scheme) error {
 		SchemeGroupVersion,
 		&MyResource{},
 		&MyResourceList{},
+		&MyResourceClass{},
+		&MyResourceClassList{},
+		&MyResourceSet{},
+		&MyResourceSetList{},
+		&MyResourceQuota{},
+		&MyResourceQuotaList{},
 	)
 
 	metav1.AddToGroupVersion(schema, SchemeGroupVersion)
@@ -1,9 +1,257 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+const (
+	// SuspendAnnotation overrides spec.suspend when set to "true" or "false".
+	SuspendAnnotation = "samplecontroller.reshnm.de/suspend"
+	// PromoteAnnotation promotes the preview of a blue/green rollout if it is set to the preview revision.
+	PromoteAnnotation = "samplecontroller.reshnm.de/promote"
+	// RollbackToAnnotation rolls the spec back to the recorded revision with the given number.
+	// The value "0" rolls back to the revision before the current one. MyResources of a MyResourceSet
+	// can't be rolled back, their spec is the template of the MyResourceSet.
+	RollbackToAnnotation = "samplecontroller.reshnm.de/rollback-to"
+	// DefaultClassAnnotation marks the MyResourceClass used by MyResources without spec.className when set to "true".
+	DefaultClassAnnotation = "samplecontroller.reshnm.de/is-default-class"
+	// RevisionLabel is set on the pods of a MyResource to the hash of their spec. Pods with the same
+	// revision are interchangeable.
+	RevisionLabel = "samplecontroller.reshnm.de/revision"
+)
+
+const (
+	// ConditionSuspended is true while reconciliation of the MyResource is suspended.
+	ConditionSuspended = "Suspended"
+	// ConditionNameConflict is true if the name of a child is taken by an object the MyResource doesn't control.
+	ConditionNameConflict = "NameConflict"
+	// ConditionInvalidTemplate is true if the message is a template that can't be rendered.
+	ConditionInvalidTemplate = "InvalidTemplate"
+	// ConditionPodSecurityViolation is true if the echo pods would violate the Pod Security level
+	// enforced in the namespace of the MyResource.
+	ConditionPodSecurityViolation = "PodSecurityViolation"
+	// ConditionPolicyViolation is true if the echo pods would violate the image policy of the controller,
+	// no children are created or updated while it is true.
+	ConditionPolicyViolation = "PolicyViolation"
+	// ConditionQuotaExceeded is true if the MyResource doesn't fit into a MyResourceQuota of its namespace,
+	// no children are created or updated while it is true.
+	ConditionQuotaExceeded = "QuotaExceeded"
+	// ConditionReady is true if all MyResources of a MyResourceSet are ready.
+	ConditionReady = "Ready"
+)
+
+// ReloadStrategy defines how a changed message reaches the echo pod.
+type ReloadStrategy string
+
+const (
+	// ReloadStrategyRecreate passes the message as env var, a new message needs a new pod.
+	ReloadStrategyRecreate ReloadStrategy = "Recreate"
+	// ReloadStrategyHotReload mounts the message from a ConfigMap, the echo server picks up changes while running.
+	ReloadStrategyHotReload ReloadStrategy = "HotReload"
+)
+
+// MessageFormat defines how the echo server interprets the message.
+type MessageFormat string
+
+const (
+	// MessageFormatPlain serves the message as is.
+	MessageFormatPlain MessageFormat = "Plain"
+	// MessageFormatTemplate renders the message as Go text/template for every request.
+	MessageFormatTemplate MessageFormat = "Template"
+)
+
+// PathType defines how the path of a Route is matched.
+type PathType string
+
+const (
+	PathTypeExact  PathType = "Exact"
+	PathTypePrefix PathType = "Prefix"
+)
+
+// Route defines the response for requests matching a path.
+type Route struct {
+	Path string `json:"path"`
+	// PathType defines whether Path must match exactly or as prefix. Defaults to Prefix.
+	// +optional
+	PathType PathType `json:"pathType,omitempty"`
+	// Body is served as response body, it is rendered as template if the MessageFormat is Template.
+	// +optional
+	Body string `json:"body,omitempty"`
+	// StatusCode is the HTTP status of the response. Defaults to 200.
+	// +optional
+	StatusCode int32 `json:"statusCode,omitempty"`
+	// ContentType is the content type of the response. Defaults to text/plain.
+	// +optional
+	ContentType string `json:"contentType,omitempty"`
+	// Headers are added to the response.
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Strategy defines how the pods of a MyResource are replaced when its spec changes.
+type Strategy string
+
+const (
+	// StrategyRecreate deletes outdated pods and creates new ones in their place.
+	StrategyRecreate Strategy = "Recreate"
+	// StrategyBlueGreen brings up a new pod set next to the active one and switches over once it is promoted.
+	StrategyBlueGreen Strategy = "BlueGreen"
+)
+
+// BlueGreenStrategy configures blue/green rollouts.
+type BlueGreenStrategy struct {
+	// AutoPromotionSeconds promotes a ready preview after the given number of seconds.
+	// Without it the preview is only promoted by the promote annotation.
+	// +optional
+	AutoPromotionSeconds *int32 `json:"autoPromotionSeconds,omitempty"`
+}
+
+// RolloutStep is the state of the rollout of a MyResource.
+type RolloutStep string
+
+const (
+	// RolloutStepStable means that all pods run the current revision.
+	RolloutStepStable RolloutStep = "Stable"
+	// RolloutStepProgressing means that pods of a new revision are being created.
+	RolloutStepProgressing RolloutStep = "Progressing"
+	// RolloutStepAwaitingPromotion means that the preview is ready and waits to be promoted.
+	RolloutStepAwaitingPromotion RolloutStep = "AwaitingPromotion"
+)
+
+// RolloutStatus reports the revisions of the pod sets of a MyResource.
+type RolloutStatus struct {
+	// ActiveRevision is the revision served by the main Service.
+	// +optional
+	ActiveRevision string `json:"activeRevision,omitempty"`
+	// PreviewRevision is the revision served by the preview Service during a blue/green rollout.
+	// +optional
+	PreviewRevision string `json:"previewRevision,omitempty"`
+	// PreviewReadyTime is when all pods of the preview became ready.
+	// +optional
+	PreviewReadyTime *metav1.Time `json:"previewReadyTime,omitempty"`
+	// Step is the current step of the rollout.
+	// +optional
+	Step RolloutStep `json:"step,omitempty"`
+}
+
+// ScheduleEntry switches the message whenever its cron expression fires.
+type ScheduleEntry struct {
+	// Cron is a cron expression in the standard five field format.
+	Cron string `json:"cron"`
+	// Message replaces spec.message from the time Cron fires until another entry fires.
+	Message string `json:"message"`
+	// TimeZone is the IANA time zone Cron is evaluated in. Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// ScheduleStatus reports which scheduled message is active.
+type ScheduleStatus struct {
+	// ActiveEntry is the index of the schedule entry whose message is served.
+	// It is unset while spec.message is served.
+	// +optional
+	ActiveEntry *int32 `json:"activeEntry,omitempty"`
+	// LastChangeTime is when the active entry last fired.
+	// +optional
+	LastChangeTime *metav1.Time `json:"lastChangeTime,omitempty"`
+	// NextChangeTime is when the next entry fires.
+	// +optional
+	NextChangeTime *metav1.Time `json:"nextChangeTime,omitempty"`
+}
+
+// Placement selects the spoke clusters the children of a MyResource are created in.
+// Placed children are always rolled out with the Recreate strategy and reloaded by recreating them.
+type Placement struct {
+	// Clusters are the names of the Secrets registering the spoke clusters.
+	// +optional
+	Clusters []string `json:"clusters,omitempty"`
+}
+
+// ClusterStatus reports the pods of a MyResource in a spoke cluster.
+type ClusterStatus struct {
+	Name string `json:"name"`
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+	// Message explains why the pods in the cluster aren't ready.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PlannedChange is a create, update or delete that was validated with a server-side dry-run but not applied.
+type PlannedChange struct {
+	// Action is Create, Update, Delete or DeleteAllOf.
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	// Name is the name of the object, empty for DeleteAllOf.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Cluster is the spoke cluster of the object, empty for the local cluster.
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+}
+
+// NetworkPolicyPeer selects pods that are admitted to the echo port.
+type NetworkPolicyPeer struct {
+	// Namespaces are the names of the namespaces of the peers. Defaults to the namespace of the MyResource.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+	// PodSelector selects the peers in these namespaces. All pods are selected if it is unset.
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+}
+
+// NetworkPolicy restricts the ingress of the echo pods.
+type NetworkPolicy struct {
+	// From lists the peers admitted to the echo port. Ingress from all other peers and to all other ports
+	// is denied, no ingress is admitted if it is empty.
+	// The controller, which probes the pods for the message they serve, is admitted in addition if it is
+	// configured with the namespace and labels of its pods.
+	// +optional
+	From []NetworkPolicyPeer `json:"from,omitempty"`
+}
+
+// Disruption limits the voluntary disruptions of the echo pods, e.g. by node drains.
+// At most one of MinAvailable and MaxUnavailable may be set.
+type Disruption struct {
+	// MinAvailable is the number or percentage of pods that must stay available.
+	// +optional
+	MinAvailable *intstr.IntOrString `json:"minAvailable,omitempty"`
+	// MaxUnavailable is the number or percentage of pods that may be unavailable. Defaults to 1 if MinAvailable is unset.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// SecurityContext overrides the security settings of the echo pods, which comply with the Pod Security
+// restricted profile by default.
+type SecurityContext struct {
+	// RunAsUser is the user the echo server runs as. Defaults to 65532.
+	// +optional
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// RunAsGroup is the primary group of the echo server. Defaults to 65532.
+	// +optional
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+	// RunAsNonRoot requires the echo server to run as a user other than root. Defaults to true.
+	// +optional
+	RunAsNonRoot *bool `json:"runAsNonRoot,omitempty"`
+	// ReadOnlyRootFilesystem mounts the root filesystem of the echo container read-only. Defaults to true.
+	// +optional
+	ReadOnlyRootFilesystem *bool `json:"readOnlyRootFilesystem,omitempty"`
+	// AllowPrivilegeEscalation lets processes gain more privileges than their parent. Defaults to false.
+	// +optional
+	AllowPrivilegeEscalation *bool `json:"allowPrivilegeEscalation,omitempty"`
+	// AddCapabilities are granted to the echo container, all other capabilities are dropped.
+	// +optional
+	AddCapabilities []corev1.Capability `json:"addCapabilities,omitempty"`
+	// SeccompProfile is the seccomp profile of the echo pods. Defaults to RuntimeDefault.
+	// +optional
+	SeccompProfile *corev1.SeccompProfile `json:"seccompProfile,omitempty"`
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -17,10 +265,94 @@ type MyResource struct {
 
 type MyResourceSpec struct {
 	Message string `json:"message"`
+	// ClassName is the name of the MyResourceClass providing defaults for the children.
+	// The default class is used if it is empty.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+	// MessageFormat defines whether the message is served as is or rendered as template. Defaults to Plain.
+	// +optional
+	MessageFormat MessageFormat `json:"messageFormat,omitempty"`
+	// TemplateHeaders lists the request headers that message templates may access.
+	// +optional
+	TemplateHeaders []string `json:"templateHeaders,omitempty"`
+	// Replicas is the number of echo pods. Defaults to 1.
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// Strategy defines how pods are replaced on spec changes. Defaults to Recreate.
+	// +optional
+	Strategy Strategy `json:"strategy,omitempty"`
+	// BlueGreen configures the BlueGreen strategy.
+	// +optional
+	BlueGreen *BlueGreenStrategy `json:"blueGreen,omitempty"`
+	// RevisionHistoryLimit is the number of recorded specs kept for rollbacks. Defaults to 10.
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+	// Suspend stops the controller from changing the children of the MyResource.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// Schedule changes the served message at the times given by cron expressions.
+	// The entry that fired last wins, spec.message is served until any entry fires.
+	// +optional
+	Schedule []ScheduleEntry `json:"schedule,omitempty"`
+	// Routes are matched against the request path, the message is served for requests no route matches.
+	// +optional
+	Routes []Route `json:"routes,omitempty"`
+	// ReloadStrategy defines how message and route changes are delivered to the pod. Defaults to Recreate.
+	// It is ignored by the BlueGreen strategy, which rolls out every change as a new pod set.
+	// +optional
+	ReloadStrategy ReloadStrategy `json:"reloadStrategy,omitempty"`
+	// Placement creates the pods and the Service in spoke clusters instead of the local cluster.
+	// Placed MyResources are rolled out with the Recreate strategy and reload strategy only.
+	// +optional
+	Placement *Placement `json:"placement,omitempty"`
+	// NetworkPolicy restricts the ingress of the echo pods by a NetworkPolicy. Without it the pods only get
+	// a NetworkPolicy if the controller denies ingress by default, which it does unless configured otherwise.
+	// Pods placed in spoke clusters get the same NetworkPolicy, it doesn't admit the controller.
+	// +optional
+	NetworkPolicy *NetworkPolicy `json:"networkPolicy,omitempty"`
+	// Disruption configures the PodDisruptionBudget of the echo pods, which they only get if there is
+	// more than one replica. Pods placed in spoke clusters get none.
+	// +optional
+	Disruption *Disruption `json:"disruption,omitempty"`
+	// SecurityContext overrides the security settings of the echo pods. Overrides that violate the
+	// Pod Security level enforced in the namespace are reported by the PodSecurityViolation condition.
+	// +optional
+	SecurityContext *SecurityContext `json:"securityContext,omitempty"`
 }
 
 type MyResourceStatus struct {
 	PodName string `json:"podName"`
+	// ClassName is the name of the MyResourceClass the children were created with.
+	// +optional
+	ClassName string `json:"className,omitempty"`
+	// ServiceName is the name of the Service exposing the active pods.
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+	// CurrentRevision is the name of the ControllerRevision recording the applied spec.
+	// +optional
+	CurrentRevision string `json:"currentRevision,omitempty"`
+	// Schedule reports the active scheduled message.
+	// +optional
+	Schedule *ScheduleStatus `json:"schedule,omitempty"`
+	// Rollout reports the revisions of the pod sets.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+	// LastError is the last error that stopped the controller from reconciling the MyResource
+	// and that is not retried.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+	// Clusters reports the pods in the spoke clusters of spec.placement.
+	// +optional
+	Clusters []ClusterStatus `json:"clusters,omitempty"`
+	// ObservedMessage is the message that every pod of the revision of status.podName confirmed to serve.
+	// It is only set once all replicas of that revision are ready and serve it.
+	// +optional
+	ObservedMessage string `json:"observedMessage,omitempty"`
+	// PlannedChanges are the changes a controller running in dry-run mode would apply to the children.
+	// +optional
+	PlannedChanges []PlannedChange `json:"plannedChanges,omitempty"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -31,3 +363,198 @@ type MyResourceList struct {
 
 	Items []MyResource `json:"items"`
 }
+
+// PodOverlay is merged into the echo pods.
+type PodOverlay struct {
+	// Labels are added to the pods, they can't override the labels set by the controller.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are added to the pods.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// TopologySpreadConstraints replace the default constraints, which spread replicated pods across zones and nodes.
+	// Constraints without label selector select the pods of the MyResource.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+}
+
+// Exposure configures the Services of a MyResource.
+type Exposure struct {
+	// ServiceType is the type of the Services. Defaults to ClusterIP.
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+	// Port is the port of the Services. Defaults to 80.
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+	// Annotations are added to the Services.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MyResourceClass holds defaults shared by the MyResources referring to it, similar to a StorageClass.
+type MyResourceClass struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MyResourceClassSpec `json:"spec"`
+}
+
+type MyResourceClassSpec struct {
+	// Image is the image of the echo pods, it overrides the image the controller is configured with.
+	// +optional
+	Image string `json:"image,omitempty"`
+	// Port is the port the echo server listens on. Defaults to 8080.
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+	// Resources are the compute resources of the echo container.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// PodOverlay is merged into the echo pods.
+	// +optional
+	PodOverlay *PodOverlay `json:"podOverlay,omitempty"`
+	// Exposure configures the Services.
+	// +optional
+	Exposure *Exposure `json:"exposure,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type MyResourceClassList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MyResourceClass `json:"items"`
+}
+
+// MyResourceTemplate describes the MyResources created by a MyResourceSet.
+type MyResourceTemplate struct {
+	// Labels and annotations of the MyResources, their name is the name of the MyResourceSet.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec MyResourceSpec `json:"spec"`
+}
+
+// NamespaceStatus reports the MyResource of a MyResourceSet in one namespace.
+type NamespaceStatus struct {
+	Namespace string `json:"namespace"`
+	Ready     bool   `json:"ready"`
+	// Message explains why the MyResource isn't ready.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MyResourceSet creates a MyResource from the same template in every namespace matching a selector.
+type MyResourceSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MyResourceSetSpec   `json:"spec"`
+	Status MyResourceSetStatus `json:"status"`
+}
+
+type MyResourceSetSpec struct {
+	// NamespaceSelector selects the namespaces a MyResource is created in.
+	NamespaceSelector metav1.LabelSelector `json:"namespaceSelector"`
+	// Template is applied to the MyResources whenever they differ from it. They can be suspended and
+	// promoted through annotations, but not rolled back, roll back the template instead.
+	Template MyResourceTemplate `json:"template"`
+}
+
+type MyResourceSetStatus struct {
+	// ObservedGeneration is the generation of the MyResourceSet the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Namespaces is the number of namespaces the selector matches.
+	// +optional
+	Namespaces int32 `json:"namespaces,omitempty"`
+	// ReadyNamespaces is the number of namespaces whose MyResource is ready.
+	// +optional
+	ReadyNamespaces int32 `json:"readyNamespaces,omitempty"`
+	// NamespaceStatuses reports the MyResource in each matching namespace.
+	// +optional
+	NamespaceStatuses []NamespaceStatus `json:"namespaceStatuses,omitempty"`
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type MyResourceSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MyResourceSet `json:"items"`
+}
+
+// QuotaResources are amounts of echo workloads in a namespace.
+type QuotaResources struct {
+	// MyResources is the number of MyResources.
+	// +optional
+	MyResources *int32 `json:"myresources,omitempty"`
+	// Replicas is the sum of the replicas of the MyResources.
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+	// CPU is the sum of the CPU requested by the echo pods. Only the requests set by MyResourceClasses count.
+	// +optional
+	CPU *resource.Quantity `json:"cpu,omitempty"`
+	// Memory is the sum of the memory requested by the echo pods. Only the requests set by MyResourceClasses count.
+	// +optional
+	Memory *resource.Quantity `json:"memory,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MyResourceQuota limits the echo workloads in its namespace. MyResources are admitted in the order of their
+// creation, a MyResource that doesn't fit next to the ones created before it gets no children. MyResources with
+// a placement run their pods in spoke clusters, they don't count.
+type MyResourceQuota struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MyResourceQuotaSpec   `json:"spec"`
+	Status MyResourceQuotaStatus `json:"status"`
+}
+
+type MyResourceQuotaSpec struct {
+	// Hard are the limits of the namespace, unset limits are unlimited.
+	Hard QuotaResources `json:"hard"`
+}
+
+type MyResourceQuotaStatus struct {
+	// ObservedGeneration is the generation of the MyResourceQuota the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Used is the usage of the admitted MyResources.
+	// +optional
+	Used QuotaResources `json:"used,omitempty"`
+	// RejectedMyResources is the number of MyResources that don't fit into the quotas of the namespace.
+	// +optional
+	RejectedMyResources int32 `json:"rejectedMyResources,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type MyResourceQuotaList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []MyResourceQuota `json:"items"`
+}
@@ -1,17 +1,88 @@
 package myresource
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	myresourceV1Alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/logging"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/spoke"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
 )
 
-func AddControllerToManager(mgr manager.Manager) error {
-	controller, err := CreateController(mgr.GetClient())
+func AddControllerToManager(mgr manager.Manager, options Options) error {
+	if options.EventRecorder == nil {
+		options.EventRecorder = mgr.GetEventRecorderFor("myresource-controller")
+	}
+	var secretCache cache.Cache
+	if options.SpokeNamespace != "" {
+		var err error
+		secretCache, err = newSecretCache(mgr, options.SpokeNamespace)
+		if err != nil {
+			return err
+		}
+		if options.Spokes == nil {
+			hub, err := client.NewDelegatingClient(client.NewDelegatingClientInput{CacheReader: secretCache, Client: mgr.GetClient()})
+			if err != nil {
+				return err
+			}
+			options.Spokes = spoke.NewSecretClients(tracing.WrapClient(hub), options.SpokeNamespace)
+		}
+	}
+	controller, err := CreateController(tracing.WrapClient(mgr.GetClient()), options)
 	if err != nil {
 		return err
 	}
+	if options.Recorder != nil {
+		controller = options.Recorder.WrapReconciler(controller)
+	}
+	logger := mgr.GetLogger().WithName("myresource")
+
+	controllerBuilder := builder.ControllerManagedBy(mgr).
+		For(&myresourceV1Alpha1.MyResource{}).
+		Owns(&corev1.Pod{}).
+		Owns(&corev1.ConfigMap{}).
+		Owns(&corev1.Service{}).
+		Owns(&networkingv1.NetworkPolicy{}).
+		Owns(&policyv1.PodDisruptionBudget{}).
+		Watches(&source.Kind{Type: &myresourceV1Alpha1.MyResourceClass{}},
+			handler.EnqueueRequestsFromMapFunc(myResourcesOfClass(mgr.GetClient(), logger))).
+		Watches(&source.Kind{Type: &myresourceV1Alpha1.MyResourceQuota{}},
+			handler.EnqueueRequestsFromMapFunc(myResourcesOfQuota(mgr.GetClient(), logger))).
+		Watches(&source.Kind{Type: &corev1.Namespace{}},
+			handler.EnqueueRequestsFromMapFunc(myResourcesOfNamespace(mgr.GetClient(), logger)),
+			builder.WithPredicates(podSecurityLevelChanged()))
+	if secretCache != nil {
+		controllerBuilder = controllerBuilder.Watches(source.NewKindWithCache(&corev1.Secret{}, secretCache),
+			handler.EnqueueRequestsFromMapFunc(myResourcesOfSpoke(mgr.GetClient(), logger, options.SpokeNamespace)))
+		if secretClients, ok := options.Spokes.(*spoke.SecretClients); ok {
+			controllerBuilder = controllerBuilder.Watches(source.NewKindWithCache(&corev1.Secret{}, secretCache),
+				forgetDeletedSpokes(secretClients))
+		}
+	}
 
-	return builder.ControllerManagedBy(mgr).For(&myresourceV1Alpha1.MyResource{}).Complete(controller)
+	return controllerBuilder.
+		WithOptions(options.ControllerOptions()).
+		Complete(tracing.WrapReconciler("MyResource", logging.WrapReconciler(logging.KeyMyResource, controller)))
+}
+
+// newSecretCache returns a cache of the Secrets in the spoke namespace and adds it to mgr. The cache of mgr
+// would list and watch the Secrets of all namespaces, the controller is only granted access to this one.
+func newSecretCache(mgr manager.Manager, namespace string) (cache.Cache, error) {
+	secretCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:    mgr.GetScheme(),
+		Mapper:    mgr.GetRESTMapper(),
+		Namespace: namespace,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return secretCache, mgr.Add(secretCache)
 }
@@ -0,0 +1,216 @@
+package myresource
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"strconv"
+	"strings"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+// resolveClass returns the MyResourceClass named by spec.className of myresource, or the default class
+// if it names none. It returns nil if myresource has no class.
+func resolveClass(ctx context.Context, c client.Client, myresource *v1alpha1.MyResource) (*v1alpha1.MyResourceClass, error) {
+	if myresource.Spec.ClassName == "" {
+		return defaultClass(ctx, c)
+	}
+
+	class := &v1alpha1.MyResourceClass{}
+	err := c.Get(ctx, types.NamespacedName{Name: myresource.Spec.ClassName}, class)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// the class watch triggers a reconcile once the class is created
+			return nil, reconcileutil.Terminal(fmt.Errorf("MyResourceClass %q not found", myresource.Spec.ClassName))
+		}
+		return nil, reconcileutil.Transient(fmt.Errorf("failed to get MyResourceClass %q: %w", myresource.Spec.ClassName, err))
+	}
+	return class, nil
+}
+
+// defaultClass returns the MyResourceClass annotated as default. If several are, the newest one wins,
+// like it does for StorageClasses.
+func defaultClass(ctx context.Context, c client.Client) (*v1alpha1.MyResourceClass, error) {
+	classes := &v1alpha1.MyResourceClassList{}
+	err := c.List(ctx, classes)
+	if err != nil {
+		return nil, reconcileutil.Transient(fmt.Errorf("failed to list MyResourceClasses: %w", err))
+	}
+
+	var result *v1alpha1.MyResourceClass
+	for i := range classes.Items {
+		class := &classes.Items[i]
+		if !isDefaultClass(class) {
+			continue
+		}
+		if result == nil || result.CreationTimestamp.Before(&class.CreationTimestamp) ||
+			(result.CreationTimestamp.Equal(&class.CreationTimestamp) && class.Name < result.Name) {
+			result = class
+		}
+	}
+	return result, nil
+}
+
+func isDefaultClass(class *v1alpha1.MyResourceClass) bool {
+	isDefault, _ := strconv.ParseBool(class.Annotations[v1alpha1.DefaultClassAnnotation])
+	return isDefault
+}
+
+// validatePodOverlay returns an error if the pod overlay of class sets fields the echo pods can't be created with,
+// so that they are rejected before the Pod Security level of the namespace is checked.
+func validatePodOverlay(class *v1alpha1.MyResourceClass) error {
+	if class == nil || class.Spec.PodOverlay == nil {
+		return nil
+	}
+	overlay := class.Spec.PodOverlay
+
+	var invalid []string
+	if name := overlay.ServiceAccountName; name != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(name) {
+			invalid = append(invalid, fmt.Sprintf("serviceAccountName %q: %s", name, msg))
+		}
+	}
+	if name := overlay.PriorityClassName; name != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(name) {
+			invalid = append(invalid, fmt.Sprintf("priorityClassName %q: %s", name, msg))
+		}
+	}
+	for _, key := range sortedKeys(overlay.Labels) {
+		for _, msg := range validation.IsQualifiedName(key) {
+			invalid = append(invalid, fmt.Sprintf("label %q: %s", key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(overlay.Labels[key]) {
+			invalid = append(invalid, fmt.Sprintf("label %q: %s", key, msg))
+		}
+	}
+	for _, key := range sortedKeys(overlay.Annotations) {
+		for _, msg := range validation.IsQualifiedName(strings.ToLower(key)) {
+			invalid = append(invalid, fmt.Sprintf("annotation %q: %s", key, msg))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("MyResourceClass %q has an invalid podOverlay: %s", class.Name, strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// applyClass merges class over the defaults of the echo pod template of myresource. Labels and annotations of the
+// pod overlay are not part of the pod revision, changing them only affects pods created afterwards.
+func applyClass(pod *corev1.Pod, myresource *v1alpha1.MyResource, class *v1alpha1.MyResourceClass) {
+	if class == nil {
+		return
+	}
+
+	container := &pod.Spec.Containers[0]
+	if class.Spec.Image != "" {
+		container.Image = class.Spec.Image
+	}
+	if class.Spec.Port != nil {
+		port := *class.Spec.Port
+		container.Ports[0].ContainerPort = port
+		for i := range container.Env {
+			if container.Env[i].Name == "PORT" {
+				container.Env[i].Value = strconv.Itoa(int(port))
+			}
+		}
+	}
+	if class.Spec.Resources != nil {
+		container.Resources = *class.Spec.Resources.DeepCopy()
+	}
+
+	overlay := class.Spec.PodOverlay
+	if overlay == nil {
+		return
+	}
+	labels := map[string]string{}
+	for key, value := range overlay.Labels {
+		labels[key] = value
+	}
+	// the label contract of the controller takes precedence
+	for key, value := range pod.Labels {
+		labels[key] = value
+	}
+	pod.Labels = labels
+	if len(overlay.Annotations) > 0 {
+		pod.Annotations = map[string]string{}
+		for key, value := range overlay.Annotations {
+			pod.Annotations[key] = value
+		}
+	}
+	if len(overlay.NodeSelector) > 0 {
+		pod.Spec.NodeSelector = map[string]string{}
+		for key, value := range overlay.NodeSelector {
+			pod.Spec.NodeSelector[key] = value
+		}
+	}
+	pod.Spec.Tolerations = append(pod.Spec.Tolerations, overlay.Tolerations...)
+	pod.Spec.PriorityClassName = overlay.PriorityClassName
+	pod.Spec.ServiceAccountName = overlay.ServiceAccountName
+	if len(overlay.TopologySpreadConstraints) > 0 {
+		pod.Spec.TopologySpreadConstraints = make([]corev1.TopologySpreadConstraint, 0, len(overlay.TopologySpreadConstraints))
+		for _, constraint := range overlay.TopologySpreadConstraints {
+			constraint := *constraint.DeepCopy()
+			if constraint.LabelSelector == nil {
+				constraint.LabelSelector = &metav1.LabelSelector{MatchLabels: childLabels(myresource)}
+			}
+			pod.Spec.TopologySpreadConstraints = append(pod.Spec.TopologySpreadConstraints, constraint)
+		}
+	}
+}
+
+// exposureOf returns the Service settings of class, or the defaults if class has none.
+func exposureOf(class *v1alpha1.MyResourceClass) *v1alpha1.Exposure {
+	if class == nil || class.Spec.Exposure == nil {
+		return &v1alpha1.Exposure{}
+	}
+	return class.Spec.Exposure
+}
+
+// myResourcesOfClass maps a MyResourceClass to the MyResources using it, so that class changes are rolled out.
+// MyResources without className are affected if the class is or was their default class.
+func myResourcesOfClass(c client.Client, logger logr.Logger) func(obj client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		class, ok := obj.(*v1alpha1.MyResourceClass)
+		if !ok {
+			return nil
+		}
+
+		myresources := &v1alpha1.MyResourceList{}
+		err := c.List(context.Background(), myresources)
+		if err != nil {
+			logger.Error(err, "failed to list MyResources of MyResourceClass", "myresourceclass", class.Name)
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, myresource := range myresources.Items {
+			uses := myresource.Spec.ClassName == class.Name ||
+				(myresource.Spec.ClassName == "" && (isDefaultClass(class) || myresource.Status.ClassName == class.Name))
+			if uses {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: myresource.Name, Namespace: myresource.Namespace},
+				})
+			}
+		}
+		return requests
+	}
+}
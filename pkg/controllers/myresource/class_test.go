@@ -0,0 +1,259 @@
+package myresource
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"testing"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func newClass(name string, spec v1alpha1.MyResourceClassSpec) *v1alpha1.MyResourceClass {
+	return &v1alpha1.MyResourceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       spec,
+	}
+}
+
+func defaultClassNamed(name string, created time.Time) *v1alpha1.MyResourceClass {
+	class := newClass(name, v1alpha1.MyResourceClassSpec{})
+	class.Annotations = map[string]string{v1alpha1.DefaultClassAnnotation: "true"}
+	class.CreationTimestamp = metav1.NewTime(created)
+	return class
+}
+
+func TestApplyClass(t *testing.T) {
+	myresource := kittesting.NewMyResource(testNamespace, "echo").WithReplicas(2).Build()
+	port := int32(9090)
+	resources := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+	}
+	class := newClass("custom", v1alpha1.MyResourceClassSpec{
+		Image:     "example.com/echoserver:custom",
+		Port:      &port,
+		Resources: resources,
+		PodOverlay: &v1alpha1.PodOverlay{
+			Labels:       map[string]string{"team": "echo", labelController: "hijacked"},
+			Annotations:  map[string]string{"example.com/owner": "echo"},
+			NodeSelector: map[string]string{"disk": "ssd"},
+			Tolerations:  []corev1.Toleration{{Key: "dedicated", Operator: corev1.TolerationOpExists}},
+			TopologySpreadConstraints: []corev1.TopologySpreadConstraint{{
+				MaxSkew:           1,
+				TopologyKey:       "kubernetes.io/hostname",
+				WhenUnsatisfiable: corev1.ScheduleAnyway,
+			}},
+			PriorityClassName:  "high",
+			ServiceAccountName: "echo",
+		},
+	})
+
+	pod := newPod(myresource, testImage)
+	applyClass(pod, myresource, class)
+
+	container := pod.Spec.Containers[0]
+	if container.Image != class.Spec.Image {
+		t.Errorf("image is %q, expected the image of the class", container.Image)
+	}
+	if container.Ports[0].ContainerPort != port || !hasEnv(container.Env, "PORT", "9090") {
+		t.Errorf("port %d and env %+v don't match the port of the class", container.Ports[0].ContainerPort, container.Env)
+	}
+	if !equality.Semantic.DeepEqual(container.Resources, *resources) {
+		t.Errorf("resources are %+v, expected the resources of the class", container.Resources)
+	}
+	if pod.Labels["team"] != "echo" || pod.Labels[labelController] != childLabels(myresource)[labelController] {
+		t.Errorf("labels are %v, expected the overlay labels without overriding the controller labels", pod.Labels)
+	}
+	if pod.Annotations["example.com/owner"] != "echo" || pod.Spec.NodeSelector["disk"] != "ssd" {
+		t.Errorf("annotations %v and node selector %v don't match the overlay", pod.Annotations, pod.Spec.NodeSelector)
+	}
+	if len(pod.Spec.Tolerations) != 1 || pod.Spec.PriorityClassName != "high" || pod.Spec.ServiceAccountName != "echo" {
+		t.Errorf("pod spec %+v doesn't match the overlay", pod.Spec)
+	}
+	constraints := pod.Spec.TopologySpreadConstraints
+	if len(constraints) != 1 || constraints[0].LabelSelector == nil ||
+		!equality.Semantic.DeepEqual(constraints[0].LabelSelector.MatchLabels, childLabels(myresource)) {
+		t.Errorf("topology spread constraints are %+v, expected the overlay selecting the pods of the MyResource", constraints)
+	}
+}
+
+func TestApplyClassKeepsDefaults(t *testing.T) {
+	myresource := kittesting.NewMyResource(testNamespace, "echo").WithReplicas(2).Build()
+	for name, class := range map[string]*v1alpha1.MyResourceClass{
+		"no class":    nil,
+		"empty class": newClass("empty", v1alpha1.MyResourceClassSpec{}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			pod := newPod(myresource, testImage)
+			applyClass(pod, myresource, class)
+
+			if expected := newPod(myresource, testImage); !equality.Semantic.DeepEqual(pod, expected) {
+				t.Errorf("class changed the pod template to %+v", pod)
+			}
+		})
+	}
+}
+
+func TestResolveClass(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		className string
+		classes   []*v1alpha1.MyResourceClass
+		expected  string
+	}{
+		{
+			name: "no class",
+		},
+		{
+			name:      "named class",
+			className: "custom",
+			classes:   []*v1alpha1.MyResourceClass{newClass("custom", v1alpha1.MyResourceClassSpec{}), defaultClassNamed("default", now)},
+			expected:  "custom",
+		},
+		{
+			name:     "default class",
+			classes:  []*v1alpha1.MyResourceClass{newClass("custom", v1alpha1.MyResourceClassSpec{}), defaultClassNamed("default", now)},
+			expected: "default",
+		},
+		{
+			name:     "newest default class wins",
+			classes:  []*v1alpha1.MyResourceClass{defaultClassNamed("old", now), defaultClassNamed("new", now.Add(time.Hour))},
+			expected: "new",
+		},
+		{
+			name:     "first name wins among default classes of the same age",
+			classes:  []*v1alpha1.MyResourceClass{defaultClassNamed("b", now), defaultClassNamed("a", now)},
+			expected: "a",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// the classes are stored as they are, their creation timestamps decide between default classes
+			objs := make([]client.Object, 0, len(test.classes))
+			for _, class := range test.classes {
+				objs = append(objs, class)
+			}
+			cluster := newTestCluster(t, objs...)
+			myresource := kittesting.NewMyResource(testNamespace, "echo").WithClassName(test.className).Build()
+
+			class, err := resolveClass(context.Background(), cluster, myresource)
+			if err != nil {
+				t.Fatalf("failed to resolve class: %v", err)
+			}
+			name := ""
+			if class != nil {
+				name = class.Name
+			}
+			if name != test.expected {
+				t.Errorf("resolved class %q, expected %q", name, test.expected)
+			}
+		})
+	}
+}
+
+func TestResolveMissingClass(t *testing.T) {
+	cluster := newTestCluster(t)
+	myresource := kittesting.NewMyResource(testNamespace, "echo").WithClassName("missing").Build()
+
+	_, err := resolveClass(context.Background(), cluster, myresource)
+	if !reconcileutil.IsTerminal(err) {
+		t.Errorf("missing class returned %v, expected a terminal error", err)
+	}
+}
+
+func TestDefaultClassIsApplied(t *testing.T) {
+	port := int32(8443)
+	class := defaultClassNamed("default", time.Now())
+	class.Spec.Exposure = &v1alpha1.Exposure{ServiceType: corev1.ServiceTypeNodePort, Port: &port}
+	class.Spec.Image = "example.com/echoserver:default"
+	cluster := newTestCluster(t, class, kittesting.NewMyResource(testNamespace, "echo").Build())
+	controller := newTestController(t, cluster, nil)
+
+	reconcileMyResource(t, controller, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	if myresource.Status.ClassName != "default" {
+		t.Errorf("status.className is %q, expected the default class", myresource.Status.ClassName)
+	}
+	pods := listPods(t, cluster)
+	if len(pods) != 1 || pods[0].Spec.Containers[0].Image != class.Spec.Image {
+		t.Errorf("the pods don't run the image of the default class: %+v", pods)
+	}
+	service := &corev1.Service{}
+	err := cluster.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: mainServiceName(myresource)}, service)
+	if err != nil {
+		t.Fatalf("failed to get Service: %v", err)
+	}
+	if service.Spec.Type != corev1.ServiceTypeNodePort || service.Spec.Ports[0].Port != port {
+		t.Errorf("Service has type %s and port %d, expected the exposure of the class", service.Spec.Type, service.Spec.Ports[0].Port)
+	}
+}
+
+func TestValidatePodOverlay(t *testing.T) {
+	tests := []struct {
+		name    string
+		overlay *v1alpha1.PodOverlay
+		invalid bool
+	}{
+		{name: "no overlay"},
+		{
+			name: "valid overlay",
+			overlay: &v1alpha1.PodOverlay{
+				Labels:             map[string]string{"example.com/team": "echo"},
+				Annotations:        map[string]string{"example.com/Owner": "echo"},
+				PriorityClassName:  "high",
+				ServiceAccountName: "echo",
+			},
+		},
+		{name: "invalid service account", overlay: &v1alpha1.PodOverlay{ServiceAccountName: "Echo_SA"}, invalid: true},
+		{name: "invalid priority class", overlay: &v1alpha1.PodOverlay{PriorityClassName: "high/priority"}, invalid: true},
+		{name: "invalid label key", overlay: &v1alpha1.PodOverlay{Labels: map[string]string{"a/b/c": "echo"}}, invalid: true},
+		{name: "invalid label value", overlay: &v1alpha1.PodOverlay{Labels: map[string]string{"team": "echo team"}}, invalid: true},
+		{name: "invalid annotation key", overlay: &v1alpha1.PodOverlay{Annotations: map[string]string{"-owner": "echo"}}, invalid: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validatePodOverlay(newClass("custom", v1alpha1.MyResourceClassSpec{PodOverlay: test.overlay}))
+			if (err != nil) != test.invalid {
+				t.Errorf("validatePodOverlay returned %v, expected invalid %t", err, test.invalid)
+			}
+		})
+	}
+}
+
+func TestInvalidPodOverlayIsRejectedBeforePodSecurity(t *testing.T) {
+	class := defaultClassNamed("default", time.Now())
+	class.Spec.PodOverlay = &v1alpha1.PodOverlay{ServiceAccountName: "Echo_SA"}
+	cluster := newTestCluster(t, class, kittesting.NewMyResource(testNamespace, "echo").Build())
+	namespace := &corev1.Namespace{}
+	err := cluster.Get(context.Background(), types.NamespacedName{Name: testNamespace}, namespace)
+	if err != nil {
+		t.Fatalf("failed to get namespace: %v", err)
+	}
+	namespace.Labels = map[string]string{labelPodSecurityEnforce: podSecurityRestricted}
+	err = cluster.Update(context.Background(), namespace)
+	if err != nil {
+		t.Fatalf("failed to label namespace: %v", err)
+	}
+	controller := newTestController(t, cluster, nil)
+
+	reconcileMyResource(t, controller, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	if !strings.Contains(myresource.Status.LastError, "serviceAccountName") {
+		t.Errorf("status.lastError is %q, expected the invalid serviceAccountName", myresource.Status.LastError)
+	}
+	if pods := listPods(t, cluster); len(pods) > 0 {
+		t.Errorf("got %d pods of an invalid class", len(pods))
+	}
+}
@@ -0,0 +1,117 @@
+package myresource
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"path"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+const (
+	messageKey       = "message"
+	messageVolume    = "message"
+	messageMountPath = "/etc/echoserver"
+)
+
+var (
+	messageFilePath = path.Join(messageMountPath, messageKey)
+	routesFilePath  = path.Join(messageMountPath, routesKey)
+)
+
+// usesHotReload reports whether the message of myresource is delivered through a mounted ConfigMap.
+func usesHotReload(myresource *v1alpha1.MyResource) bool {
+	return myresource.Spec.ReloadStrategy == v1alpha1.ReloadStrategyHotReload && !usesBlueGreen(myresource) && !isPlaced(myresource)
+}
+
+func configMapName(myresource *v1alpha1.MyResource) string {
+	return childName(myresource.Name, "message")
+}
+
+// reconcileConfigMap creates or updates the ConfigMap holding the message and routes of myresource.
+func (c *Controller) reconcileConfigMap(ctx context.Context, myresource *v1alpha1.MyResource) error {
+	name := configMapName(myresource)
+	configMap := &corev1.ConfigMap{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: name, Namespace: myresource.Namespace}, configMap)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcileutil.Transient(fmt.Errorf("failed to get ConfigMap %q: %w", name, err))
+		}
+
+		log.FromContext(ctx).Info("creating ConfigMap", "configMap", name)
+		err = c.client.Create(ctx, newConfigMap(myresource, name))
+		if err != nil {
+			return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to create ConfigMap %q: %w", name, err))
+		}
+		return nil
+	}
+
+	owned, err := c.claim(ctx, myresource, configMap)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return &nameConflictError{obj: configMap}
+	}
+
+	data := configMapData(myresource)
+	if equality.Semantic.DeepEqual(configMap.Data, data) {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("updating message and routes in ConfigMap", "configMap", name)
+	configMap.Data = data
+	err = c.client.Update(ctx, configMap)
+	if err != nil {
+		return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to update ConfigMap %q: %w", name, err))
+	}
+	return nil
+}
+
+// deleteConfigMap deletes the ConfigMap of myresource if it is controlled by myresource.
+func (c *Controller) deleteConfigMap(ctx context.Context, myresource *v1alpha1.MyResource) error {
+	name := configMapName(myresource)
+	configMap := &corev1.ConfigMap{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: name, Namespace: myresource.Namespace}, configMap)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return reconcileutil.Transient(fmt.Errorf("failed to get ConfigMap %q: %w", name, err))
+	}
+
+	if !metav1.IsControlledBy(configMap, myresource) {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("deleting ConfigMap", "configMap", name)
+	return c.deleteChild(ctx, configMap)
+}
+
+func newConfigMap(myresource *v1alpha1.MyResource, name string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: myresource.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(myresource, v1alpha1.SchemeGroupVersion.WithKind("MyResource")),
+			},
+			Labels: childLabels(myresource),
+		},
+		Data: configMapData(myresource),
+	}
+}
+
+func configMapData(myresource *v1alpha1.MyResource) map[string]string {
+	return map[string]string{
+		messageKey: myresource.Spec.Message,
+		routesKey:  routeTable(myresource),
+	}
+}
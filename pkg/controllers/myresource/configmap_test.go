@@ -0,0 +1,96 @@
+package myresource
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func getConfigMap(c *kittesting.Cluster, myresource *v1alpha1.MyResource) (*corev1.ConfigMap, error) {
+	configMap := &corev1.ConfigMap{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: configMapName(myresource)}, configMap)
+	return configMap, err
+}
+
+func TestHotReloadUpdatesConfigMapInPlace(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").
+		WithMessage("hello").
+		WithReloadStrategy(v1alpha1.ReloadStrategyHotReload).
+		Build())
+	controller := newTestController(t, cluster, nil)
+
+	reconcileMyResource(t, controller, "echo")
+	myresource := getMyResource(t, cluster, "echo")
+	configMap, err := getConfigMap(cluster, myresource)
+	if err != nil {
+		t.Fatalf("failed to get ConfigMap: %v", err)
+	}
+	if configMap.Data[messageKey] != "hello" {
+		t.Errorf("ConfigMap holds message %q, expected %q", configMap.Data[messageKey], "hello")
+	}
+	pods := listPods(t, cluster)
+	if len(pods) != 1 {
+		t.Fatalf("got %d pods, expected 1", len(pods))
+	}
+
+	myresource.Spec.Message = "goodbye"
+	if err := cluster.Update(context.Background(), myresource); err != nil {
+		t.Fatalf("failed to update MyResource: %v", err)
+	}
+	reconcileMyResource(t, controller, "echo")
+
+	configMap, err = getConfigMap(cluster, myresource)
+	if err != nil {
+		t.Fatalf("failed to get ConfigMap: %v", err)
+	}
+	if configMap.Data[messageKey] != "goodbye" {
+		t.Errorf("ConfigMap holds message %q, expected %q", configMap.Data[messageKey], "goodbye")
+	}
+	if updated := listPods(t, cluster); len(updated) != 1 || updated[0].UID != pods[0].UID {
+		t.Errorf("the pod was replaced although the message is hot reloaded: %+v", updated)
+	}
+}
+
+func TestConfigMapIsDeletedWithoutHotReload(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").
+		WithMessage("hello").
+		WithReloadStrategy(v1alpha1.ReloadStrategyHotReload).
+		Build())
+	controller := newTestController(t, cluster, nil)
+
+	reconcileMyResource(t, controller, "echo")
+	myresource := getMyResource(t, cluster, "echo")
+	if _, err := getConfigMap(cluster, myresource); err != nil {
+		t.Fatalf("failed to get ConfigMap: %v", err)
+	}
+
+	myresource.Spec.ReloadStrategy = v1alpha1.ReloadStrategyRecreate
+	if err := cluster.Update(context.Background(), myresource); err != nil {
+		t.Fatalf("failed to update MyResource: %v", err)
+	}
+	reconcileMyResource(t, controller, "echo")
+
+	if _, err := getConfigMap(cluster, myresource); !apierrors.IsNotFound(err) {
+		t.Errorf("the ConfigMap was kept after hot reload was switched off: %v", err)
+	}
+}
+
+func TestConfigMapOfOthersIsKept(t *testing.T) {
+	myresource := kittesting.NewMyResource(testNamespace, "echo").WithMessage("hello").Build()
+	foreign := &corev1.ConfigMap{}
+	foreign.Namespace = testNamespace
+	foreign.Name = configMapName(myresource)
+	cluster := newTestCluster(t, myresource, foreign)
+	controller := newTestController(t, cluster, nil)
+
+	reconcileMyResource(t, controller, "echo")
+
+	if _, err := getConfigMap(cluster, myresource); err != nil {
+		t.Errorf("a ConfigMap not controlled by the MyResource was deleted: %v", err)
+	}
+}
@@ -2,26 +2,82 @@ package myresource
 
 import (
 	"context"
-	"fmt"
+	goerrors "errors"
+	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/klog/v2"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strconv"
 	"time"
 
 	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/debug"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/imagepolicy"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/logging"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/spoke"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+)
+
+const (
+	// nameConflictRequeueInterval is how often a MyResource whose child name is taken is checked again,
+	// foreign objects don't trigger a reconcile of the MyResource.
+	nameConflictRequeueInterval = time.Minute
+)
+
+const (
+	echoServerPort = 8080
 )
 
 type Controller struct {
-	client client.Client
+	client               client.Client
+	clock                clock.Clock
+	echoServerImage      string
+	imagePullSecrets     []corev1.LocalObjectReference
+	spokes               spoke.Clients
+	denyIngressByDefault bool
+	controllerPeer       *v1alpha1.NetworkPolicyPeer
+	imagePolicy          imagepolicy.Policy
 }
 
-func CreateController(client client.Client) (reconcile.Reconciler, error) {
+func CreateController(client client.Client, options Options) (reconcile.Reconciler, error) {
+	err := options.Validate()
+	if err != nil {
+		return nil, err
+	}
+	if options.Clock == nil {
+		options.Clock = clock.RealClock{}
+	}
+	if options.Spokes == nil && options.SpokeNamespace != "" {
+		options.Spokes = spoke.NewSecretClients(client, options.SpokeNamespace)
+	}
+
 	controller := Controller{
-		client: client,
+		client:               client,
+		clock:                options.Clock,
+		echoServerImage:      options.EchoServerImage,
+		imagePullSecrets:     localObjectReferences(options.EchoServerImagePullSecrets),
+		spokes:               options.Spokes,
+		denyIngressByDefault: options.DenyIngressByDefault,
+		controllerPeer:       options.controllerPeer(),
+		imagePolicy:          options.ImagePolicy,
+	}
+	if options.DryRun {
+		if options.EventRecorder == nil {
+			options.EventRecorder = &record.FakeRecorder{}
+		}
+		controller.client = &dryRunClient{Client: client}
+		if controller.spokes != nil {
+			controller.spokes = dryRunSpokes{spokes: controller.spokes}
+		}
+		return &dryRunReconciler{controller: &controller, client: client, events: options.EventRecorder}, nil
 	}
 	return &controller, nil
 }
@@ -30,79 +86,271 @@ func (c *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	myresource := &v1alpha1.MyResource{}
 	err := c.client.Get(ctx, req.NamespacedName, myresource)
 	if err != nil {
+		if errors.IsNotFound(err) {
+			log.FromContext(ctx).V(1).Info("MyResource no longer exists")
+			debug.Forget(ctx)
+			return reconcile.Result{}, nil
+		}
 		return reconcile.Result{}, err
 	}
+	tracing.RecordObject(ctx, myresource)
+	ctx, logger := logging.WithGeneration(ctx, myresource)
 
-	klog.V(4).Infof("reconciling MyResource %q, message=%q",
-		req.NamespacedName,
-		myresource.Spec.Message)
+	if myresource.DeletionTimestamp != nil {
+		err = c.removePlacement(ctx, myresource)
+		if reconcileutil.IsTerminal(err) {
+			logger.Error(err, "finalizing MyResource failed")
+			return reconcile.Result{}, c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+				status.LastError = err.Error()
+			})
+		}
+		return reconcile.Result{}, err
+	}
+
+	if suspended, reason := isSuspended(logger, myresource); suspended {
+		logger.V(1).Info("reconciliation of MyResource is suspended", "reason", reason)
+		return reconcile.Result{}, c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:               v1alpha1.ConditionSuspended,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: myresource.Generation,
+				Reason:             reason,
+				Message:            "reconciliation is suspended, children are left unchanged",
+			})
+		})
+	}
+
+	if meta.IsStatusConditionTrue(myresource.Status.Conditions, v1alpha1.ConditionSuspended) {
+		logger.Info("resuming reconciliation of MyResource")
+		err = c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:               v1alpha1.ConditionSuspended,
+				Status:             metav1.ConditionFalse,
+				ObservedGeneration: myresource.Generation,
+				Reason:             "Resumed",
+				Message:            "reconciliation is active",
+			})
+		})
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
 
-	podName := fmt.Sprintf("%s-pod", myresource.Name)
-	pod := &corev1.Pod{}
-	err = c.client.Get(ctx, types.NamespacedName{Name: podName, Namespace: req.Namespace}, pod)
+	if _, ok := myresource.Annotations[v1alpha1.RollbackToAnnotation]; ok {
+		err = c.rollback(ctx, myresource)
+		if reconcileutil.IsTerminal(err) {
+			logger.Error(err, "rolling back MyResource failed")
+			return reconcile.Result{}, c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+				status.LastError = err.Error()
+			})
+		}
+		// the update of the spec triggers the next reconcile
+		return reconcile.Result{}, err
+	}
+
+	logger.V(1).Info("reconciling MyResource", "message", myresource.Spec.Message)
+
+	err = validateTemplate(myresource)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			klog.Infof("creating pod '%q'", podName)
-			err := c.client.Create(ctx, newPod(myresource, podName))
-			if err != nil {
-				return reconcile.Result{}, err
-			}
-
-			err = c.updateMyResource(ctx, myresource, podName)
-			if err != nil {
-				return reconcile.Result{}, err
-			}
+		logger.Error(err, "MyResource has an invalid message template")
+		return reconcile.Result{}, c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+			setInvalidTemplate(status, myresource, err)
+		})
+	}
+
+	result, err := c.reconcileChildren(ctx, myresource)
+	if reconcileutil.IsTerminal(err) {
+		logger.Error(err, "reconciling MyResource failed permanently")
+		return reconcile.Result{}, c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+			status.LastError = err.Error()
+		})
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return result, c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+		status.LastError = ""
+		clearInvalidTemplate(status)
+	})
+}
+
+func (c *Controller) reconcileChildren(ctx context.Context, myresource *v1alpha1.MyResource) (reconcile.Result, error) {
+	result, status, err := c.reconcileOwnedChildren(ctx, myresource)
+	var conflict *nameConflictError
+	if goerrors.As(err, &conflict) {
+		log.FromContext(ctx).Info("child of MyResource is owned by someone else", "child", conflict.obj.GetName())
+		return reconcile.Result{RequeueAfter: nameConflictRequeueInterval}, c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+			setNameConflict(status, myresource, conflict.obj)
+		})
+	}
+	var exceeded *quotaExceededError
+	if goerrors.As(err, &exceeded) {
+		log.FromContext(ctx).Info("MyResource exceeds a MyResourceQuota", "reason", exceeded.reason)
+		return reconcile.Result{}, c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+			setQuotaExceeded(status, myresource, exceeded)
+		})
+	}
+	var policyViolation *imagePolicyError
+	if goerrors.As(err, &policyViolation) {
+		log.FromContext(ctx).Info("pods of MyResource would violate the image policy", "image", policyViolation.image)
+		return reconcile.Result{}, c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+			setPolicyViolation(status, myresource, policyViolation)
+		})
+	}
+	var violation *podSecurityError
+	if goerrors.As(err, &violation) {
+		log.FromContext(ctx).Info("pods of MyResource would violate the Pod Security level of the namespace", "level", violation.level)
+		return reconcile.Result{}, c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+			setPodSecurityViolation(status, myresource, violation)
+		})
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return result, c.updateMyResource(ctx, myresource, status)
+}
+
+func (c *Controller) reconcileOwnedChildren(ctx context.Context, myresource *v1alpha1.MyResource) (reconcile.Result, *childStatus, error) {
+	now := c.clock.Now()
+	schedule, err := evaluateSchedule(myresource, now)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	// the children are reconciled with the message that is active according to the schedule
+	effective := myresource
+	if schedule != nil {
+		effective = myresource.DeepCopy()
+		effective.Spec.Message = scheduledMessage(myresource, schedule)
+	}
+
+	class, err := resolveClass(ctx, c.client, myresource)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+	err = validatePodOverlay(class)
+	if err != nil {
+		return reconcile.Result{}, nil, reconcileutil.Terminal(err)
+	}
+
+	template := c.podTemplate(effective)
+	applyClass(template, effective, class)
+	err = c.checkImagePolicy(template)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+	err = c.checkQuota(ctx, myresource)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	if usesHotReload(effective) {
+		err = c.reconcileConfigMap(ctx, effective)
+	} else {
+		err = c.deleteConfigMap(ctx, effective)
+	}
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	revision := podRevision(template)
+	reconcileStrategy := c.reconcileRecreate
+	if usesBlueGreen(effective) {
+		reconcileStrategy = c.reconcileBlueGreen
+	}
+	if isPlaced(effective) {
+		reconcileStrategy = c.reconcilePlacement
+	} else {
+		err = c.checkPodSecurity(ctx, myresource, template)
+		if err != nil {
+			return reconcile.Result{}, nil, err
+		}
+		err = c.removePlacement(ctx, myresource)
+		if err != nil {
+			return reconcile.Result{}, nil, err
+		}
+		err = c.reconcileNetworkPolicy(ctx, effective)
+		if err != nil {
+			return reconcile.Result{}, nil, err
+		}
+		err = c.reconcilePodDisruptionBudget(ctx, effective)
+		if err != nil {
+			return reconcile.Result{}, nil, err
 		}
 	}
 
-	return reconcile.Result{RequeueAfter: 10 * time.Second}, nil
+	result, status, err := reconcileStrategy(ctx, effective, template, revision, exposureOf(class))
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	status.schedule = schedule
+	if class != nil {
+		status.className = class.Name
+	}
+	if schedule != nil && schedule.NextChangeTime != nil {
+		untilNextChange := schedule.NextChangeTime.Sub(now)
+		if result.RequeueAfter == 0 || untilNextChange < result.RequeueAfter {
+			result.RequeueAfter = untilNextChange
+		}
+	}
+
+	status.revision, err = c.recordRevision(ctx, myresource)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+	return result, status, nil
 }
 
-func (c *Controller) updateMyResource(ctx context.Context, myresource *v1alpha1.MyResource, podName string) error {
-	myresource.Status.PodName = podName
-	err := c.client.Update(ctx, myresource)
+func (c *Controller) updateMyResource(ctx context.Context, myresource *v1alpha1.MyResource, childStatus *childStatus) error {
+	podName := childStatus.podName
+	err := c.updateStatus(ctx, myresource, func(status *v1alpha1.MyResourceStatus) {
+		status.PodName = podName
+		status.ClassName = childStatus.className
+		status.ServiceName = childStatus.serviceName
+		status.Rollout = childStatus.rollout
+		status.CurrentRevision = childStatus.revision
+		status.Schedule = childStatus.schedule
+		status.Clusters = childStatus.clusters
+		clearNameConflict(status)
+		clearPodSecurityViolation(status)
+		clearPolicyViolation(status)
+		clearQuotaExceeded(status)
+	})
 
 	if err != nil {
-		klog.Errorf("failed to update status of MyResource '%q'", myresource.Name)
+		log.FromContext(ctx).Error(err, "failed to update status of MyResource")
 		return err
 	}
 
-	klog.Infof("updated status of MyResource '%s' with podName '%q'", myresource.Name, podName)
+	log.FromContext(ctx).V(1).Info("updated status of MyResource", "podName", podName)
 	return nil
 }
 
-func newPod(myresource *v1alpha1.MyResource, podName string) *corev1.Pod {
-	labels := map[string]string{
-		"app":        "echoserver",
-		"controller": myresource.Name,
-	}
-	return &corev1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      podName,
-			Namespace: myresource.Namespace,
-			OwnerReferences: []metav1.OwnerReference{
-				*metav1.NewControllerRef(myresource, v1alpha1.SchemeGroupVersion.WithKind("MyResource")),
-			},
-			Labels: labels,
-		},
-		Spec: corev1.PodSpec{
-			Containers: []corev1.Container{
-				{
-					Name:  "echoserver",
-					Image: "reshnm/echoserver:latest",
-					Env: []corev1.EnvVar{
-						{
-							Name:  "ECHO_MESSAGE",
-							Value: myresource.Spec.Message,
-						},
-					},
-					Ports: []corev1.ContainerPort{
-						{
-							ContainerPort: 80,
-						},
-					},
-				},
-			},
-		},
+// updateStatus applies mutate to the status of myresource and writes it back if anything changed.
+func (c *Controller) updateStatus(ctx context.Context, myresource *v1alpha1.MyResource, mutate func(status *v1alpha1.MyResourceStatus)) error {
+	status := myresource.Status.DeepCopy()
+	mutate(status)
+	if equality.Semantic.DeepEqual(status, &myresource.Status) {
+		return nil
+	}
+
+	myresource.Status = *status
+	return c.client.Status().Update(ctx, myresource)
+}
+
+// isSuspended reports whether reconciliation of myresource is suspended, and why.
+// The suspend annotation takes precedence over spec.suspend.
+func isSuspended(logger logr.Logger, myresource *v1alpha1.MyResource) (bool, string) {
+	if value, ok := myresource.Annotations[v1alpha1.SuspendAnnotation]; ok {
+		suspended, err := strconv.ParseBool(value)
+		if err == nil {
+			return suspended, "SuspendedByAnnotation"
+		}
+		logger.Info("ignoring invalid value of annotation", "annotation", v1alpha1.SuspendAnnotation, "value", value)
 	}
+
+	return myresource.Spec.Suspend, "SuspendedBySpec"
 }
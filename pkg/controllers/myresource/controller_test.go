@@ -0,0 +1,221 @@
+package myresource
+
+import (
+	"context"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strings"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/debug"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+)
+
+const (
+	testNamespace = "default"
+	testImage     = "example.com/echoserver:test"
+)
+
+func newTestScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	Install(scheme)
+	return scheme
+}
+
+// newTestCluster returns a Cluster holding objs and the namespace of the tests.
+func newTestCluster(t *testing.T, objs ...client.Object) *kittesting.Cluster {
+	t.Helper()
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}
+	cluster, err := kittesting.NewCluster(newTestScheme(), append(objs, namespace)...)
+	if err != nil {
+		t.Fatalf("failed to create cluster: %v", err)
+	}
+	return cluster
+}
+
+// newTestController returns a Controller for cluster that runs the test image.
+func newTestController(t *testing.T, cluster client.Client, configure func(options *Options)) *Controller {
+	t.Helper()
+	options := DefaultOptions()
+	options.EchoServerImage = testImage
+	if configure != nil {
+		configure(&options)
+	}
+	controller, err := CreateController(cluster, options)
+	if err != nil {
+		t.Fatalf("failed to create controller: %v", err)
+	}
+	return controller.(*Controller)
+}
+
+func reconcileMyResource(t *testing.T, reconciler reconcile.Reconciler, name string) reconcile.Result {
+	t.Helper()
+	result, err := reconciler.Reconcile(context.Background(), reconcile.Request{
+		NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: name},
+	})
+	if err != nil {
+		t.Fatalf("reconcile of %q failed: %v", name, err)
+	}
+	return result
+}
+
+func getMyResource(t *testing.T, c client.Client, name string) *v1alpha1.MyResource {
+	t.Helper()
+	myresource := &v1alpha1.MyResource{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: name}, myresource)
+	if err != nil {
+		t.Fatalf("failed to get MyResource %q: %v", name, err)
+	}
+	return myresource
+}
+
+func listPods(t *testing.T, c client.Client) []corev1.Pod {
+	t.Helper()
+	pods := &corev1.PodList{}
+	err := c.List(context.Background(), pods, client.InNamespace(testNamespace))
+	if err != nil {
+		t.Fatalf("failed to list pods: %v", err)
+	}
+	return pods.Items
+}
+
+func TestSuspendedMyResourceIgnoresRollback(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").
+		WithMessage("current").
+		WithAnnotation(v1alpha1.RollbackToAnnotation, "0").
+		Suspended().
+		Build())
+	controller := newTestController(t, cluster, nil)
+
+	reconcileMyResource(t, controller, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	if myresource.Spec.Message != "current" {
+		t.Errorf("the spec of a suspended MyResource was changed to message %q", myresource.Spec.Message)
+	}
+	if _, ok := myresource.Annotations[v1alpha1.RollbackToAnnotation]; !ok {
+		t.Error("the rollback annotation of a suspended MyResource was removed")
+	}
+	if !meta.IsStatusConditionTrue(myresource.Status.Conditions, v1alpha1.ConditionSuspended) {
+		t.Errorf("condition %s is not true: %+v", v1alpha1.ConditionSuspended, myresource.Status.Conditions)
+	}
+	if pods := listPods(t, cluster); len(pods) > 0 {
+		t.Errorf("a suspended MyResource got %d pods", len(pods))
+	}
+}
+
+func hasEnv(env []corev1.EnvVar, name string, value string) bool {
+	for _, e := range env {
+		if e.Name == name {
+			return e.Value == value
+		}
+	}
+	return false
+}
+
+// changeMyResource applies change to the MyResource called name.
+func changeMyResource(t *testing.T, c client.Client, name string, change func(myresource *v1alpha1.MyResource)) {
+	t.Helper()
+	myresource := getMyResource(t, c, name)
+	change(myresource)
+	err := c.Update(context.Background(), myresource)
+	if err != nil {
+		t.Fatalf("failed to update MyResource %q: %v", name, err)
+	}
+}
+
+// setPodsReady marks the pods of revision as ready, as the kubelet would.
+func setPodsReady(t *testing.T, c client.Client, revision string) {
+	t.Helper()
+	for _, pod := range listPods(t, c) {
+		if pod.Labels[labelRevision] != revision {
+			continue
+		}
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}}
+		err := c.Status().Update(context.Background(), &pod)
+		if err != nil {
+			t.Fatalf("failed to update status of pod %q: %v", pod.Name, err)
+		}
+	}
+}
+
+func TestPodsCarryTraceContext(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").WithMessage("hello").Build())
+	controller := newTestController(t, cluster, nil)
+
+	ctx, span := sdktrace.NewTracerProvider().Tracer(tracing.TracerName).Start(context.Background(), "Reconcile MyResource")
+	defer span.End()
+	_, err := controller.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: "echo"}})
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	pods := listPods(t, cluster)
+	if len(pods) != 1 {
+		t.Fatalf("got %d pods, expected 1", len(pods))
+	}
+	traceParent := pods[0].Annotations[tracing.AnnotationTraceParent]
+	if !strings.Contains(traceParent, span.SpanContext().TraceID().String()) {
+		t.Errorf("pod has trace context %q, expected the trace of the reconcile", traceParent)
+	}
+	for _, env := range pods[0].Spec.Containers[0].Env {
+		if env.Name == "ECHO_TRACE_PARENT" {
+			if env.ValueFrom == nil || env.ValueFrom.FieldRef == nil || !strings.Contains(env.ValueFrom.FieldRef.FieldPath, tracing.AnnotationTraceParent) {
+				t.Errorf("ECHO_TRACE_PARENT doesn't refer to the annotation %s: %+v", tracing.AnnotationTraceParent, env)
+			}
+			return
+		}
+	}
+	t.Error("the pod doesn't pass its trace context to the echo server")
+}
+
+func TestPodsGetImagePullSecrets(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").WithMessage("hello").Build())
+	controller := newTestController(t, cluster, func(options *Options) {
+		options.EchoServerImagePullSecrets = []string{"registry-a", "registry-b"}
+	})
+
+	reconcileMyResource(t, controller, "echo")
+
+	pods := listPods(t, cluster)
+	if len(pods) != 1 {
+		t.Fatalf("got %d pods, expected 1", len(pods))
+	}
+	expected := []corev1.LocalObjectReference{{Name: "registry-a"}, {Name: "registry-b"}}
+	if !equality.Semantic.DeepEqual(pods[0].Spec.ImagePullSecrets, expected) {
+		t.Errorf("pod has image pull Secrets %v, expected %v", pods[0].Spec.ImagePullSecrets, expected)
+	}
+}
+
+func TestRecorderForgetsDeletedMyResource(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").Build())
+	recorder := debug.NewRecorder()
+	reconciler := recorder.WrapReconciler(newTestController(t, cluster, nil))
+	key := types.NamespacedName{Namespace: testNamespace, Name: "echo"}
+
+	reconcileMyResource(t, reconciler, "echo")
+	if _, ok := recorder.State(key); !ok {
+		t.Fatalf("reconcile of the MyResource wasn't recorded")
+	}
+
+	err := cluster.Delete(context.Background(), getMyResource(t, cluster, "echo"))
+	if err != nil {
+		t.Fatalf("failed to delete MyResource: %v", err)
+	}
+	reconcileMyResource(t, reconciler, "echo")
+	if state, ok := recorder.State(key); ok {
+		t.Errorf("state %+v of the deleted MyResource was kept", state)
+	}
+}
@@ -0,0 +1,144 @@
+package myresource
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+const (
+	// labelZone and labelHostname are the topology keys replicated pods are spread across by default.
+	labelZone     = "topology.kubernetes.io/zone"
+	labelHostname = "kubernetes.io/hostname"
+)
+
+func podDisruptionBudgetName(myresource *v1alpha1.MyResource) string {
+	return childName(myresource.Name, "pdb")
+}
+
+// reconcilePodDisruptionBudget creates or updates the PodDisruptionBudget of the pods of myresource if it has more
+// than one replica, and deletes it otherwise. A single pod can't be drained without disruption anyway.
+func (c *Controller) reconcilePodDisruptionBudget(ctx context.Context, myresource *v1alpha1.MyResource) error {
+	if replicas(myresource) <= 1 {
+		return c.deletePodDisruptionBudget(ctx, myresource)
+	}
+
+	disruption := myresource.Spec.Disruption
+	if disruption != nil && disruption.MinAvailable != nil && disruption.MaxUnavailable != nil {
+		return reconcileutil.Terminal(goerrors.New("spec.disruption must not set both minAvailable and maxUnavailable"))
+	}
+
+	name := podDisruptionBudgetName(myresource)
+	desired := newPodDisruptionBudget(myresource, name)
+
+	budget := &policyv1.PodDisruptionBudget{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: name, Namespace: myresource.Namespace}, budget)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcileutil.Transient(fmt.Errorf("failed to get PodDisruptionBudget %q: %w", name, err))
+		}
+
+		log.FromContext(ctx).Info("creating PodDisruptionBudget", "podDisruptionBudget", name)
+		err = c.client.Create(ctx, desired)
+		if err != nil {
+			return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to create PodDisruptionBudget %q: %w", name, err))
+		}
+		return nil
+	}
+
+	owned, err := c.claim(ctx, myresource, budget)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return &nameConflictError{obj: budget}
+	}
+
+	if equality.Semantic.DeepEqual(budget.Spec, desired.Spec) {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("updating PodDisruptionBudget", "podDisruptionBudget", name)
+	budget.Spec = desired.Spec
+	err = c.client.Update(ctx, budget)
+	if err != nil {
+		return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to update PodDisruptionBudget %q: %w", name, err))
+	}
+	return nil
+}
+
+// deletePodDisruptionBudget deletes the PodDisruptionBudget of myresource if it is controlled by myresource.
+func (c *Controller) deletePodDisruptionBudget(ctx context.Context, myresource *v1alpha1.MyResource) error {
+	name := podDisruptionBudgetName(myresource)
+	budget := &policyv1.PodDisruptionBudget{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: name, Namespace: myresource.Namespace}, budget)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return reconcileutil.Transient(fmt.Errorf("failed to get PodDisruptionBudget %q: %w", name, err))
+	}
+
+	if !metav1.IsControlledBy(budget, myresource) {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("deleting PodDisruptionBudget", "podDisruptionBudget", name)
+	return c.deleteChild(ctx, budget)
+}
+
+func newPodDisruptionBudget(myresource *v1alpha1.MyResource, name string) *policyv1.PodDisruptionBudget {
+	budget := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: myresource.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(myresource, v1alpha1.SchemeGroupVersion.WithKind("MyResource")),
+			},
+			Labels: childLabels(myresource),
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: childLabels(myresource)},
+		},
+	}
+
+	disruption := myresource.Spec.Disruption
+	switch {
+	case disruption != nil && disruption.MinAvailable != nil:
+		minAvailable := *disruption.MinAvailable
+		budget.Spec.MinAvailable = &minAvailable
+	case disruption != nil && disruption.MaxUnavailable != nil:
+		maxUnavailable := *disruption.MaxUnavailable
+		budget.Spec.MaxUnavailable = &maxUnavailable
+	default:
+		maxUnavailable := intstr.FromInt(1)
+		budget.Spec.MaxUnavailable = &maxUnavailable
+	}
+	return budget
+}
+
+// defaultTopologySpread returns the constraints spreading the pods of myresource across zones and nodes.
+// They are preferences, pods are still scheduled if a cluster has a single zone or too few nodes.
+func defaultTopologySpread(myresource *v1alpha1.MyResource) []corev1.TopologySpreadConstraint {
+	constraints := make([]corev1.TopologySpreadConstraint, 0, 2)
+	for _, topologyKey := range []string{labelZone, labelHostname} {
+		constraints = append(constraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       topologyKey,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: childLabels(myresource)},
+		})
+	}
+	return constraints
+}
@@ -0,0 +1,193 @@
+package myresource
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strings"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/spoke"
+)
+
+const (
+	actionCreate      = "Create"
+	actionUpdate      = "Update"
+	actionDelete      = "Delete"
+	actionDeleteAllOf = "DeleteAllOf"
+)
+
+// plan collects the changes of a single reconcile in dry-run mode.
+type plan struct {
+	changes []v1alpha1.PlannedChange
+}
+
+type planKey struct{}
+
+func withPlan(ctx context.Context) (context.Context, *plan) {
+	p := &plan{}
+	return context.WithValue(ctx, planKey{}, p), p
+}
+
+func (p *plan) record(change v1alpha1.PlannedChange) {
+	p.changes = append(p.changes, change)
+}
+
+// dryRunReconciler runs the Controller in dry-run mode. The children are left alone, the changes the
+// Controller would apply are written into status.plannedChanges of the MyResource and reported as events.
+type dryRunReconciler struct {
+	controller *Controller
+	// client applies the planned changes, the Controller only gets a dryRunClient
+	client client.Client
+	events record.EventRecorder
+}
+
+func (r *dryRunReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	ctx, p := withPlan(ctx)
+	result, err := r.controller.Reconcile(ctx, req)
+	if err != nil {
+		// the plan is incomplete, the retry plans again
+		return result, err
+	}
+	return result, r.writePlan(ctx, req, p)
+}
+
+// writePlan records the changes of p in the status of the MyResource, if they differ from the last plan.
+func (r *dryRunReconciler) writePlan(ctx context.Context, req reconcile.Request, p *plan) error {
+	myresource := &v1alpha1.MyResource{}
+	err := r.client.Get(ctx, req.NamespacedName, myresource)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if equality.Semantic.DeepEqual(myresource.Status.PlannedChanges, p.changes) {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("planned changes of MyResource", "changes", len(p.changes))
+	for _, change := range p.changes {
+		r.events.Eventf(myresource, corev1.EventTypeNormal, "Planned"+change.Action, "would %s", describeChange(change))
+	}
+
+	patch := client.MergeFrom(myresource.DeepCopy())
+	myresource.Status.PlannedChanges = p.changes
+	return r.client.Status().Patch(ctx, myresource, patch)
+}
+
+func describeChange(change v1alpha1.PlannedChange) string {
+	description := fmt.Sprintf("%s %s %q", strings.ToLower(change.Action), change.Kind, change.Name)
+	if change.Action == actionDeleteAllOf {
+		description = fmt.Sprintf("delete all %s objects of the MyResource", change.Kind)
+	}
+	if change.Cluster != "" {
+		description += fmt.Sprintf(" in cluster %q", change.Cluster)
+	}
+	return description
+}
+
+// dryRunClient validates writes with a server-side dry-run and records them in the plan of the reconcile
+// instead of applying them. Writes that fail aren't planned, their error is returned like without dry-run.
+// Status writes are dropped, the status of the MyResources belongs to the controller that applies the changes.
+type dryRunClient struct {
+	client.Client
+	// cluster is the spoke cluster of the client, empty for the local cluster
+	cluster string
+}
+
+func (c *dryRunClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	err := c.Client.Create(ctx, obj, append(opts, client.DryRunAll)...)
+	if err != nil {
+		return err
+	}
+	c.record(ctx, actionCreate, obj)
+	return nil
+}
+
+func (c *dryRunClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	err := c.Client.Update(ctx, obj, append(opts, client.DryRunAll)...)
+	if err != nil {
+		return err
+	}
+	c.record(ctx, actionUpdate, obj)
+	return nil
+}
+
+func (c *dryRunClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	err := c.Client.Patch(ctx, obj, patch, append(opts, client.DryRunAll)...)
+	if err != nil {
+		return err
+	}
+	c.record(ctx, actionUpdate, obj)
+	return nil
+}
+
+func (c *dryRunClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	err := c.Client.Delete(ctx, obj, append(opts, client.DryRunAll)...)
+	if err != nil {
+		return err
+	}
+	c.record(ctx, actionDelete, obj)
+	return nil
+}
+
+func (c *dryRunClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	err := c.Client.DeleteAllOf(ctx, obj, append(opts, client.DryRunAll)...)
+	if err != nil {
+		return err
+	}
+	c.record(ctx, actionDeleteAllOf, obj)
+	return nil
+}
+
+func (c *dryRunClient) Status() client.StatusWriter {
+	return discardStatusWriter{}
+}
+
+func (c *dryRunClient) record(ctx context.Context, action string, obj client.Object) {
+	p, ok := ctx.Value(planKey{}).(*plan)
+	if !ok {
+		return
+	}
+
+	kind := fmt.Sprintf("%T", obj)
+	if gvk, err := apiutil.GVKForObject(obj, c.Scheme()); err == nil {
+		kind = gvk.Kind
+	}
+	change := v1alpha1.PlannedChange{Action: action, Kind: kind, Cluster: c.cluster}
+	if action != actionDeleteAllOf {
+		change.Name = obj.GetName()
+	}
+	p.record(change)
+}
+
+type discardStatusWriter struct{}
+
+func (discardStatusWriter) Update(context.Context, client.Object, ...client.UpdateOption) error {
+	return nil
+}
+
+func (discardStatusWriter) Patch(context.Context, client.Object, client.Patch, ...client.PatchOption) error {
+	return nil
+}
+
+// dryRunSpokes hands out dryRunClients for the spoke clusters.
+type dryRunSpokes struct {
+	spokes spoke.Clients
+}
+
+func (s dryRunSpokes) Client(ctx context.Context, name string) (client.Client, error) {
+	spokeClient, err := s.spokes.Client(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return &dryRunClient{Client: spokeClient, cluster: name}, nil
+}
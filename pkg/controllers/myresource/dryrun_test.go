@@ -0,0 +1,95 @@
+package myresource
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+// newDryRunReconciler returns a reconciler for cluster that plans the changes of a Controller.
+func newDryRunReconciler(t *testing.T, cluster *kittesting.Cluster) reconcile.Reconciler {
+	t.Helper()
+	options := DefaultOptions()
+	options.EchoServerImage = testImage
+	options.DryRun = true
+	reconciler, err := CreateController(cluster, options)
+	if err != nil {
+		t.Fatalf("failed to create dry-run controller: %v", err)
+	}
+	return reconciler
+}
+
+// plans reports whether changes contains action on an object of kind.
+func plans(changes []v1alpha1.PlannedChange, action string, kind string) bool {
+	for _, change := range changes {
+		if change.Action == action && change.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDryRunPlansWithoutApplying(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").WithMessage("hello").Build())
+	dryRun := newDryRunReconciler(t, cluster)
+
+	reconcileMyResource(t, dryRun, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	if !plans(myresource.Status.PlannedChanges, actionCreate, "Pod") {
+		t.Errorf("no pod creation is planned: %+v", myresource.Status.PlannedChanges)
+	}
+	if pods := listPods(t, cluster); len(pods) > 0 {
+		t.Errorf("found %d pods created in dry-run mode", len(pods))
+	}
+	if myresource.Status.PodName != "" || myresource.Status.Rollout != nil {
+		t.Errorf("the status was written in dry-run mode: %+v", myresource.Status)
+	}
+}
+
+func TestDryRunAndLiveControllerShareStatus(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").WithMessage("hello").Build())
+	live := newTestController(t, cluster, nil)
+	dryRun := newDryRunReconciler(t, cluster)
+
+	reconcileMyResource(t, dryRun, "echo")
+	reconcileMyResource(t, live, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	if myresource.Status.PodName == "" || len(listPods(t, cluster)) != 1 {
+		t.Fatalf("the live controller didn't create the pod: %+v", myresource.Status)
+	}
+	if !plans(myresource.Status.PlannedChanges, actionCreate, "Pod") {
+		t.Errorf("the live controller overwrote the planned changes: %+v", myresource.Status.PlannedChanges)
+	}
+	podName, revision := myresource.Status.PodName, myresource.Status.CurrentRevision
+
+	changeMyResource(t, cluster, "echo", func(myresource *v1alpha1.MyResource) {
+		myresource.Spec.Message = "goodbye"
+	})
+	reconcileMyResource(t, dryRun, "echo")
+
+	myresource = getMyResource(t, cluster, "echo")
+	if !plans(myresource.Status.PlannedChanges, actionDelete, "Pod") {
+		t.Errorf("replacing the pod is not planned: %+v", myresource.Status.PlannedChanges)
+	}
+	if myresource.Status.PodName != podName || myresource.Status.CurrentRevision != revision {
+		t.Errorf("the dry-run controller overwrote the status of the live controller: %+v", myresource.Status)
+	}
+	if pods := listPods(t, cluster); len(pods) != 1 || !hasEnv(pods[0].Spec.Containers[0].Env, "ECHO_MESSAGE", "hello") {
+		t.Errorf("the dry-run controller changed the pods: %+v", pods)
+	}
+
+	reconcileMyResource(t, live, "echo")
+	reconcileMyResource(t, live, "echo")
+
+	myresource = getMyResource(t, cluster, "echo")
+	if !plans(myresource.Status.PlannedChanges, actionDelete, "Pod") {
+		t.Errorf("the live controller overwrote the planned changes: %+v", myresource.Status.PlannedChanges)
+	}
+	if pods := listPods(t, cluster); len(pods) != 1 || !hasEnv(pods[0].Spec.Containers[0].Env, "ECHO_MESSAGE", "goodbye") {
+		t.Errorf("the live controller didn't replace the pod: %+v", pods)
+	}
+}
@@ -0,0 +1,46 @@
+package myresource
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+)
+
+// imagePolicyError is returned if the image of the echo pods violates the image policy of the controller.
+type imagePolicyError struct {
+	image      string
+	violations []string
+}
+
+func (e *imagePolicyError) Error() string {
+	return strings.Join(e.violations, ", ")
+}
+
+// checkImagePolicy returns an imagePolicyError if a container of pod violates the image policy.
+func (c *Controller) checkImagePolicy(pod *corev1.Pod) error {
+	for _, container := range pod.Spec.Containers {
+		violations := c.imagePolicy.Evaluate(container.Image)
+		if len(violations) > 0 {
+			return &imagePolicyError{image: container.Image, violations: violations}
+		}
+	}
+	return nil
+}
+
+func setPolicyViolation(status *v1alpha1.MyResourceStatus, myresource *v1alpha1.MyResource, err *imagePolicyError) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionPolicyViolation,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: myresource.Generation,
+		Reason:             "ImageNotAllowed",
+		Message:            fmt.Sprintf("children are left unchanged: %s", err.Error()),
+	})
+}
+
+func clearPolicyViolation(status *v1alpha1.MyResourceStatus) {
+	meta.RemoveStatusCondition(&status.Conditions, v1alpha1.ConditionPolicyViolation)
+}
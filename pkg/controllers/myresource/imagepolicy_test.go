@@ -0,0 +1,53 @@
+package myresource
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func TestValidateRejectsEchoServerImageViolatingThePolicy(t *testing.T) {
+	options := DefaultOptions()
+	err := options.Validate()
+	if err == nil {
+		t.Error("options without echo server image are valid")
+	}
+
+	options.EchoServerImage = "reshnm/echoserver:latest"
+	options.ImagePolicy.ForbidLatestTag = true
+	err = options.Validate()
+	if err == nil {
+		t.Errorf("echo server image %q passed a policy forbidding the latest tag", options.EchoServerImage)
+	}
+
+	options.EchoServerImage = testImage
+	err = options.Validate()
+	if err != nil {
+		t.Errorf("echo server image %q was rejected: %v", options.EchoServerImage, err)
+	}
+}
+
+func TestClassImageViolatingThePolicy(t *testing.T) {
+	class := &v1alpha1.MyResourceClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "untrusted"},
+		Spec:       v1alpha1.MyResourceClassSpec{Image: "docker.io/untrusted/echoserver:v1"},
+	}
+	cluster := newTestCluster(t, class,
+		kittesting.NewMyResource(testNamespace, "echo").WithMessage("hello").WithClassName("untrusted").Build())
+	controller := newTestController(t, cluster, func(options *Options) {
+		options.ImagePolicy.AllowedRegistries = []string{"example.com"}
+	})
+
+	reconcileMyResource(t, controller, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	if !meta.IsStatusConditionTrue(myresource.Status.Conditions, v1alpha1.ConditionPolicyViolation) {
+		t.Errorf("condition %s is not true: %+v", v1alpha1.ConditionPolicyViolation, myresource.Status.Conditions)
+	}
+	if pods := listPods(t, cluster); len(pods) > 0 {
+		t.Errorf("found %d pods with an image violating the policy", len(pods))
+	}
+}
@@ -0,0 +1,51 @@
+package myresource
+
+import (
+	"fmt"
+	"hash/fnv"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"strings"
+)
+
+const (
+	// maxChildNameLength keeps child names valid as DNS labels and as label values.
+	maxChildNameLength = 63
+	hashLength         = 8
+)
+
+// childName returns the name of the child of the MyResource called parent. The name is a DNS-1035 label,
+// which Services require. Names that would be too long or that aren't valid labels, because parent contains
+// dots or starts with a digit, are shortened and sanitized and made unique again by a hash of parent.
+func childName(parent string, suffix string) string {
+	name := fmt.Sprintf("%s-%s", parent, suffix)
+	if len(validation.IsDNS1035Label(name)) == 0 {
+		return name
+	}
+
+	prefix := strings.ReplaceAll(parent, ".", "-")
+	if prefix[0] < 'a' || prefix[0] > 'z' {
+		prefix = "x" + prefix
+	}
+	prefixLength := maxChildNameLength - len(suffix) - hashLength - 2
+	if len(prefix) > prefixLength {
+		prefix = prefix[:prefixLength]
+	}
+	prefix = strings.TrimRight(prefix, "-")
+	return fmt.Sprintf("%s-%s-%s", prefix, nameHash(parent), suffix)
+}
+
+// labelValue returns a valid label value identifying the MyResource called name.
+func labelValue(name string) string {
+	if len(name) <= maxChildNameLength {
+		return name
+	}
+
+	prefix := strings.TrimRight(name[:maxChildNameLength-hashLength-1], "-.")
+	return fmt.Sprintf("%s-%s", prefix, nameHash(name))
+}
+
+func nameHash(name string) string {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(name))
+	return fmt.Sprintf("%08x", hash.Sum32())
+}
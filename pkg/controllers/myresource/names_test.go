@@ -0,0 +1,53 @@
+package myresource
+
+import (
+	"k8s.io/apimachinery/pkg/util/validation"
+	"strings"
+	"testing"
+)
+
+func TestChildName(t *testing.T) {
+	tests := []struct {
+		parent   string
+		suffix   string
+		expected string
+	}{
+		{"echo", "svc", "echo-svc"},
+		{"echo", "blue-0", "echo-blue-0"},
+		{"echo.example", "svc", "echo-example-" + nameHash("echo.example") + "-svc"},
+		{"1echo", "svc", "x1echo-" + nameHash("1echo") + "-svc"},
+		{strings.Repeat("a", 253), "svc", strings.Repeat("a", 50) + "-" + nameHash(strings.Repeat("a", 253)) + "-svc"},
+	}
+
+	for _, test := range tests {
+		name := childName(test.parent, test.suffix)
+		if name != test.expected {
+			t.Errorf("childName(%q, %q) = %q, expected %q", test.parent, test.suffix, name, test.expected)
+		}
+	}
+}
+
+func TestChildNameIsDNS1035Label(t *testing.T) {
+	parents := []string{
+		"echo",
+		"echo.example.com",
+		"0-echo",
+		"9.9",
+		strings.Repeat("a", 62),
+		strings.Repeat("a.", 100) + "a",
+		strings.Repeat("a", 55) + "." + strings.Repeat("b", 20),
+	}
+
+	for _, parent := range parents {
+		for _, suffix := range []string{"svc", "preview", "green-12", nameHash(parent)} {
+			name := childName(parent, suffix)
+			if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+				t.Errorf("childName(%q, %q) = %q is invalid: %v", parent, suffix, name, errs)
+			}
+		}
+	}
+
+	if childName("a.b", "svc") == childName("a-b", "svc") {
+		t.Errorf("MyResources a.b and a-b share the child name %q", childName("a.b", "svc"))
+	}
+}
@@ -0,0 +1,156 @@
+package myresource
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sort"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+func networkPolicyName(myresource *v1alpha1.MyResource) string {
+	return childName(myresource.Name, "netpol")
+}
+
+// reconcileNetworkPolicy creates or updates the NetworkPolicy restricting the ingress of the pods of myresource.
+// If myresource has no spec.networkPolicy, the pods only get a NetworkPolicy denying all ingress if the
+// controller denies ingress by default.
+func (c *Controller) reconcileNetworkPolicy(ctx context.Context, myresource *v1alpha1.MyResource) error {
+	if myresource.Spec.NetworkPolicy == nil && !c.denyIngressByDefault {
+		return c.deleteNetworkPolicy(ctx, myresource)
+	}
+
+	name := networkPolicyName(myresource)
+	desired := newNetworkPolicy(myresource, name, c.controllerPeer)
+
+	policy := &networkingv1.NetworkPolicy{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: name, Namespace: myresource.Namespace}, policy)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcileutil.Transient(fmt.Errorf("failed to get NetworkPolicy %q: %w", name, err))
+		}
+
+		log.FromContext(ctx).Info("creating NetworkPolicy", "networkPolicy", name)
+		err = c.client.Create(ctx, desired)
+		if err != nil {
+			return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to create NetworkPolicy %q: %w", name, err))
+		}
+		return nil
+	}
+
+	owned, err := c.claim(ctx, myresource, policy)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return &nameConflictError{obj: policy}
+	}
+
+	if equality.Semantic.DeepEqual(policy.Spec, desired.Spec) {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("updating NetworkPolicy", "networkPolicy", name)
+	policy.Spec = desired.Spec
+	err = c.client.Update(ctx, policy)
+	if err != nil {
+		return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to update NetworkPolicy %q: %w", name, err))
+	}
+	return nil
+}
+
+// deleteNetworkPolicy deletes the NetworkPolicy of myresource if it is controlled by myresource.
+func (c *Controller) deleteNetworkPolicy(ctx context.Context, myresource *v1alpha1.MyResource) error {
+	name := networkPolicyName(myresource)
+	policy := &networkingv1.NetworkPolicy{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: name, Namespace: myresource.Namespace}, policy)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return reconcileutil.Transient(fmt.Errorf("failed to get NetworkPolicy %q: %w", name, err))
+	}
+
+	if !metav1.IsControlledBy(policy, myresource) {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("deleting NetworkPolicy", "networkPolicy", name)
+	return c.deleteChild(ctx, policy)
+}
+
+// newNetworkPolicy returns a NetworkPolicy that admits ingress to the echo port of all pods of myresource
+// only from the peers of its spec.networkPolicy, or no ingress at all if it has none. The controller pods
+// selected by controller, if set, are always admitted, the controller probes the pods for their message.
+func newNetworkPolicy(myresource *v1alpha1.MyResource, name string, controller *v1alpha1.NetworkPolicyPeer) *networkingv1.NetworkPolicy {
+	policy := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: myresource.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(myresource, v1alpha1.SchemeGroupVersion.WithKind("MyResource")),
+			},
+			Labels: childLabels(myresource),
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: childLabels(myresource)},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress},
+		},
+	}
+
+	var peers []v1alpha1.NetworkPolicyPeer
+	if myresource.Spec.NetworkPolicy != nil {
+		peers = append(peers, myresource.Spec.NetworkPolicy.From...)
+	}
+	if controller != nil {
+		peers = append(peers, *controller)
+	}
+	if len(peers) == 0 {
+		return policy
+	}
+
+	protocol := corev1.ProtocolTCP
+	port := intstr.FromString("http")
+	rule := networkingv1.NetworkPolicyIngressRule{
+		Ports: []networkingv1.NetworkPolicyPort{{Protocol: &protocol, Port: &port}},
+	}
+	for _, peer := range peers {
+		rule.From = append(rule.From, networkPolicyPeer(peer))
+	}
+	policy.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{rule}
+	return policy
+}
+
+// networkPolicyPeer translates peer, the namespaces are selected by their kubernetes.io/metadata.name label.
+func networkPolicyPeer(peer v1alpha1.NetworkPolicyPeer) networkingv1.NetworkPolicyPeer {
+	podSelector := &metav1.LabelSelector{}
+	if peer.PodSelector != nil {
+		podSelector = peer.PodSelector.DeepCopy()
+	}
+	result := networkingv1.NetworkPolicyPeer{PodSelector: podSelector}
+	if len(peer.Namespaces) == 0 {
+		return result
+	}
+
+	namespaces := append([]string(nil), peer.Namespaces...)
+	sort.Strings(namespaces)
+	result.NamespaceSelector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      corev1.LabelMetadataName,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   namespaces,
+			},
+		},
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package myresource
+
+import (
+	"context"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func getNetworkPolicy(t *testing.T, c *kittesting.Cluster, myresource *v1alpha1.MyResource) *networkingv1.NetworkPolicy {
+	t.Helper()
+	policy := &networkingv1.NetworkPolicy{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: networkPolicyName(myresource)}, policy)
+	if err != nil {
+		t.Fatalf("failed to get NetworkPolicy: %v", err)
+	}
+	return policy
+}
+
+func admits(policy *networkingv1.NetworkPolicy, namespace string, podLabels map[string]string) bool {
+	for _, rule := range policy.Spec.Ingress {
+		for _, peer := range rule.From {
+			if peer.NamespaceSelector == nil || len(peer.NamespaceSelector.MatchExpressions) != 1 {
+				continue
+			}
+			namespaces := peer.NamespaceSelector.MatchExpressions[0].Values
+			if len(namespaces) != 1 || namespaces[0] != namespace {
+				continue
+			}
+			selector, err := metav1.LabelSelectorAsMap(peer.PodSelector)
+			if err == nil && len(selector) == len(podLabels) && selector["app"] == podLabels["app"] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestNetworkPolicyAdmitsController(t *testing.T) {
+	controllerLabels := map[string]string{"app": "k8s-sample-controller-crd"}
+	tests := []struct {
+		name       string
+		myresource *v1alpha1.MyResource
+	}{
+		{
+			name:       "deny by default",
+			myresource: kittesting.NewMyResource(testNamespace, "echo").Build(),
+		},
+		{
+			name: "peers of the MyResource",
+			myresource: func() *v1alpha1.MyResource {
+				myresource := kittesting.NewMyResource(testNamespace, "echo").Build()
+				myresource.Spec.NetworkPolicy = &v1alpha1.NetworkPolicy{
+					From: []v1alpha1.NetworkPolicyPeer{{Namespaces: []string{"clients"}}},
+				}
+				return myresource
+			}(),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cluster := newTestCluster(t, test.myresource)
+			controller := newTestController(t, cluster, func(options *Options) {
+				options.DenyIngressByDefault = true
+				options.ControllerNamespace = "controller-system"
+				options.ControllerPodLabels = controllerLabels
+			})
+
+			reconcileMyResource(t, controller, "echo")
+
+			policy := getNetworkPolicy(t, cluster, test.myresource)
+			if !admits(policy, "controller-system", controllerLabels) {
+				t.Errorf("NetworkPolicy doesn't admit the controller: %+v", policy.Spec.Ingress)
+			}
+			if test.myresource.Spec.NetworkPolicy != nil && !admits(policy, "clients", nil) {
+				t.Errorf("NetworkPolicy doesn't admit the peers of the MyResource: %+v", policy.Spec.Ingress)
+			}
+		})
+	}
+}
+
+func TestNetworkPolicyWithoutControllerDeniesAllIngress(t *testing.T) {
+	myresource := kittesting.NewMyResource(testNamespace, "echo").Build()
+	cluster := newTestCluster(t, myresource)
+	controller := newTestController(t, cluster, func(options *Options) {
+		options.DenyIngressByDefault = true
+	})
+
+	reconcileMyResource(t, controller, "echo")
+
+	if policy := getNetworkPolicy(t, cluster, myresource); len(policy.Spec.Ingress) > 0 {
+		t.Errorf("NetworkPolicy admits ingress: %+v", policy.Spec.Ingress)
+	}
+}
+
+func TestNetworkPolicyDeniesIngressByDefault(t *testing.T) {
+	myresource := kittesting.NewMyResource(testNamespace, "echo").Build()
+	cluster := newTestCluster(t, myresource)
+	controller := newTestController(t, cluster, nil)
+
+	reconcileMyResource(t, controller, "echo")
+
+	if policy := getNetworkPolicy(t, cluster, myresource); len(policy.Spec.Ingress) > 0 {
+		t.Errorf("NetworkPolicy admits ingress: %+v", policy.Spec.Ingress)
+	}
+
+	controller = newTestController(t, cluster, func(options *Options) {
+		options.DenyIngressByDefault = false
+	})
+	reconcileMyResource(t, controller, "echo")
+
+	err := cluster.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: networkPolicyName(myresource)}, &networkingv1.NetworkPolicy{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("NetworkPolicy was kept without default deny: %v", err)
+	}
+}
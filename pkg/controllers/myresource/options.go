@@ -0,0 +1,124 @@
+package myresource
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/tools/record"
+	"strings"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/debug"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/imagepolicy"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/spoke"
+)
+
+// Options configures the MyResource controller.
+type Options struct {
+	reconcileutil.Options
+
+	// EchoServerImage is the image of the echo pods. It is required and must contain this binary,
+	// the pods run its echoserver subcommand. Usually it is the image of the controller itself.
+	EchoServerImage string
+	// EchoServerImagePullSecrets are the names of the Secrets the echo pods pull their image with.
+	// The Secrets must exist in the namespaces of the MyResources, and of the spoke clusters for placed ones.
+	EchoServerImagePullSecrets []string
+
+	// Clock is the source of the current time, it defaults to the real clock.
+	Clock clock.Clock
+
+	// SpokeNamespace is the namespace of the Secrets registering spoke clusters. If it is set, the controller
+	// runs in hub mode and creates the children of MyResources with a placement in the spoke clusters.
+	SpokeNamespace string
+	// Spokes overrides the spoke clusters registered in SpokeNamespace.
+	Spokes spoke.Clients
+
+	// DenyIngressByDefault gives the pods of MyResources without spec.networkPolicy a NetworkPolicy
+	// denying all ingress, so that every echo pod has a NetworkPolicy. It is set by default.
+	DenyIngressByDefault bool
+	// ControllerNamespace and ControllerPodLabels select the pods of the controller. If they are set, the
+	// NetworkPolicies of the echo pods admit them, the controller probes the pods for the message they serve.
+	ControllerNamespace string
+	ControllerPodLabels map[string]string
+
+	// ImagePolicy restricts the images of the echo pods.
+	ImagePolicy imagepolicy.Policy
+
+	// DryRun validates the changes to the children with a server-side dry-run and reports them in
+	// status.plannedChanges and events of the MyResources instead of applying them.
+	DryRun bool
+	// EventRecorder reports the planned changes in dry-run mode.
+	EventRecorder record.EventRecorder
+
+	// Recorder, if set, records the outcome of every reconcile for the debug server.
+	Recorder *debug.Recorder
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Options:              reconcileutil.DefaultOptions(),
+		DenyIngressByDefault: true,
+	}
+}
+
+// Validate returns an error if the options can't run the controller.
+func (o *Options) Validate() error {
+	if o.EchoServerImage == "" {
+		return errors.New("the echo server image is required, usually it is the image of the controller")
+	}
+	if violations := o.ImagePolicy.Evaluate(o.EchoServerImage); len(violations) > 0 {
+		return fmt.Errorf("the echo server image violates the image policy: %s", strings.Join(violations, ", "))
+	}
+	return nil
+}
+
+// controllerPeer returns the peer selecting the controller pods, or nil if they are not configured.
+func (o *Options) controllerPeer() *v1alpha1.NetworkPolicyPeer {
+	if o.ControllerNamespace == "" || len(o.ControllerPodLabels) == 0 {
+		return nil
+	}
+	return &v1alpha1.NetworkPolicyPeer{
+		Namespaces:  []string{o.ControllerNamespace},
+		PodSelector: &metav1.LabelSelector{MatchLabels: o.ControllerPodLabels},
+	}
+}
+
+func (o *Options) AddFlags(flagSet *flag.FlagSet) {
+	o.Options.AddFlags(flagSet, "myresource")
+	flagSet.StringVar(&o.EchoServerImage, "echoserver-image", o.EchoServerImage,
+		"image of the echo pods, required, usually the image of the controller itself")
+	flagSet.Func("echoserver-image-pull-secrets",
+		"comma separated names of the Secrets the echo pods pull their image with, they must exist in the namespaces of the MyResources",
+		func(value string) error {
+			o.EchoServerImagePullSecrets = nil
+			for _, name := range strings.Split(value, ",") {
+				name = strings.TrimSpace(name)
+				if name != "" {
+					o.EchoServerImagePullSecrets = append(o.EchoServerImagePullSecrets, name)
+				}
+			}
+			return nil
+		})
+	flagSet.StringVar(&o.SpokeNamespace, "spoke-namespace", o.SpokeNamespace,
+		"namespace of the kubeconfig Secrets registering spoke clusters, enables hub mode")
+	o.ImagePolicy.AddFlags(flagSet)
+	flagSet.BoolVar(&o.DenyIngressByDefault, "network-policy-default-deny", o.DenyIngressByDefault,
+		"deny all ingress to the echo pods of MyResources without spec.networkPolicy, set to false to leave them without NetworkPolicy")
+	flagSet.StringVar(&o.ControllerNamespace, "controller-namespace", o.ControllerNamespace,
+		"namespace of the controller pods, which the NetworkPolicies of the echo pods admit")
+	flagSet.Func("controller-pod-labels", "comma-separated key=value labels of the controller pods, which the NetworkPolicies of the echo pods admit",
+		func(value string) error {
+			podLabels, err := labels.ConvertSelectorToLabelsMap(value)
+			if err != nil {
+				return err
+			}
+			o.ControllerPodLabels = podLabels
+			return nil
+		})
+	flagSet.BoolVar(&o.DryRun, "dry-run", o.DryRun,
+		"plan the changes to the children of MyResources in status.plannedChanges and events instead of applying them")
+}
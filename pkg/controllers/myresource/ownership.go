@@ -0,0 +1,88 @@
+package myresource
+
+import (
+	"context"
+	"fmt"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+const (
+	labelApp        = "app"
+	labelController = "controller"
+
+	appEchoServer = "echoserver"
+)
+
+// nameConflictError is returned if the name of a child is taken by an object the MyResource doesn't control.
+type nameConflictError struct {
+	obj client.Object
+}
+
+func (e *nameConflictError) Error() string {
+	return fmt.Sprintf("%q is not controlled by the MyResource", e.obj.GetName())
+}
+
+// childLabels returns the labels that identify the children of myresource.
+func childLabels(myresource *v1alpha1.MyResource) map[string]string {
+	return map[string]string{
+		labelApp:        appEchoServer,
+		labelController: labelValue(myresource.Name),
+	}
+}
+
+// matchesLabelContract reports whether obj carries the labels of a child of myresource.
+func matchesLabelContract(myresource *v1alpha1.MyResource, obj client.Object) bool {
+	for key, value := range childLabels(myresource) {
+		if obj.GetLabels()[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// claim verifies that myresource controls obj. Objects without a controller that match the label
+// contract are adopted. It returns false if obj belongs to somebody else.
+func (c *Controller) claim(ctx context.Context, myresource *v1alpha1.MyResource, obj client.Object) (bool, error) {
+	ownerRef := metav1.GetControllerOf(obj)
+	if ownerRef != nil {
+		return ownerRef.UID == myresource.UID, nil
+	}
+
+	if !matchesLabelContract(myresource, obj) || myresource.DeletionTimestamp != nil || obj.GetDeletionTimestamp() != nil {
+		return false, nil
+	}
+
+	log.FromContext(ctx).Info("adopting orphaned child", "type", fmt.Sprintf("%T", obj), "child", obj.GetName())
+	obj.SetOwnerReferences(append(obj.GetOwnerReferences(),
+		*metav1.NewControllerRef(myresource, v1alpha1.SchemeGroupVersion.WithKind("MyResource"))))
+	err := c.client.Update(ctx, obj)
+	if err != nil {
+		return false, reconcileutil.ClassifyAPIError(fmt.Errorf("failed to adopt %q: %w", obj.GetName(), err))
+	}
+	return true, nil
+}
+
+func setNameConflict(status *v1alpha1.MyResourceStatus, myresource *v1alpha1.MyResource, obj client.Object) {
+	message := fmt.Sprintf("%q exists but is not controlled by this MyResource", obj.GetName())
+	if ownerRef := metav1.GetControllerOf(obj); ownerRef != nil {
+		message = fmt.Sprintf("%q is controlled by %s %q", obj.GetName(), ownerRef.Kind, ownerRef.Name)
+	}
+
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionNameConflict,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: myresource.Generation,
+		Reason:             "ChildNotOwned",
+		Message:            message,
+	})
+}
+
+func clearNameConflict(status *v1alpha1.MyResourceStatus) {
+	meta.RemoveStatusCondition(&status.Conditions, v1alpha1.ConditionNameConflict)
+}
@@ -0,0 +1,485 @@
+package myresource
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/spoke"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+)
+
+const (
+	// finalizerSpokes keeps a placed MyResource until its children are deleted from the spoke clusters.
+	finalizerSpokes = "samplecontroller.reshnm.de/spokes"
+	// labelHubUID marks the children in spoke clusters with the UID of their MyResource in the hub cluster.
+	// Owner references can't point across clusters, the garbage collector of the spoke would delete the children.
+	labelHubUID = "samplecontroller.reshnm.de/hub-uid"
+)
+
+const (
+	// spokeResyncInterval is how often the children in spoke clusters are checked, they aren't watched.
+	spokeResyncInterval = 30 * time.Second
+	// spokeProgressInterval is how often they are checked while not all pods are ready.
+	spokeProgressInterval = 5 * time.Second
+)
+
+// isPlaced reports whether the children of myresource are created in spoke clusters.
+func isPlaced(myresource *v1alpha1.MyResource) bool {
+	return myresource.Spec.Placement != nil && len(myresource.Spec.Placement.Clusters) > 0
+}
+
+// reconcilePlacement creates the pods and the main Service of myresource in the spoke clusters of its placement
+// and removes them from the local cluster and from clusters that are no longer selected.
+func (c *Controller) reconcilePlacement(ctx context.Context, myresource *v1alpha1.MyResource, template *corev1.Pod, revision string, exposure *v1alpha1.Exposure) (reconcile.Result, *childStatus, error) {
+	if c.spokes == nil {
+		return reconcile.Result{}, nil, reconcileutil.Terminal(goerrors.New("spec.placement requires the controller to run in hub mode"))
+	}
+	if usesBlueGreen(myresource) {
+		return reconcile.Result{}, nil, reconcileutil.Terminal(goerrors.New("spec.strategy BlueGreen doesn't apply to placed MyResources"))
+	}
+	if myresource.Spec.ReloadStrategy == v1alpha1.ReloadStrategyHotReload {
+		return reconcile.Result{}, nil, reconcileutil.Terminal(goerrors.New("spec.reloadStrategy HotReload doesn't apply to placed MyResources"))
+	}
+
+	if !controllerutil.ContainsFinalizer(myresource, finalizerSpokes) {
+		controllerutil.AddFinalizer(myresource, finalizerSpokes)
+		err := c.client.Update(ctx, myresource)
+		if err != nil {
+			return reconcile.Result{}, nil, reconcileutil.ClassifyAPIError(fmt.Errorf("failed to add finalizer: %w", err))
+		}
+	}
+
+	err := c.deleteLocalChildren(ctx, myresource)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	selected := map[string]bool{}
+	clusters := make([]v1alpha1.ClusterStatus, 0, len(myresource.Spec.Placement.Clusters))
+	ready := true
+	for _, name := range myresource.Spec.Placement.Clusters {
+		if selected[name] {
+			continue
+		}
+		selected[name] = true
+
+		status := c.reconcileSpoke(ctx, name, myresource, template, revision, exposure)
+		ready = ready && status.Message == "" && status.ReadyReplicas == status.Replicas
+		clusters = append(clusters, status)
+	}
+
+	// clusters that failed to be cleaned up stay in the status, so that they are tried again
+	clusters = append(clusters, c.cleanupSpokes(ctx, myresource, selected)...)
+
+	requeueAfter := spokeResyncInterval
+	if !ready {
+		requeueAfter = spokeProgressInterval
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter}, &childStatus{
+		serviceName: mainServiceName(myresource),
+		clusters:    clusters,
+		rollout: &v1alpha1.RolloutStatus{
+			ActiveRevision: revision,
+			Step:           stepOf(ready),
+		},
+	}, nil
+}
+
+// removePlacement deletes the children of myresource from all spoke clusters and releases its finalizer.
+// It returns an error if the children couldn't be deleted from all of them. Outside of hub mode the spoke
+// clusters can't be reached, the finalizer is released right away and the children are left behind.
+func (c *Controller) removePlacement(ctx context.Context, myresource *v1alpha1.MyResource) error {
+	if !controllerutil.ContainsFinalizer(myresource, finalizerSpokes) {
+		return nil
+	}
+
+	if c.spokes == nil {
+		log.FromContext(ctx).Info("controller doesn't run in hub mode, leaving the children in spoke clusters behind",
+			"clusters", clusterNames(myresource.Status.Clusters))
+	} else {
+		remaining := c.cleanupSpokes(ctx, myresource, nil)
+		if len(remaining) > 0 {
+			return reconcileutil.Transient(fmt.Errorf("failed to delete children from spoke cluster %q: %s", remaining[0].Name, remaining[0].Message))
+		}
+	}
+
+	controllerutil.RemoveFinalizer(myresource, finalizerSpokes)
+	err := c.client.Update(ctx, myresource)
+	if err != nil {
+		return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to remove finalizer: %w", err))
+	}
+	return nil
+}
+
+// clusterNames returns the names of the clusters in a status.
+func clusterNames(clusters []v1alpha1.ClusterStatus) []string {
+	names := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		names = append(names, cluster.Name)
+	}
+	return names
+}
+
+// cleanupSpokes deletes the children of myresource from the clusters in its status that aren't in keep.
+// It returns the status of the clusters that couldn't be cleaned up.
+func (c *Controller) cleanupSpokes(ctx context.Context, myresource *v1alpha1.MyResource, keep map[string]bool) []v1alpha1.ClusterStatus {
+	names := map[string]bool{}
+	for _, status := range myresource.Status.Clusters {
+		names[status.Name] = true
+	}
+	if myresource.DeletionTimestamp != nil && myresource.Spec.Placement != nil {
+		// clusters that were added right before the deletion may not be in the status yet
+		for _, name := range myresource.Spec.Placement.Clusters {
+			names[name] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		if !keep[name] {
+			sorted = append(sorted, name)
+		}
+	}
+	sort.Strings(sorted)
+
+	var remaining []v1alpha1.ClusterStatus
+	for _, name := range sorted {
+		err := c.deleteSpokeChildren(ctx, name, myresource)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "failed to delete children of MyResource from spoke cluster", "cluster", name)
+			remaining = append(remaining, v1alpha1.ClusterStatus{Name: name, Message: fmt.Sprintf("removing children: %v", err)})
+		}
+	}
+	return remaining
+}
+
+// myResourcesOfSpoke maps a Secret registering a spoke cluster to the MyResources placed in the cluster,
+// so that they are reconciled when the cluster is registered or its kubeconfig changes.
+func myResourcesOfSpoke(c client.Client, logger logr.Logger, namespace string) func(obj client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		if obj.GetNamespace() != namespace {
+			return nil
+		}
+
+		myresources := &v1alpha1.MyResourceList{}
+		err := c.List(context.Background(), myresources)
+		if err != nil {
+			logger.Error(err, "failed to list MyResources of spoke cluster", "cluster", obj.GetName())
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, myresource := range myresources.Items {
+			if !isPlaced(&myresource) {
+				continue
+			}
+			for _, cluster := range myresource.Spec.Placement.Clusters {
+				if cluster == obj.GetName() {
+					requests = append(requests, reconcile.Request{
+						NamespacedName: types.NamespacedName{Name: myresource.Name, Namespace: myresource.Namespace},
+					})
+					break
+				}
+			}
+		}
+		return requests
+	}
+}
+
+// forgetDeletedSpokes drops the cached clients of the spoke clusters whose Secret is deleted.
+func forgetDeletedSpokes(clients *spoke.SecretClients) handler.EventHandler {
+	return handler.Funcs{
+		DeleteFunc: func(e event.DeleteEvent, _ workqueue.RateLimitingInterface) {
+			if e.Object.GetNamespace() == clients.Namespace() {
+				clients.Forget(e.Object.GetName())
+			}
+		},
+	}
+}
+
+// deleteLocalChildren deletes the pods, Services, the NetworkPolicy and the PodDisruptionBudget of myresource
+// from the local cluster.
+func (c *Controller) deleteLocalChildren(ctx context.Context, myresource *v1alpha1.MyResource) error {
+	err := c.deleteStalePods(ctx, myresource, nil)
+	if err != nil {
+		return err
+	}
+	err = c.deleteNetworkPolicy(ctx, myresource)
+	if err != nil {
+		return err
+	}
+	err = c.deletePodDisruptionBudget(ctx, myresource)
+	if err != nil {
+		return err
+	}
+	err = c.deleteService(ctx, myresource, mainServiceName(myresource))
+	if err != nil {
+		return err
+	}
+	return c.deleteService(ctx, myresource, previewServiceName(myresource))
+}
+
+// reconcileSpoke creates the pods and the main Service of myresource in the spoke cluster called name.
+// Errors are reported in the returned status.
+func (c *Controller) reconcileSpoke(ctx context.Context, name string, myresource *v1alpha1.MyResource, template *corev1.Pod, revision string, exposure *v1alpha1.Exposure) v1alpha1.ClusterStatus {
+	status := v1alpha1.ClusterStatus{Name: name, Replicas: int32(replicas(myresource))}
+
+	spokeClient, err := c.spokes.Client(ctx, name)
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+
+	err = ensureSpokeNamespace(ctx, spokeClient, myresource.Namespace)
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+
+	ready, err := ensureSpokePods(ctx, spokeClient, myresource, template, revision)
+	if err != nil {
+		log.FromContext(ctx).V(1).Info("failed to reconcile pods of MyResource in spoke cluster", "cluster", name, "error", err.Error())
+		status.Message = err.Error()
+		return status
+	}
+	status.ReadyReplicas = int32(ready)
+
+	err = ensureSpokeService(ctx, spokeClient, myresource, exposure)
+	if err != nil {
+		status.Message = err.Error()
+		return status
+	}
+
+	err = ensureSpokeNetworkPolicy(ctx, spokeClient, myresource, c.denyIngressByDefault)
+	if err != nil {
+		status.Message = err.Error()
+	}
+	return status
+}
+
+// ensureSpokeNamespace creates the namespace of the children in a spoke cluster unless it exists.
+// It is shared by all MyResources of the namespace and left behind when they are deleted.
+func ensureSpokeNamespace(ctx context.Context, spokeClient client.Client, name string) error {
+	err := spokeClient.Get(ctx, types.NamespacedName{Name: name}, &corev1.Namespace{})
+	if err == nil {
+		return nil
+	}
+	if !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to get namespace %q: %w", name, err)
+	}
+	err = spokeClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %q: %w", name, err)
+	}
+	return nil
+}
+
+// ensureSpokePods creates the missing pods of myresource in a spoke cluster and deletes outdated ones.
+// It returns the number of ready pods of revision.
+func ensureSpokePods(ctx context.Context, spokeClient client.Client, myresource *v1alpha1.MyResource, template *corev1.Pod, revision string) (int, error) {
+	pods := &corev1.PodList{}
+	err := spokeClient.List(ctx, pods, client.InNamespace(myresource.Namespace), client.MatchingLabels{labelHubUID: string(myresource.UID)})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	existing := map[string]*corev1.Pod{}
+	for i := range pods.Items {
+		existing[pods.Items[i].Name] = &pods.Items[i]
+	}
+
+	ready := 0
+	keep := map[string]bool{}
+	for i := 0; i < replicas(myresource); i++ {
+		name := podName(myresource, recreatePodSet, i)
+		keep[name] = true
+
+		pod, ok := existing[name]
+		if ok && pod.Labels[labelRevision] == revision {
+			if pod.DeletionTimestamp == nil && reconcileutil.IsPodReady(pod) {
+				ready++
+			}
+			continue
+		}
+		if ok {
+			// the pod is recreated once it is gone
+			if pod.DeletionTimestamp == nil {
+				err = deleteSpokeObject(ctx, spokeClient, pod)
+				if err != nil {
+					return 0, err
+				}
+			}
+			continue
+		}
+
+		pod = spokePod(myresource, template, name, revision)
+		tracing.InjectTraceContext(ctx, pod)
+		err = spokeClient.Create(ctx, pod)
+		if err != nil && !errors.IsAlreadyExists(err) {
+			return 0, fmt.Errorf("failed to create pod %q: %w", name, err)
+		}
+	}
+
+	for _, pod := range existing {
+		if !keep[pod.Name] && pod.DeletionTimestamp == nil {
+			err = deleteSpokeObject(ctx, spokeClient, pod)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return ready, nil
+}
+
+// ensureSpokeService creates or updates the main Service of myresource in a spoke cluster.
+func ensureSpokeService(ctx context.Context, spokeClient client.Client, myresource *v1alpha1.MyResource, exposure *v1alpha1.Exposure) error {
+	desired := newService(myresource, mainServiceName(myresource), exposure, childLabels(myresource))
+	desired.OwnerReferences = nil
+	desired.Labels[labelHubUID] = string(myresource.UID)
+
+	service := &corev1.Service{}
+	err := spokeClient.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, service)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get Service %q: %w", desired.Name, err)
+		}
+		err = spokeClient.Create(ctx, desired)
+		if err != nil {
+			return fmt.Errorf("failed to create Service %q: %w", desired.Name, err)
+		}
+		return nil
+	}
+
+	if service.Labels[labelHubUID] != string(myresource.UID) {
+		return fmt.Errorf("Service %q exists but doesn't belong to this MyResource", service.Name)
+	}
+
+	keepNodePorts(desired, service)
+	if equality.Semantic.DeepEqual(service.Spec.Selector, desired.Spec.Selector) &&
+		equality.Semantic.DeepEqual(service.Spec.Ports, desired.Spec.Ports) &&
+		service.Spec.Type == desired.Spec.Type {
+		return nil
+	}
+
+	service.Spec.Type = desired.Spec.Type
+	service.Spec.Selector = desired.Spec.Selector
+	service.Spec.Ports = desired.Spec.Ports
+	err = spokeClient.Update(ctx, service)
+	if err != nil {
+		return fmt.Errorf("failed to update Service %q: %w", service.Name, err)
+	}
+	return nil
+}
+
+// ensureSpokeNetworkPolicy creates or updates the NetworkPolicy of myresource in a spoke cluster. Without
+// spec.networkPolicy the pods only get one denying all ingress if denyByDefault is set. The controller pods
+// aren't admitted, they run in the hub cluster.
+func ensureSpokeNetworkPolicy(ctx context.Context, spokeClient client.Client, myresource *v1alpha1.MyResource, denyByDefault bool) error {
+	name := networkPolicyName(myresource)
+	if myresource.Spec.NetworkPolicy == nil && !denyByDefault {
+		return deleteSpokeChild(ctx, spokeClient, myresource, &networkingv1.NetworkPolicy{}, name)
+	}
+
+	desired := newNetworkPolicy(myresource, name, nil)
+	desired.OwnerReferences = nil
+	desired.Labels[labelHubUID] = string(myresource.UID)
+
+	policy := &networkingv1.NetworkPolicy{}
+	err := spokeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: myresource.Namespace}, policy)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get NetworkPolicy %q: %w", name, err)
+		}
+		err = spokeClient.Create(ctx, desired)
+		if err != nil {
+			return fmt.Errorf("failed to create NetworkPolicy %q: %w", name, err)
+		}
+		return nil
+	}
+
+	if policy.Labels[labelHubUID] != string(myresource.UID) {
+		return fmt.Errorf("NetworkPolicy %q exists but doesn't belong to this MyResource", name)
+	}
+	if equality.Semantic.DeepEqual(policy.Spec, desired.Spec) {
+		return nil
+	}
+
+	policy.Spec = desired.Spec
+	err = spokeClient.Update(ctx, policy)
+	if err != nil {
+		return fmt.Errorf("failed to update NetworkPolicy %q: %w", name, err)
+	}
+	return nil
+}
+
+// deleteSpokeChildren deletes the pods, the Service and the NetworkPolicy of myresource from the spoke cluster
+// called name.
+func (c *Controller) deleteSpokeChildren(ctx context.Context, name string, myresource *v1alpha1.MyResource) error {
+	spokeClient, err := c.spokes.Client(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	err = spokeClient.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace(myresource.Namespace),
+		client.MatchingLabels{labelHubUID: string(myresource.UID)})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete pods: %w", err)
+	}
+
+	err = deleteSpokeChild(ctx, spokeClient, myresource, &corev1.Service{}, mainServiceName(myresource))
+	if err != nil {
+		return err
+	}
+	return deleteSpokeChild(ctx, spokeClient, myresource, &networkingv1.NetworkPolicy{}, networkPolicyName(myresource))
+}
+
+// deleteSpokeChild deletes the object called name from a spoke cluster if it is labeled with the UID of myresource.
+// obj receives the object.
+func deleteSpokeChild(ctx context.Context, spokeClient client.Client, myresource *v1alpha1.MyResource, obj client.Object, name string) error {
+	err := spokeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: myresource.Namespace}, obj)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get %q: %w", name, err)
+	}
+	if obj.GetLabels()[labelHubUID] != string(myresource.UID) {
+		return nil
+	}
+	return deleteSpokeObject(ctx, spokeClient, obj)
+}
+
+func deleteSpokeObject(ctx context.Context, spokeClient client.Client, obj client.Object) error {
+	err := spokeClient.Delete(ctx, obj, client.Preconditions{UID: uidOf(obj)})
+	if err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete %q: %w", obj.GetName(), err)
+	}
+	return nil
+}
+
+// spokePod returns the pod called name of myresource for a spoke cluster.
+func spokePod(myresource *v1alpha1.MyResource, template *corev1.Pod, name string, revision string) *corev1.Pod {
+	pod := podFromTemplate(template, name, revision)
+	pod.OwnerReferences = nil
+	pod.Labels[labelHubUID] = string(myresource.UID)
+	return pod
+}
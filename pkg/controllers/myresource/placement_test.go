@@ -0,0 +1,238 @@
+package myresource
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"strings"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/spoke"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+// newPlacedMyResource returns a MyResource with replicas pods placed in clusters.
+func newPlacedMyResource(name string, replicas int32, clusters ...string) *v1alpha1.MyResource {
+	myresource := kittesting.NewMyResource(testNamespace, name).
+		WithUID(types.UID(name + "-uid")).
+		WithMessage("hello").
+		WithReplicas(replicas).
+		Build()
+	myresource.Spec.Placement = &v1alpha1.Placement{Clusters: clusters}
+	return myresource
+}
+
+// clusterStatus returns the status of the spoke cluster called name, or nil if there is none.
+func clusterStatus(myresource *v1alpha1.MyResource, name string) *v1alpha1.ClusterStatus {
+	for i := range myresource.Status.Clusters {
+		if myresource.Status.Clusters[i].Name == name {
+			return &myresource.Status.Clusters[i]
+		}
+	}
+	return nil
+}
+
+// expectSpokeChildren fails the test if the spoke cluster doesn't hold the given number of pods of myresource,
+// and its main Service and NetworkPolicy unless there are no pods.
+func expectSpokeChildren(t *testing.T, name string, c client.Client, myresource *v1alpha1.MyResource, pods int) {
+	t.Helper()
+	spokePods := listPods(t, c)
+	if len(spokePods) != pods {
+		t.Errorf("spoke cluster %q holds %d pods, expected %d", name, len(spokePods), pods)
+	}
+	for _, pod := range spokePods {
+		if pod.Labels[labelHubUID] != string(myresource.UID) {
+			t.Errorf("pod %q in spoke cluster %q is labeled with hub UID %q", pod.Name, name, pod.Labels[labelHubUID])
+		}
+		if len(pod.OwnerReferences) > 0 {
+			t.Errorf("pod %q in spoke cluster %q has owner references", pod.Name, name)
+		}
+	}
+
+	children := map[string]client.Object{
+		mainServiceName(myresource):   &corev1.Service{},
+		networkPolicyName(myresource): &networkingv1.NetworkPolicy{},
+	}
+	for childName, child := range children {
+		err := c.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: childName}, child)
+		if pods == 0 {
+			if !errors.IsNotFound(err) {
+				t.Errorf("spoke cluster %q still holds %q: %v", name, childName, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("failed to get %q from spoke cluster %q: %v", childName, name, err)
+		} else if child.GetLabels()[labelHubUID] != string(myresource.UID) {
+			t.Errorf("%q in spoke cluster %q is labeled with hub UID %q", childName, name, child.GetLabels()[labelHubUID])
+		}
+	}
+}
+
+func TestPlacementCreatesChildrenInSpokes(t *testing.T) {
+	cluster := newTestCluster(t, newPlacedMyResource("echo", 2, "a", "b", "missing"))
+	spokeA := newTestCluster(t)
+	spokeB := newTestCluster(t)
+	controller := newTestController(t, cluster, func(options *Options) {
+		options.Spokes = spoke.StaticClients{"a": spokeA, "b": spokeB}
+	})
+
+	reconcileMyResource(t, controller, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	if !controllerutil.ContainsFinalizer(myresource, finalizerSpokes) {
+		t.Errorf("placed MyResource has no finalizer %s", finalizerSpokes)
+	}
+	if pods := listPods(t, cluster); len(pods) > 0 {
+		t.Errorf("placed MyResource got %d pods in the hub cluster", len(pods))
+	}
+	expectSpokeChildren(t, "a", spokeA, myresource, 2)
+	expectSpokeChildren(t, "b", spokeB, myresource, 2)
+
+	for _, name := range []string{"a", "b"} {
+		status := clusterStatus(myresource, name)
+		if status == nil || status.Replicas != 2 || status.ReadyReplicas != 0 || status.Message != "" {
+			t.Errorf("spoke cluster %q has status %+v", name, status)
+		}
+	}
+	if status := clusterStatus(myresource, "missing"); status == nil || !strings.Contains(status.Message, "not registered") {
+		t.Errorf("unregistered spoke cluster has status %+v", status)
+	}
+}
+
+func TestPlacementCreatesNamespaceInSpokes(t *testing.T) {
+	cluster := newTestCluster(t, newPlacedMyResource("echo", 1, "a"))
+	spokeA, err := kittesting.NewCluster(newTestScheme())
+	if err != nil {
+		t.Fatalf("failed to create spoke cluster: %v", err)
+	}
+	controller := newTestController(t, cluster, func(options *Options) {
+		options.Spokes = spoke.StaticClients{"a": spokeA}
+	})
+
+	reconcileMyResource(t, controller, "echo")
+
+	err = spokeA.Get(context.Background(), types.NamespacedName{Name: testNamespace}, &corev1.Namespace{})
+	if err != nil {
+		t.Errorf("namespace %q wasn't created in the spoke cluster: %v", testNamespace, err)
+	}
+	myresource := getMyResource(t, cluster, "echo")
+	expectSpokeChildren(t, "a", spokeA, myresource, 1)
+	if status := clusterStatus(myresource, "a"); status == nil || status.Message != "" {
+		t.Errorf("spoke cluster has status %+v", status)
+	}
+}
+
+func TestPlacementRejectsUnsupportedStrategies(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(myresource *v1alpha1.MyResource)
+		err    string
+	}{
+		{
+			name:   "blue/green",
+			change: func(myresource *v1alpha1.MyResource) { myresource.Spec.Strategy = v1alpha1.StrategyBlueGreen },
+			err:    "spec.strategy BlueGreen",
+		},
+		{
+			name: "hot reload",
+			change: func(myresource *v1alpha1.MyResource) {
+				myresource.Spec.ReloadStrategy = v1alpha1.ReloadStrategyHotReload
+			},
+			err: "spec.reloadStrategy HotReload",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			myresource := newPlacedMyResource("echo", 1, "a")
+			test.change(myresource)
+			cluster := newTestCluster(t, myresource)
+			spokeA := newTestCluster(t)
+			controller := newTestController(t, cluster, func(options *Options) {
+				options.Spokes = spoke.StaticClients{"a": spokeA}
+			})
+
+			reconcileMyResource(t, controller, "echo")
+
+			if lastError := getMyResource(t, cluster, "echo").Status.LastError; !strings.Contains(lastError, test.err) {
+				t.Errorf("MyResource has last error %q, expected it to mention %q", lastError, test.err)
+			}
+			if pods := listPods(t, spokeA); len(pods) > 0 {
+				t.Errorf("spoke cluster got %d pods", len(pods))
+			}
+			if pods := listPods(t, cluster); len(pods) > 0 {
+				t.Errorf("hub cluster got %d pods", len(pods))
+			}
+		})
+	}
+}
+
+func TestPlacementRemovesChildrenFromDeselectedSpokes(t *testing.T) {
+	cluster := newTestCluster(t, newPlacedMyResource("echo", 1, "a", "b"))
+	spokeA := newTestCluster(t)
+	spokeB := newTestCluster(t)
+	controller := newTestController(t, cluster, func(options *Options) {
+		options.Spokes = spoke.StaticClients{"a": spokeA, "b": spokeB}
+	})
+	reconcileMyResource(t, controller, "echo")
+
+	changeMyResource(t, cluster, "echo", func(myresource *v1alpha1.MyResource) {
+		myresource.Spec.Placement.Clusters = []string{"a"}
+	})
+	reconcileMyResource(t, controller, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	expectSpokeChildren(t, "a", spokeA, myresource, 1)
+	expectSpokeChildren(t, "b", spokeB, myresource, 0)
+	if status := clusterStatus(myresource, "b"); status != nil {
+		t.Errorf("deselected spoke cluster is still in the status: %+v", status)
+	}
+}
+
+func TestDeletingPlacedMyResourceCleansUpSpokes(t *testing.T) {
+	cluster := newTestCluster(t, newPlacedMyResource("echo", 1, "a", "b"))
+	spokeA := newTestCluster(t)
+	spokeB := newTestCluster(t)
+	controller := newTestController(t, cluster, func(options *Options) {
+		options.Spokes = spoke.StaticClients{"a": spokeA, "b": spokeB}
+	})
+	reconcileMyResource(t, controller, "echo")
+	myresource := getMyResource(t, cluster, "echo")
+
+	err := cluster.Delete(context.Background(), myresource)
+	if err != nil {
+		t.Fatalf("failed to delete MyResource: %v", err)
+	}
+	reconcileMyResource(t, controller, "echo")
+
+	expectSpokeChildren(t, "a", spokeA, myresource, 0)
+	expectSpokeChildren(t, "b", spokeB, myresource, 0)
+	err = cluster.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: "echo"}, &v1alpha1.MyResource{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("MyResource wasn't released after its spokes were cleaned up: %v", err)
+	}
+}
+
+func TestDeletingPlacedMyResourceOutsideHubMode(t *testing.T) {
+	myresource := newPlacedMyResource("echo", 1, "a")
+	controllerutil.AddFinalizer(myresource, finalizerSpokes)
+	myresource.Status.Clusters = []v1alpha1.ClusterStatus{{Name: "a", Replicas: 1}}
+	cluster := newTestCluster(t, myresource)
+	controller := newTestController(t, cluster, nil)
+
+	err := cluster.Delete(context.Background(), getMyResource(t, cluster, "echo"))
+	if err != nil {
+		t.Fatalf("failed to delete MyResource: %v", err)
+	}
+	reconcileMyResource(t, controller, "echo")
+
+	err = cluster.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: "echo"}, &v1alpha1.MyResource{})
+	if !errors.IsNotFound(err) {
+		t.Errorf("finalizer %s wasn't released outside of hub mode: %v", finalizerSpokes, err)
+	}
+}
@@ -0,0 +1,266 @@
+package myresource
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"strings"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+const (
+	// labelPodSecurityEnforce is the namespace label that selects the Pod Security level enforced for its pods.
+	labelPodSecurityEnforce = "pod-security.kubernetes.io/enforce"
+
+	podSecurityBaseline   = "baseline"
+	podSecurityRestricted = "restricted"
+
+	// annotationAppArmorPrefix and the seccomp annotations configure the profiles of pods through the
+	// PodOverlay of their MyResourceClass.
+	annotationAppArmorPrefix         = "container.apparmor.security.beta.kubernetes.io/"
+	annotationSeccompPod             = "seccomp.security.alpha.kubernetes.io/pod"
+	annotationSeccompContainerPrefix = "container.seccomp.security.alpha.kubernetes.io/"
+
+	// echoServerUser is the user and group the echo server runs as unless it is overridden.
+	echoServerUser = 65532
+)
+
+// baselineCapabilities are the capabilities the Pod Security baseline profile allows to add.
+var baselineCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE":      true,
+	"CHOWN":            true,
+	"DAC_OVERRIDE":     true,
+	"FOWNER":           true,
+	"FSETID":           true,
+	"KILL":             true,
+	"MKNOD":            true,
+	"NET_BIND_SERVICE": true,
+	"SETFCAP":          true,
+	"SETGID":           true,
+	"SETPCAP":          true,
+	"SETUID":           true,
+	"SYS_CHROOT":       true,
+}
+
+// podSecurityError is returned if the echo pods of a MyResource would violate the Pod Security level
+// enforced in its namespace.
+type podSecurityError struct {
+	level      string
+	violations []string
+}
+
+func (e *podSecurityError) Error() string {
+	return fmt.Sprintf("pods would violate Pod Security %q: %s", e.level, strings.Join(e.violations, ", "))
+}
+
+// applySecurityContext sets the security settings of the echo pod, which comply with the restricted profile
+// unless spec.securityContext of myresource overrides them.
+func applySecurityContext(pod *corev1.Pod, myresource *v1alpha1.MyResource) {
+	overrides := myresource.Spec.SecurityContext
+	if overrides == nil {
+		overrides = &v1alpha1.SecurityContext{}
+	}
+
+	pod.Spec.SecurityContext = &corev1.PodSecurityContext{
+		RunAsUser:      int64OrDefault(overrides.RunAsUser, echoServerUser),
+		RunAsGroup:     int64OrDefault(overrides.RunAsGroup, echoServerUser),
+		RunAsNonRoot:   boolOrDefault(overrides.RunAsNonRoot, true),
+		SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	if overrides.SeccompProfile != nil {
+		pod.Spec.SecurityContext.SeccompProfile = overrides.SeccompProfile.DeepCopy()
+	}
+
+	pod.Spec.Containers[0].SecurityContext = &corev1.SecurityContext{
+		ReadOnlyRootFilesystem:   boolOrDefault(overrides.ReadOnlyRootFilesystem, true),
+		AllowPrivilegeEscalation: boolOrDefault(overrides.AllowPrivilegeEscalation, false),
+		Capabilities: &corev1.Capabilities{
+			Add:  append([]corev1.Capability(nil), overrides.AddCapabilities...),
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+}
+
+func int64OrDefault(value *int64, defaultValue int64) *int64 {
+	if value != nil {
+		defaultValue = *value
+	}
+	return &defaultValue
+}
+
+func boolOrDefault(value *bool, defaultValue bool) *bool {
+	if value != nil {
+		defaultValue = *value
+	}
+	return &defaultValue
+}
+
+// checkPodSecurity returns a podSecurityError if pod violates the Pod Security level enforced in the namespace
+// of myresource. Namespaces without enforced level admit any pod.
+func (c *Controller) checkPodSecurity(ctx context.Context, myresource *v1alpha1.MyResource, pod *corev1.Pod) error {
+	namespace := &corev1.Namespace{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: myresource.Namespace}, namespace)
+	if err != nil {
+		return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to get namespace %q: %w", myresource.Namespace, err))
+	}
+
+	level := namespace.Labels[labelPodSecurityEnforce]
+	violations := podSecurityViolations(level, pod)
+	if len(violations) > 0 {
+		return &podSecurityError{level: level, violations: violations}
+	}
+	return nil
+}
+
+// podSecurityViolations returns the settings of pod that the Pod Security level forbids.
+// Only the settings the controller and the PodOverlay of classes generate are checked, the privileged level
+// and unknown levels allow all of them.
+func podSecurityViolations(level string, pod *corev1.Pod) []string {
+	if level != podSecurityBaseline && level != podSecurityRestricted {
+		return nil
+	}
+	restricted := level == podSecurityRestricted
+
+	violations := annotationViolations(pod)
+	podContext := pod.Spec.SecurityContext
+	if podContext == nil {
+		podContext = &corev1.PodSecurityContext{}
+	}
+	if profile := podContext.SeccompProfile; profile != nil && profile.Type == corev1.SeccompProfileTypeUnconfined {
+		violations = append(violations, "seccompProfile must not be Unconfined")
+	} else if restricted && profile == nil {
+		violations = append(violations, "seccompProfile must be RuntimeDefault or Localhost")
+	}
+	if restricted && (podContext.RunAsNonRoot == nil || !*podContext.RunAsNonRoot) {
+		violations = append(violations, "runAsNonRoot must be true")
+	}
+	if restricted && podContext.RunAsUser != nil && *podContext.RunAsUser == 0 {
+		violations = append(violations, "runAsUser must not be 0")
+	}
+
+	for _, container := range pod.Spec.Containers {
+		securityContext := container.SecurityContext
+		if securityContext == nil {
+			securityContext = &corev1.SecurityContext{}
+		}
+		if securityContext.Privileged != nil && *securityContext.Privileged {
+			violations = append(violations, fmt.Sprintf("container %q must not be privileged", container.Name))
+		}
+		if restricted && (securityContext.AllowPrivilegeEscalation == nil || *securityContext.AllowPrivilegeEscalation) {
+			violations = append(violations, fmt.Sprintf("container %q must not allow privilege escalation", container.Name))
+		}
+
+		capabilities := securityContext.Capabilities
+		if capabilities == nil {
+			capabilities = &corev1.Capabilities{}
+		}
+		for _, capability := range capabilities.Add {
+			allowed := baselineCapabilities[capability]
+			if restricted {
+				allowed = capability == "NET_BIND_SERVICE"
+			}
+			if !allowed {
+				violations = append(violations, fmt.Sprintf("container %q must not add capability %s", container.Name, capability))
+			}
+		}
+		if restricted && !dropsAllCapabilities(capabilities) {
+			violations = append(violations, fmt.Sprintf("container %q must drop all capabilities", container.Name))
+		}
+	}
+	return violations
+}
+
+// annotationViolations returns the AppArmor and seccomp profiles set by annotations of pod that the baseline
+// level, and therefore the restricted one, forbids.
+func annotationViolations(pod *corev1.Pod) []string {
+	var violations []string
+	for _, key := range sortedKeys(pod.Annotations) {
+		value := pod.Annotations[key]
+		switch {
+		case strings.HasPrefix(key, annotationAppArmorPrefix):
+			if value != "runtime/default" && !strings.HasPrefix(value, "localhost/") {
+				violations = append(violations, fmt.Sprintf("annotation %s must be runtime/default or localhost", key))
+			}
+		case key == annotationSeccompPod || strings.HasPrefix(key, annotationSeccompContainerPrefix):
+			if value == "unconfined" {
+				violations = append(violations, fmt.Sprintf("annotation %s must not be unconfined", key))
+			}
+		}
+	}
+	return violations
+}
+
+func dropsAllCapabilities(capabilities *corev1.Capabilities) bool {
+	for _, capability := range capabilities.Drop {
+		if capability == "ALL" {
+			return true
+		}
+	}
+	return false
+}
+
+func setPodSecurityViolation(status *v1alpha1.MyResourceStatus, myresource *v1alpha1.MyResource, err *podSecurityError) {
+	reason := "ForbiddenByBaseline"
+	if err.level == podSecurityRestricted {
+		reason = "ForbiddenByRestricted"
+	}
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionPodSecurityViolation,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: myresource.Generation,
+		Reason:             reason,
+		Message:            err.Error(),
+	})
+}
+
+func clearPodSecurityViolation(status *v1alpha1.MyResourceStatus) {
+	meta.RemoveStatusCondition(&status.Conditions, v1alpha1.ConditionPodSecurityViolation)
+}
+
+// podSecurityLevelChanged passes updates of namespaces that change the enforced Pod Security level.
+func podSecurityLevelChanged() predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc:  func(event.CreateEvent) bool { return false },
+		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		GenericFunc: func(event.GenericEvent) bool { return false },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return e.ObjectOld.GetLabels()[labelPodSecurityEnforce] != e.ObjectNew.GetLabels()[labelPodSecurityEnforce]
+		},
+	}
+}
+
+// myResourcesOfNamespace maps a namespace to the MyResources in it, so that they are checked against
+// a changed Pod Security level.
+func myResourcesOfNamespace(c client.Client, logger logr.Logger) func(obj client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		return myResourcesIn(c, logger, obj.GetName())
+	}
+}
+
+// myResourcesIn returns requests for all MyResources in namespace.
+func myResourcesIn(c client.Client, logger logr.Logger, namespace string) []reconcile.Request {
+	myresources := &v1alpha1.MyResourceList{}
+	err := c.List(context.Background(), myresources, client.InNamespace(namespace))
+	if err != nil {
+		logger.Error(err, "failed to list MyResources in namespace", "namespace", namespace)
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(myresources.Items))
+	for _, myresource := range myresources.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: myresource.Name, Namespace: myresource.Namespace},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,176 @@
+package myresource
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"reflect"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func TestPodSecurityViolations(t *testing.T) {
+	yes, no := true, false
+	root := int64(0)
+	tests := []struct {
+		name     string
+		level    string
+		change   func(pod *corev1.Pod)
+		expected []string
+	}{
+		{
+			name:  "defaults comply with restricted",
+			level: podSecurityRestricted,
+		},
+		{
+			name:   "privileged level allows everything",
+			level:  "privileged",
+			change: func(pod *corev1.Pod) { pod.Spec.Containers[0].SecurityContext.Privileged = &yes },
+		},
+		{
+			name:   "unknown level allows everything",
+			level:  "unknown",
+			change: func(pod *corev1.Pod) { pod.Spec.Containers[0].SecurityContext.Privileged = &yes },
+		},
+		{
+			name:     "privileged container violates baseline",
+			level:    podSecurityBaseline,
+			change:   func(pod *corev1.Pod) { pod.Spec.Containers[0].SecurityContext.Privileged = &yes },
+			expected: []string{`container "echoserver" must not be privileged`},
+		},
+		{
+			name:  "unconfined seccomp profile violates baseline",
+			level: podSecurityBaseline,
+			change: func(pod *corev1.Pod) {
+				pod.Spec.SecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined}
+			},
+			expected: []string{"seccompProfile must not be Unconfined"},
+		},
+		{
+			name:   "missing seccomp profile complies with baseline",
+			level:  podSecurityBaseline,
+			change: func(pod *corev1.Pod) { pod.Spec.SecurityContext.SeccompProfile = nil },
+		},
+		{
+			name:     "missing seccomp profile violates restricted",
+			level:    podSecurityRestricted,
+			change:   func(pod *corev1.Pod) { pod.Spec.SecurityContext.SeccompProfile = nil },
+			expected: []string{"seccompProfile must be RuntimeDefault or Localhost"},
+		},
+		{
+			name:  "running as root complies with baseline",
+			level: podSecurityBaseline,
+			change: func(pod *corev1.Pod) {
+				pod.Spec.SecurityContext.RunAsNonRoot, pod.Spec.SecurityContext.RunAsUser = &no, &root
+			},
+		},
+		{
+			name:  "running as root violates restricted",
+			level: podSecurityRestricted,
+			change: func(pod *corev1.Pod) {
+				pod.Spec.SecurityContext.RunAsNonRoot, pod.Spec.SecurityContext.RunAsUser = &no, &root
+			},
+			expected: []string{"runAsNonRoot must be true", "runAsUser must not be 0"},
+		},
+		{
+			name:   "privilege escalation complies with baseline",
+			level:  podSecurityBaseline,
+			change: func(pod *corev1.Pod) { pod.Spec.Containers[0].SecurityContext.AllowPrivilegeEscalation = &yes },
+		},
+		{
+			name:     "privilege escalation violates restricted",
+			level:    podSecurityRestricted,
+			change:   func(pod *corev1.Pod) { pod.Spec.Containers[0].SecurityContext.AllowPrivilegeEscalation = &yes },
+			expected: []string{`container "echoserver" must not allow privilege escalation`},
+		},
+		{
+			name:  "baseline capabilities comply with baseline",
+			level: podSecurityBaseline,
+			change: func(pod *corev1.Pod) {
+				pod.Spec.Containers[0].SecurityContext.Capabilities = &corev1.Capabilities{Add: []corev1.Capability{"CHOWN", "NET_BIND_SERVICE"}}
+			},
+		},
+		{
+			name:  "other capabilities violate baseline",
+			level: podSecurityBaseline,
+			change: func(pod *corev1.Pod) {
+				pod.Spec.Containers[0].SecurityContext.Capabilities.Add = []corev1.Capability{"NET_ADMIN"}
+			},
+			expected: []string{`container "echoserver" must not add capability NET_ADMIN`},
+		},
+		{
+			name:  "restricted only allows to add NET_BIND_SERVICE",
+			level: podSecurityRestricted,
+			change: func(pod *corev1.Pod) {
+				pod.Spec.Containers[0].SecurityContext.Capabilities.Add = []corev1.Capability{"NET_BIND_SERVICE", "CHOWN"}
+			},
+			expected: []string{`container "echoserver" must not add capability CHOWN`},
+		},
+		{
+			name:  "keeping capabilities violates restricted",
+			level: podSecurityRestricted,
+			change: func(pod *corev1.Pod) {
+				pod.Spec.Containers[0].SecurityContext.Capabilities.Drop = nil
+			},
+			expected: []string{`container "echoserver" must drop all capabilities`},
+		},
+		{
+			name:  "AppArmor and seccomp annotations of the class comply with baseline",
+			level: podSecurityBaseline,
+			change: func(pod *corev1.Pod) {
+				pod.Annotations = map[string]string{
+					annotationAppArmorPrefix + "echoserver": "localhost/echo",
+					annotationSeccompPod:                    "runtime/default",
+				}
+			},
+		},
+		{
+			name:  "unconfined annotations of the class violate baseline",
+			level: podSecurityBaseline,
+			change: func(pod *corev1.Pod) {
+				pod.Annotations = map[string]string{
+					annotationAppArmorPrefix + "echoserver":         "unconfined",
+					annotationSeccompContainerPrefix + "echoserver": "unconfined",
+				}
+			},
+			expected: []string{
+				"annotation " + annotationAppArmorPrefix + "echoserver must be runtime/default or localhost",
+				"annotation " + annotationSeccompContainerPrefix + "echoserver must not be unconfined",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			myresource := kittesting.NewMyResource(testNamespace, "echo").Build()
+			pod := newPod(myresource, testImage)
+			if test.change != nil {
+				test.change(pod)
+			}
+
+			violations := podSecurityViolations(test.level, pod)
+			if !reflect.DeepEqual(violations, test.expected) {
+				t.Errorf("got violations %q, expected %q", violations, test.expected)
+			}
+		})
+	}
+}
+
+func TestPodSecurityViolationsOfSecurityContextOverrides(t *testing.T) {
+	yes := true
+	root := int64(0)
+	myresource := kittesting.NewMyResource(testNamespace, "echo").Build()
+	myresource.Spec.SecurityContext = &v1alpha1.SecurityContext{
+		RunAsUser:                &root,
+		AllowPrivilegeEscalation: &yes,
+		AddCapabilities:          []corev1.Capability{"SYS_ADMIN"},
+	}
+	pod := newPod(myresource, testImage)
+
+	if violations := podSecurityViolations(podSecurityBaseline, pod); len(violations) != 1 {
+		t.Errorf("got baseline violations %q, expected the capability only", violations)
+	}
+	if violations := podSecurityViolations(podSecurityRestricted, pod); len(violations) != 3 {
+		t.Errorf("got restricted violations %q, expected root user, privilege escalation and capability", violations)
+	}
+}
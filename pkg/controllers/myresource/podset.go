@@ -0,0 +1,307 @@
+package myresource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"strconv"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/logging"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+)
+
+const (
+	labelRevision = v1alpha1.RevisionLabel
+)
+
+// replicas returns the number of pods a pod set of myresource consists of.
+func replicas(myresource *v1alpha1.MyResource) int {
+	if myresource.Spec.Replicas == nil {
+		return 1
+	}
+	return int(*myresource.Spec.Replicas)
+}
+
+// podRevision returns a hash of the spec of template. Pods of the same revision are interchangeable.
+func podRevision(template *corev1.Pod) string {
+	// marshalling can't fail for a pod spec
+	data, _ := json.Marshal(template.Spec)
+	return nameHash(string(data))
+}
+
+// podName returns the name of the pod with the given index in the pod set called set.
+func podName(myresource *v1alpha1.MyResource, set string, index int) string {
+	if index == 0 {
+		return childName(myresource.Name, set)
+	}
+	return childName(myresource.Name, fmt.Sprintf("%s-%d", set, index))
+}
+
+// ensurePodSet creates the pods of the pod set called set from template. Pods of another revision are deleted,
+// so that they are recreated by a later reconcile. If template is nil, missing pods are not created.
+// It returns the names of the pods in the set and whether all of them are ready.
+func (c *Controller) ensurePodSet(ctx context.Context, myresource *v1alpha1.MyResource, template *corev1.Pod, set string, revision string) ([]string, bool, error) {
+	names := make([]string, 0, replicas(myresource))
+	ready := true
+
+	for i := 0; i < replicas(myresource); i++ {
+		name := podName(myresource, set, i)
+		names = append(names, name)
+
+		pod := &corev1.Pod{}
+		err := c.client.Get(ctx, types.NamespacedName{Name: name, Namespace: myresource.Namespace}, pod)
+		if err != nil {
+			if !errors.IsNotFound(err) {
+				return nil, false, reconcileutil.Transient(fmt.Errorf("failed to get pod %q: %w", name, err))
+			}
+
+			ready = false
+			if template == nil {
+				continue
+			}
+
+			log.FromContext(ctx).Info("creating pod", logging.KeyPod, logging.Ref(myresource.Namespace, name))
+			pod := podFromTemplate(template, name, revision)
+			tracing.InjectTraceContext(ctx, pod)
+			err = c.client.Create(ctx, pod)
+			if err != nil {
+				return nil, false, reconcileutil.ClassifyAPIError(fmt.Errorf("failed to create pod %q: %w", name, err))
+			}
+			continue
+		}
+
+		owned, err := c.claim(ctx, myresource, pod)
+		if err != nil {
+			return nil, false, err
+		}
+		if !owned {
+			return nil, false, &nameConflictError{obj: pod}
+		}
+
+		if pod.DeletionTimestamp != nil {
+			ready = false
+			continue
+		}
+
+		if pod.Labels[labelRevision] != revision {
+			log.FromContext(ctx).Info("deleting pod of outdated revision", logging.KeyPod, logging.RefOf(pod), "revision", pod.Labels[labelRevision])
+			err = c.deleteChild(ctx, pod)
+			if err != nil {
+				return nil, false, err
+			}
+			ready = false
+			continue
+		}
+
+		if !reconcileutil.IsPodReady(pod) {
+			ready = false
+		}
+	}
+
+	return names, ready, nil
+}
+
+// deleteStalePods deletes all pods controlled by myresource that are not listed in keep.
+func (c *Controller) deleteStalePods(ctx context.Context, myresource *v1alpha1.MyResource, keep []string) error {
+	podList := &corev1.PodList{}
+	err := c.client.List(ctx, podList, client.InNamespace(myresource.Namespace), client.MatchingLabels(childLabels(myresource)))
+	if err != nil {
+		return reconcileutil.Transient(fmt.Errorf("failed to list pods: %w", err))
+	}
+
+	keepNames := make(map[string]bool, len(keep))
+	for _, name := range keep {
+		keepNames[name] = true
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if keepNames[pod.Name] || !metav1.IsControlledBy(pod, myresource) || pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		log.FromContext(ctx).Info("deleting stale pod", logging.KeyPod, logging.RefOf(pod))
+		err := c.deleteChild(ctx, pod)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// existingPods returns the names of the pods of the pod set called set that exist and aren't being deleted.
+func (c *Controller) existingPods(ctx context.Context, myresource *v1alpha1.MyResource, set string) ([]string, error) {
+	podList := &corev1.PodList{}
+	err := c.client.List(ctx, podList, client.InNamespace(myresource.Namespace), client.MatchingLabels(childLabels(myresource)))
+	if err != nil {
+		return nil, reconcileutil.Transient(fmt.Errorf("failed to list pods: %w", err))
+	}
+
+	inSet := make(map[string]bool, replicas(myresource))
+	for i := 0; i < replicas(myresource); i++ {
+		inSet[podName(myresource, set, i)] = true
+	}
+
+	var names []string
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if inSet[pod.Name] && metav1.IsControlledBy(pod, myresource) && pod.DeletionTimestamp == nil {
+			names = append(names, pod.Name)
+		}
+	}
+	return names, nil
+}
+
+// deleteChild deletes obj, it is not an error if obj is already gone.
+func (c *Controller) deleteChild(ctx context.Context, obj client.Object) error {
+	err := c.client.Delete(ctx, obj, client.Preconditions{UID: uidOf(obj)})
+	if err != nil && !errors.IsNotFound(err) {
+		return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to delete %q: %w", obj.GetName(), err))
+	}
+	return nil
+}
+
+func uidOf(obj client.Object) *types.UID {
+	uid := obj.GetUID()
+	return &uid
+}
+
+func podFromTemplate(template *corev1.Pod, name string, revision string) *corev1.Pod {
+	pod := template.DeepCopy()
+	pod.Name = name
+	pod.Labels[labelRevision] = revision
+	return pod
+}
+
+// podTemplate returns the template of the pods of myresource with the echo server image and the image pull
+// Secrets of the controller.
+func (c *Controller) podTemplate(myresource *v1alpha1.MyResource) *corev1.Pod {
+	pod := newPod(myresource, c.echoServerImage)
+	pod.Spec.ImagePullSecrets = c.imagePullSecrets
+	return pod
+}
+
+// localObjectReferences refers to the objects called names.
+func localObjectReferences(names []string) []corev1.LocalObjectReference {
+	var refs []corev1.LocalObjectReference
+	for _, name := range names {
+		refs = append(refs, corev1.LocalObjectReference{Name: name})
+	}
+	return refs
+}
+
+// newPod returns the template of the pods of myresource.
+func newPod(myresource *v1alpha1.MyResource, image string) *corev1.Pod {
+	labels := childLabels(myresource)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: myresource.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(myresource, v1alpha1.SchemeGroupVersion.WithKind("MyResource")),
+			},
+			Labels: labels,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:  "echoserver",
+					Image: image,
+					Args:  []string{"echoserver"},
+					Env: []corev1.EnvVar{
+						{
+							Name:  "ECHO_MESSAGE",
+							Value: myresource.Spec.Message,
+						},
+						{
+							Name:  "PORT",
+							Value: strconv.Itoa(echoServerPort),
+						},
+						{
+							// spans of the echo server link to the reconcile that created the pod
+							Name:      "ECHO_TRACE_PARENT",
+							ValueFrom: fieldRef(fmt.Sprintf("metadata.annotations['%s']", tracing.AnnotationTraceParent)),
+						},
+					},
+					Ports: []corev1.ContainerPort{
+						{
+							Name:          "http",
+							ContainerPort: echoServerPort,
+						},
+					},
+					LivenessProbe: &corev1.Probe{
+						Handler: corev1.Handler{
+							HTTPGet: &corev1.HTTPGetAction{
+								Path: "/healthz",
+								Port: intstr.FromString("http"),
+							},
+						},
+					},
+					ReadinessProbe: &corev1.Probe{
+						Handler: corev1.Handler{
+							HTTPGet: &corev1.HTTPGetAction{
+								Path: "/readyz",
+								Port: intstr.FromString("http"),
+							},
+						},
+						PeriodSeconds: 2,
+					},
+				},
+			},
+		},
+	}
+
+	if len(myresource.Spec.Routes) > 0 {
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, corev1.EnvVar{
+			Name:  "ECHO_ROUTES",
+			Value: routeTable(myresource),
+		})
+	}
+	if usesTemplate(myresource) {
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, templateEnv(myresource)...)
+	}
+	if usesHotReload(myresource) {
+		mountMessage(pod, childName(myresource.Name, "message"))
+	}
+	if replicas(myresource) > 1 {
+		pod.Spec.TopologySpreadConstraints = defaultTopologySpread(myresource)
+	}
+	applySecurityContext(pod, myresource)
+	return pod
+}
+
+// mountMessage replaces the message and routes env vars of pod by the ConfigMap configMapName,
+// which the echo server watches for changes.
+func mountMessage(pod *corev1.Pod, configMapName string) {
+	container := &pod.Spec.Containers[0]
+	env := make([]corev1.EnvVar, 0, len(container.Env))
+	for _, envVar := range container.Env {
+		if envVar.Name != "ECHO_MESSAGE" && envVar.Name != "ECHO_ROUTES" {
+			env = append(env, envVar)
+		}
+	}
+	container.Env = append(env,
+		corev1.EnvVar{Name: "ECHO_MESSAGE_FILE", Value: messageFilePath},
+		corev1.EnvVar{Name: "ECHO_ROUTES_FILE", Value: routesFilePath})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      messageVolume,
+		MountPath: messageMountPath,
+		ReadOnly:  true,
+	})
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name: messageVolume,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+			},
+		},
+	})
+}
@@ -0,0 +1,195 @@
+package myresource
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+// QuotaEvaluation is the outcome of admitting the MyResources of a namespace to its MyResourceQuotas.
+type QuotaEvaluation struct {
+	// Used is the usage of the admitted MyResources.
+	Used v1alpha1.QuotaResources
+	// Rejected maps the names of the MyResources that don't fit into the quotas to the reason.
+	Rejected map[string]string
+}
+
+// EvaluateQuotas admits the MyResources of namespace to its MyResourceQuotas in the order of their creation.
+// A MyResource is admitted if it fits into all quotas next to the MyResources admitted before it, MyResources
+// that are being deleted don't count. Neither do placed MyResources, their pods don't run in the namespace
+// but in spoke clusters. CPU and memory are only counted for classes that request them, the requests of
+// pods without class are up to the ResourceQuotas and LimitRanges of the namespace. All MyResources are
+// admitted if the namespace has no quotas.
+func EvaluateQuotas(ctx context.Context, c client.Client, namespace string) (*QuotaEvaluation, error) {
+	quotas := &v1alpha1.MyResourceQuotaList{}
+	err := c.List(ctx, quotas, client.InNamespace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MyResourceQuotas: %w", err)
+	}
+
+	evaluation := &QuotaEvaluation{Rejected: map[string]string{}}
+	if len(quotas.Items) == 0 {
+		evaluation.Used = quotaUsage{}.resources()
+		return evaluation, nil
+	}
+
+	myresources := &v1alpha1.MyResourceList{}
+	err = c.List(ctx, myresources, client.InNamespace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MyResources: %w", err)
+	}
+	items := myresources.Items
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].CreationTimestamp.Equal(&items[j].CreationTimestamp) {
+			return items[i].CreationTimestamp.Before(&items[j].CreationTimestamp)
+		}
+		return items[i].Name < items[j].Name
+	})
+
+	used := quotaUsage{}
+	for i := range items {
+		myresource := &items[i]
+		if myresource.DeletionTimestamp != nil || isPlaced(myresource) {
+			continue
+		}
+
+		usage, err := usageOf(ctx, c, myresource)
+		if err != nil {
+			return nil, err
+		}
+		total := used.add(usage)
+		if reason := total.exceeds(quotas.Items); reason != "" {
+			evaluation.Rejected[myresource.Name] = reason
+			continue
+		}
+		used = total
+	}
+
+	evaluation.Used = used.resources()
+	return evaluation, nil
+}
+
+// quotaUsage is what MyResources count against MyResourceQuotas.
+type quotaUsage struct {
+	myresources int32
+	replicas    int32
+	cpu         resource.Quantity
+	memory      resource.Quantity
+}
+
+// usageOf returns the usage of myresource. The requests of its pods come from its class,
+// pods of a class that doesn't exist yet request nothing.
+func usageOf(ctx context.Context, c client.Client, myresource *v1alpha1.MyResource) (quotaUsage, error) {
+	usage := quotaUsage{myresources: 1, replicas: int32(replicas(myresource))}
+
+	class, err := resolveClass(ctx, c, myresource)
+	if err != nil && !reconcileutil.IsTerminal(err) {
+		return quotaUsage{}, err
+	}
+	if class == nil || class.Spec.Resources == nil {
+		return usage, nil
+	}
+
+	requests := class.Spec.Resources.Requests
+	for i := 0; i < replicas(myresource); i++ {
+		usage.cpu.Add(requests[corev1.ResourceCPU])
+		usage.memory.Add(requests[corev1.ResourceMemory])
+	}
+	return usage, nil
+}
+
+func (u quotaUsage) add(other quotaUsage) quotaUsage {
+	sum := quotaUsage{
+		myresources: u.myresources + other.myresources,
+		replicas:    u.replicas + other.replicas,
+		cpu:         u.cpu.DeepCopy(),
+		memory:      u.memory.DeepCopy(),
+	}
+	sum.cpu.Add(other.cpu)
+	sum.memory.Add(other.memory)
+	return sum
+}
+
+// exceeds returns why u doesn't fit into quotas, or an empty string if it fits into all of them.
+func (u quotaUsage) exceeds(quotas []v1alpha1.MyResourceQuota) string {
+	for _, quota := range quotas {
+		hard := quota.Spec.Hard
+		switch {
+		case hard.MyResources != nil && u.myresources > *hard.MyResources:
+			return fmt.Sprintf("MyResourceQuota %q allows %d MyResources", quota.Name, *hard.MyResources)
+		case hard.Replicas != nil && u.replicas > *hard.Replicas:
+			return fmt.Sprintf("MyResourceQuota %q allows %d replicas, %d are requested", quota.Name, *hard.Replicas, u.replicas)
+		case hard.CPU != nil && u.cpu.Cmp(*hard.CPU) > 0:
+			return fmt.Sprintf("MyResourceQuota %q allows %s CPU, %s are requested", quota.Name, hard.CPU.String(), u.cpu.String())
+		case hard.Memory != nil && u.memory.Cmp(*hard.Memory) > 0:
+			return fmt.Sprintf("MyResourceQuota %q allows %s memory, %s are requested", quota.Name, hard.Memory.String(), u.memory.String())
+		}
+	}
+	return ""
+}
+
+func (u quotaUsage) resources() v1alpha1.QuotaResources {
+	myresources := u.myresources
+	replicas := u.replicas
+	cpu := u.cpu.DeepCopy()
+	memory := u.memory.DeepCopy()
+	return v1alpha1.QuotaResources{
+		MyResources: &myresources,
+		Replicas:    &replicas,
+		CPU:         &cpu,
+		Memory:      &memory,
+	}
+}
+
+// quotaExceededError is returned if a MyResource doesn't fit into the MyResourceQuotas of its namespace.
+type quotaExceededError struct {
+	reason string
+}
+
+func (e *quotaExceededError) Error() string {
+	return e.reason
+}
+
+// checkQuota returns a quotaExceededError if myresource isn't admitted by the MyResourceQuotas of its namespace.
+func (c *Controller) checkQuota(ctx context.Context, myresource *v1alpha1.MyResource) error {
+	evaluation, err := EvaluateQuotas(ctx, c.client, myresource.Namespace)
+	if err != nil {
+		return reconcileutil.Transient(err)
+	}
+	if reason, ok := evaluation.Rejected[myresource.Name]; ok {
+		return &quotaExceededError{reason: reason}
+	}
+	return nil
+}
+
+func setQuotaExceeded(status *v1alpha1.MyResourceStatus, myresource *v1alpha1.MyResource, err *quotaExceededError) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionQuotaExceeded,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: myresource.Generation,
+		Reason:             "QuotaExceeded",
+		Message:            fmt.Sprintf("children are left unchanged: %s", err.Error()),
+	})
+}
+
+func clearQuotaExceeded(status *v1alpha1.MyResourceStatus) {
+	meta.RemoveStatusCondition(&status.Conditions, v1alpha1.ConditionQuotaExceeded)
+}
+
+// myResourcesOfQuota maps a MyResourceQuota to the MyResources in its namespace, so that they are admitted again
+// when the quota or its usage changes.
+func myResourcesOfQuota(c client.Client, logger logr.Logger) func(obj client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		return myResourcesIn(c, logger, obj.GetNamespace())
+	}
+}
@@ -0,0 +1,217 @@
+package myresource
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"testing"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/spoke"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func newQuota(name string, hard v1alpha1.QuotaResources) *v1alpha1.MyResourceQuota {
+	return &v1alpha1.MyResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Namespace: testNamespace, Name: name},
+		Spec:       v1alpha1.MyResourceQuotaSpec{Hard: hard},
+	}
+}
+
+// newQuotaMyResource returns a MyResource with replicas pods of class that was created minutes after a fixed time.
+func newQuotaMyResource(name string, replicas int32, class string, minutes int) *v1alpha1.MyResource {
+	myresource := kittesting.NewMyResource(testNamespace, name).WithReplicas(replicas).WithClassName(class).Build()
+	myresource.CreationTimestamp = metav1.NewTime(time.Date(2026, 1, 1, 0, minutes, 0, 0, time.UTC))
+	return myresource
+}
+
+func quantityPtr(value string) *resource.Quantity {
+	quantity := resource.MustParse(value)
+	return &quantity
+}
+
+func TestEvaluateQuotas(t *testing.T) {
+	small := newClass("small", v1alpha1.MyResourceClassSpec{Resources: &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+	}})
+	unlimited := newClass("unlimited", v1alpha1.MyResourceClassSpec{})
+	deleted := newQuotaMyResource("deleted", 1, "unlimited", 0)
+	deleted.Finalizers = []string{"example.com/keep"}
+	deleted.DeletionTimestamp = &metav1.Time{Time: time.Date(2026, 1, 1, 1, 0, 0, 0, time.UTC)}
+	placed := newQuotaMyResource("placed", 3, "unlimited", 0)
+	placed.Spec.Placement = &v1alpha1.Placement{Clusters: []string{"a"}}
+
+	tests := []struct {
+		name     string
+		quotas   []*v1alpha1.MyResourceQuota
+		objs     []*v1alpha1.MyResource
+		rejected []string
+		replicas int32
+		cpu      string
+	}{
+		{
+			name:     "no quotas admit everything",
+			objs:     []*v1alpha1.MyResource{newQuotaMyResource("a", 5, "small", 1)},
+			replicas: 0,
+			cpu:      "0",
+		},
+		{
+			name:     "number of MyResources",
+			quotas:   []*v1alpha1.MyResourceQuota{newQuota("count", v1alpha1.QuotaResources{MyResources: int32Ptr(1)})},
+			objs:     []*v1alpha1.MyResource{newQuotaMyResource("b", 1, "unlimited", 2), newQuotaMyResource("a", 1, "unlimited", 1)},
+			rejected: []string{"b"},
+			replicas: 1,
+			cpu:      "0",
+		},
+		{
+			name:     "creation order breaks ties by name",
+			quotas:   []*v1alpha1.MyResourceQuota{newQuota("count", v1alpha1.QuotaResources{MyResources: int32Ptr(1)})},
+			objs:     []*v1alpha1.MyResource{newQuotaMyResource("b", 1, "unlimited", 1), newQuotaMyResource("a", 1, "unlimited", 1)},
+			rejected: []string{"b"},
+			replicas: 1,
+			cpu:      "0",
+		},
+		{
+			name:     "replicas",
+			quotas:   []*v1alpha1.MyResourceQuota{newQuota("replicas", v1alpha1.QuotaResources{Replicas: int32Ptr(3)})},
+			objs:     []*v1alpha1.MyResource{newQuotaMyResource("a", 2, "unlimited", 1), newQuotaMyResource("b", 2, "unlimited", 2), newQuotaMyResource("c", 1, "unlimited", 3)},
+			rejected: []string{"b"},
+			replicas: 3,
+			cpu:      "0",
+		},
+		{
+			name:     "CPU of the class",
+			quotas:   []*v1alpha1.MyResourceQuota{newQuota("cpu", v1alpha1.QuotaResources{CPU: quantityPtr("250m")})},
+			objs:     []*v1alpha1.MyResource{newQuotaMyResource("a", 2, "small", 1), newQuotaMyResource("b", 1, "small", 2)},
+			rejected: []string{"b"},
+			replicas: 2,
+			cpu:      "200m",
+		},
+		{
+			name:     "memory of the class",
+			quotas:   []*v1alpha1.MyResourceQuota{newQuota("memory", v1alpha1.QuotaResources{Memory: quantityPtr("100Mi")})},
+			objs:     []*v1alpha1.MyResource{newQuotaMyResource("a", 2, "small", 1), newQuotaMyResource("b", 1, "small", 2)},
+			rejected: []string{"a"},
+			replicas: 1,
+			cpu:      "100m",
+		},
+		{
+			name:     "classes without requests only count replicas",
+			quotas:   []*v1alpha1.MyResourceQuota{newQuota("cpu", v1alpha1.QuotaResources{CPU: quantityPtr("0")})},
+			objs:     []*v1alpha1.MyResource{newQuotaMyResource("a", 2, "unlimited", 1), newQuotaMyResource("b", 1, "", 2)},
+			replicas: 3,
+			cpu:      "0",
+		},
+		{
+			name: "all quotas must admit",
+			quotas: []*v1alpha1.MyResourceQuota{
+				newQuota("count", v1alpha1.QuotaResources{MyResources: int32Ptr(5)}),
+				newQuota("replicas", v1alpha1.QuotaResources{Replicas: int32Ptr(1)}),
+			},
+			objs:     []*v1alpha1.MyResource{newQuotaMyResource("a", 1, "unlimited", 1), newQuotaMyResource("b", 1, "unlimited", 2)},
+			rejected: []string{"b"},
+			replicas: 1,
+			cpu:      "0",
+		},
+		{
+			name:     "deleted and placed MyResources don't count",
+			quotas:   []*v1alpha1.MyResourceQuota{newQuota("replicas", v1alpha1.QuotaResources{MyResources: int32Ptr(1), Replicas: int32Ptr(1)})},
+			objs:     []*v1alpha1.MyResource{deleted, placed, newQuotaMyResource("a", 1, "unlimited", 1)},
+			replicas: 1,
+			cpu:      "0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			objs := []client.Object{small, unlimited}
+			for _, quota := range test.quotas {
+				objs = append(objs, quota.DeepCopy())
+			}
+			for _, myresource := range test.objs {
+				objs = append(objs, myresource.DeepCopy())
+			}
+			cluster := newTestCluster(t, objs...)
+
+			evaluation, err := EvaluateQuotas(context.Background(), cluster, testNamespace)
+			if err != nil {
+				t.Fatalf("EvaluateQuotas failed: %v", err)
+			}
+
+			var rejected []string
+			for name := range evaluation.Rejected {
+				rejected = append(rejected, name)
+			}
+			sort.Strings(rejected)
+			if !reflect.DeepEqual(rejected, test.rejected) {
+				t.Errorf("rejected %v (%v), expected %v", rejected, evaluation.Rejected, test.rejected)
+			}
+			if *evaluation.Used.Replicas != test.replicas {
+				t.Errorf("used replicas are %d, expected %d", *evaluation.Used.Replicas, test.replicas)
+			}
+			if cpu := resource.MustParse(test.cpu); evaluation.Used.CPU.Cmp(cpu) != 0 {
+				t.Errorf("used CPU is %s, expected %s", evaluation.Used.CPU, test.cpu)
+			}
+		})
+	}
+}
+
+func TestQuotaExceededCondition(t *testing.T) {
+	quota := newQuota("count", v1alpha1.QuotaResources{MyResources: int32Ptr(1)})
+	cluster := newTestCluster(t, quota,
+		newQuotaMyResource("first", 1, "", 1),
+		newQuotaMyResource("second", 1, "", 2))
+	controller := newTestController(t, cluster, nil)
+
+	reconcileMyResource(t, controller, "second")
+
+	condition := meta.FindStatusCondition(getMyResource(t, cluster, "second").Status.Conditions, v1alpha1.ConditionQuotaExceeded)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		t.Fatalf("MyResource beyond the quota has condition %+v", condition)
+	}
+	if pods := listPods(t, cluster); len(pods) > 0 {
+		t.Errorf("MyResource beyond the quota got %d pods", len(pods))
+	}
+
+	changed := &v1alpha1.MyResourceQuota{}
+	err := cluster.Get(context.Background(), client.ObjectKeyFromObject(quota), changed)
+	if err != nil {
+		t.Fatalf("failed to get MyResourceQuota: %v", err)
+	}
+	changed.Spec.Hard.MyResources = int32Ptr(2)
+	err = cluster.Update(context.Background(), changed)
+	if err != nil {
+		t.Fatalf("failed to update MyResourceQuota: %v", err)
+	}
+	reconcileMyResource(t, controller, "second")
+
+	if condition := meta.FindStatusCondition(getMyResource(t, cluster, "second").Status.Conditions, v1alpha1.ConditionQuotaExceeded); condition != nil {
+		t.Errorf("MyResource within the raised quota still has condition %+v", condition)
+	}
+	if pods := listPods(t, cluster); len(pods) != 1 {
+		t.Errorf("MyResource within the raised quota got %d pods, expected 1", len(pods))
+	}
+}
+
+func TestPlacedMyResourceIsNotLimitedByQuota(t *testing.T) {
+	quota := newQuota("count", v1alpha1.QuotaResources{MyResources: int32Ptr(0)})
+	cluster := newTestCluster(t, quota, newPlacedMyResource("echo", 1, "a"))
+	spokeA := newTestCluster(t)
+	controller := newTestController(t, cluster, func(options *Options) {
+		options.Spokes = spoke.StaticClients{"a": spokeA}
+	})
+
+	reconcileMyResource(t, controller, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	if condition := meta.FindStatusCondition(myresource.Status.Conditions, v1alpha1.ConditionQuotaExceeded); condition != nil {
+		t.Errorf("placed MyResource has condition %+v", condition)
+	}
+	expectSpokeChildren(t, "a", spokeA, myresource, 1)
+}
@@ -0,0 +1,211 @@
+package myresource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sort"
+	"strconv"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+const (
+	defaultRevisionHistoryLimit = 10
+)
+
+// recordedSpec returns the part of the spec of myresource that is recorded in its revision history.
+// Fields controlling the controller itself are left out, a rollback doesn't change them.
+func recordedSpec(myresource *v1alpha1.MyResource) *v1alpha1.MyResourceSpec {
+	spec := myresource.Spec.DeepCopy()
+	spec.Suspend = false
+	spec.RevisionHistoryLimit = nil
+	return spec
+}
+
+func revisionHistoryLimit(myresource *v1alpha1.MyResource) int {
+	if myresource.Spec.RevisionHistoryLimit == nil {
+		return defaultRevisionHistoryLimit
+	}
+	return int(*myresource.Spec.RevisionHistoryLimit)
+}
+
+// listRevisions returns the ControllerRevisions controlled by myresource, oldest first.
+func (c *Controller) listRevisions(ctx context.Context, myresource *v1alpha1.MyResource) ([]*appsv1.ControllerRevision, error) {
+	revisionList := &appsv1.ControllerRevisionList{}
+	err := c.client.List(ctx, revisionList, client.InNamespace(myresource.Namespace), client.MatchingLabels(childLabels(myresource)))
+	if err != nil {
+		return nil, reconcileutil.Transient(fmt.Errorf("failed to list ControllerRevisions: %w", err))
+	}
+
+	revisions := make([]*appsv1.ControllerRevision, 0, len(revisionList.Items))
+	for i := range revisionList.Items {
+		if metav1.IsControlledBy(&revisionList.Items[i], myresource) {
+			revisions = append(revisions, &revisionList.Items[i])
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// recordRevision records the applied spec of myresource as its newest ControllerRevision and prunes
+// the history to the revision history limit. It returns the name of the ControllerRevision.
+func (c *Controller) recordRevision(ctx context.Context, myresource *v1alpha1.MyResource) (string, error) {
+	spec := recordedSpec(myresource)
+	// marshalling can't fail, the spec was just decoded from JSON
+	data, _ := json.Marshal(spec)
+	name := childName(myresource.Name, nameHash(string(data)))
+
+	revisions, err := c.listRevisions(ctx, myresource)
+	if err != nil {
+		return "", err
+	}
+
+	var newest int64
+	var current *appsv1.ControllerRevision
+	for _, revision := range revisions {
+		if revision.Revision > newest {
+			newest = revision.Revision
+		}
+		if revision.Name == name {
+			current = revision
+		}
+	}
+
+	switch {
+	case current == nil:
+		current = &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: myresource.Namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					*metav1.NewControllerRef(myresource, v1alpha1.SchemeGroupVersion.WithKind("MyResource")),
+				},
+				Labels: childLabels(myresource),
+			},
+			Data:     runtime.RawExtension{Raw: data},
+			Revision: newest + 1,
+		}
+		log.FromContext(ctx).Info("recording revision of MyResource", "revision", current.Revision, "controllerRevision", name)
+		err = c.client.Create(ctx, current)
+		if errors.IsAlreadyExists(err) {
+			return "", &nameConflictError{obj: current}
+		}
+		if err != nil {
+			return "", reconcileutil.ClassifyAPIError(fmt.Errorf("failed to create ControllerRevision %q: %w", name, err))
+		}
+		revisions = append(revisions, current)
+	case current.Revision != newest:
+		// the spec returned to an earlier revision, which becomes the newest one again
+		current.Revision = newest + 1
+		err = c.client.Update(ctx, current)
+		if err != nil {
+			return "", reconcileutil.ClassifyAPIError(fmt.Errorf("failed to update ControllerRevision %q: %w", name, err))
+		}
+		sort.Slice(revisions, func(i, j int) bool {
+			return revisions[i].Revision < revisions[j].Revision
+		})
+	}
+
+	return name, c.pruneRevisions(ctx, myresource, revisions, name)
+}
+
+// pruneRevisions deletes the oldest of revisions beyond the revision history limit, except current.
+func (c *Controller) pruneRevisions(ctx context.Context, myresource *v1alpha1.MyResource, revisions []*appsv1.ControllerRevision, current string) error {
+	excess := len(revisions) - 1 - revisionHistoryLimit(myresource)
+	for _, revision := range revisions {
+		if excess <= 0 {
+			break
+		}
+		if revision.Name == current {
+			continue
+		}
+
+		log.FromContext(ctx).V(1).Info("pruning revision of MyResource", "revision", revision.Revision)
+		err := c.deleteChild(ctx, revision)
+		if err != nil {
+			return err
+		}
+		excess--
+	}
+	return nil
+}
+
+// rollback replaces the spec of myresource by the revision requested through the rollback annotation
+// and removes the annotation. MyResources of a MyResourceSet are not rolled back, the MyResourceSet
+// would overwrite their spec again right away.
+func (c *Controller) rollback(ctx context.Context, myresource *v1alpha1.MyResource) error {
+	value := myresource.Annotations[v1alpha1.RollbackToAnnotation]
+	var target *appsv1.ControllerRevision
+	rollbackErr := errorIfSetMember(myresource)
+	if rollbackErr == nil {
+		target, rollbackErr = c.findRollbackTarget(ctx, myresource, value)
+	}
+	if reconcileutil.IsTransient(rollbackErr) {
+		return rollbackErr
+	}
+
+	if target != nil {
+		spec := v1alpha1.MyResourceSpec{}
+		rollbackErr = json.Unmarshal(target.Data.Raw, &spec)
+		if rollbackErr == nil {
+			log.FromContext(ctx).Info("rolling MyResource back", "revision", target.Revision)
+			spec.Suspend = myresource.Spec.Suspend
+			spec.RevisionHistoryLimit = myresource.Spec.RevisionHistoryLimit
+			myresource.Spec = spec
+		}
+	}
+
+	delete(myresource.Annotations, v1alpha1.RollbackToAnnotation)
+	err := c.client.Update(ctx, myresource)
+	if err != nil {
+		return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to roll back: %w", err))
+	}
+	if rollbackErr != nil {
+		return reconcileutil.Terminal(fmt.Errorf("rollback to revision %q failed: %w", value, rollbackErr))
+	}
+	return nil
+}
+
+// errorIfSetMember returns an error if myresource is controlled by a MyResourceSet.
+func errorIfSetMember(myresource *v1alpha1.MyResource) error {
+	owner := metav1.GetControllerOf(myresource)
+	if owner == nil || owner.Kind != "MyResourceSet" || owner.APIVersion != v1alpha1.SchemeGroupVersion.String() {
+		return nil
+	}
+	return reconcileutil.Terminal(fmt.Errorf("the spec is controlled by MyResourceSet %q, roll back its template instead", owner.Name))
+}
+
+// findRollbackTarget returns the recorded revision with the number value, or the one before the
+// current revision if value is "0".
+func (c *Controller) findRollbackTarget(ctx context.Context, myresource *v1alpha1.MyResource, value string) (*appsv1.ControllerRevision, error) {
+	toRevision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || toRevision < 0 {
+		return nil, reconcileutil.Terminal(fmt.Errorf("invalid revision number %q", value))
+	}
+
+	revisions, err := c.listRevisions(ctx, myresource)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		if toRevision == 0 && revision.Name != myresource.Status.CurrentRevision {
+			return revision, nil
+		}
+		if toRevision != 0 && revision.Revision == toRevision {
+			return revision, nil
+		}
+	}
+	return nil, reconcileutil.Terminal(fmt.Errorf("revision %q not found", value))
+}
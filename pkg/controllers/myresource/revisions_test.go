@@ -0,0 +1,167 @@
+package myresource
+
+import (
+	"context"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func listRevisions(t *testing.T, c client.Client) []appsv1.ControllerRevision {
+	t.Helper()
+	revisions := &appsv1.ControllerRevisionList{}
+	err := c.List(context.Background(), revisions, client.InNamespace(testNamespace))
+	if err != nil {
+		t.Fatalf("failed to list ControllerRevisions: %v", err)
+	}
+	return revisions.Items
+}
+
+// applyMessages reconciles the MyResource called echo with each of messages in turn.
+func applyMessages(t *testing.T, cluster *kittesting.Cluster, controller *Controller, messages ...string) {
+	t.Helper()
+	for _, message := range messages {
+		changeMyResource(t, cluster, "echo", func(myresource *v1alpha1.MyResource) {
+			myresource.Spec.Message = message
+		})
+		reconcileMyResource(t, controller, "echo")
+	}
+}
+
+func rollbackTo(t *testing.T, cluster *kittesting.Cluster, controller *Controller, revision string) *v1alpha1.MyResource {
+	t.Helper()
+	changeMyResource(t, cluster, "echo", func(myresource *v1alpha1.MyResource) {
+		myresource.Annotations = map[string]string{v1alpha1.RollbackToAnnotation: revision}
+	})
+	reconcileMyResource(t, controller, "echo")
+	return getMyResource(t, cluster, "echo")
+}
+
+func TestRecordRevisions(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").WithMessage("v1").Build())
+	controller := newTestController(t, cluster, nil)
+	reconcileMyResource(t, controller, "echo")
+	applyMessages(t, cluster, controller, "v2", "v1")
+
+	revisions := listRevisions(t, cluster)
+	if len(revisions) != 2 {
+		t.Fatalf("got %d ControllerRevisions, expected one per distinct spec", len(revisions))
+	}
+	current := getMyResource(t, cluster, "echo").Status.CurrentRevision
+	for _, revision := range revisions {
+		if revision.Name == current && revision.Revision != 3 {
+			t.Errorf("the spec returning to revision %q got number %d, expected 3", revision.Name, revision.Revision)
+		}
+	}
+}
+
+func TestRevisionHistoryIsPruned(t *testing.T) {
+	limit := int32(2)
+	myresource := kittesting.NewMyResource(testNamespace, "echo").WithMessage("v1").Build()
+	myresource.Spec.RevisionHistoryLimit = &limit
+	cluster := newTestCluster(t, myresource)
+	controller := newTestController(t, cluster, nil)
+	reconcileMyResource(t, controller, "echo")
+	applyMessages(t, cluster, controller, "v2", "v3", "v4", "v5")
+
+	revisions := listRevisions(t, cluster)
+	if len(revisions) != 3 {
+		t.Fatalf("got %d ControllerRevisions, expected the current one and %d more", len(revisions), limit)
+	}
+	for _, revision := range revisions {
+		if revision.Revision < 3 {
+			t.Errorf("revision %d was kept although newer ones exceed the limit", revision.Revision)
+		}
+	}
+}
+
+func TestRollbackToPreviousRevision(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").WithMessage("v1").Build())
+	controller := newTestController(t, cluster, nil)
+	reconcileMyResource(t, controller, "echo")
+	applyMessages(t, cluster, controller, "v2", "v3")
+
+	myresource := rollbackTo(t, cluster, controller, "0")
+	if myresource.Spec.Message != "v2" {
+		t.Errorf("rollback to the previous revision set message %q, expected %q", myresource.Spec.Message, "v2")
+	}
+	if _, ok := myresource.Annotations[v1alpha1.RollbackToAnnotation]; ok {
+		t.Error("the rollback annotation was kept")
+	}
+
+	// the first reconcile deletes the outdated pod, the second one creates its replacement
+	reconcileMyResource(t, controller, "echo")
+	reconcileMyResource(t, controller, "echo")
+	pods := listPods(t, cluster)
+	if len(pods) != 1 || !hasEnv(pods[0].Spec.Containers[0].Env, "ECHO_MESSAGE", "v2") {
+		t.Errorf("the pods don't serve the rolled back message: %+v", pods)
+	}
+}
+
+func TestRollbackToRevisionNumber(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").WithMessage("v1").Build())
+	controller := newTestController(t, cluster, nil)
+	reconcileMyResource(t, controller, "echo")
+	applyMessages(t, cluster, controller, "v2", "v3")
+	changeMyResource(t, cluster, "echo", func(myresource *v1alpha1.MyResource) {
+		limit := int32(5)
+		myresource.Spec.RevisionHistoryLimit = &limit
+	})
+
+	myresource := rollbackTo(t, cluster, controller, "1")
+	if myresource.Spec.Message != "v1" {
+		t.Errorf("rollback to revision 1 set message %q, expected %q", myresource.Spec.Message, "v1")
+	}
+	if myresource.Spec.RevisionHistoryLimit == nil || *myresource.Spec.RevisionHistoryLimit != 5 {
+		t.Errorf("rollback changed the revision history limit to %v", myresource.Spec.RevisionHistoryLimit)
+	}
+}
+
+func TestRollbackToUnknownRevision(t *testing.T) {
+	for _, revision := range []string{"42", "latest", "-1"} {
+		t.Run(revision, func(t *testing.T) {
+			cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").WithMessage("v1").Build())
+			controller := newTestController(t, cluster, nil)
+			reconcileMyResource(t, controller, "echo")
+
+			myresource := rollbackTo(t, cluster, controller, revision)
+			if myresource.Spec.Message != "v1" {
+				t.Errorf("failed rollback changed the message to %q", myresource.Spec.Message)
+			}
+			if _, ok := myresource.Annotations[v1alpha1.RollbackToAnnotation]; ok {
+				t.Error("the rollback annotation was kept after the rollback failed")
+			}
+			if !strings.Contains(myresource.Status.LastError, revision) {
+				t.Errorf("lastError %q doesn't report the failed rollback", myresource.Status.LastError)
+			}
+		})
+	}
+}
+
+func TestRollbackOfSetMemberIsRefused(t *testing.T) {
+	myresource := kittesting.NewMyResource(testNamespace, "echo").WithMessage("v1").Build()
+	myresource.OwnerReferences = []metav1.OwnerReference{
+		*metav1.NewControllerRef(&v1alpha1.MyResourceSet{ObjectMeta: metav1.ObjectMeta{Name: "echo", UID: "set-uid"}},
+			v1alpha1.SchemeGroupVersion.WithKind("MyResourceSet")),
+	}
+	cluster := newTestCluster(t, myresource)
+	controller := newTestController(t, cluster, nil)
+	reconcileMyResource(t, controller, "echo")
+	applyMessages(t, cluster, controller, "v2")
+
+	myresource = rollbackTo(t, cluster, controller, "0")
+	if myresource.Spec.Message != "v2" {
+		t.Errorf("MyResource of a MyResourceSet was rolled back to message %q", myresource.Spec.Message)
+	}
+	if _, ok := myresource.Annotations[v1alpha1.RollbackToAnnotation]; ok {
+		t.Error("the rollback annotation was kept")
+	}
+	if !strings.Contains(myresource.Status.LastError, "MyResourceSet") {
+		t.Errorf("lastError %q doesn't explain why the rollback was refused", myresource.Status.LastError)
+	}
+}
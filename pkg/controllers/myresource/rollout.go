@@ -0,0 +1,277 @@
+package myresource
+
+import (
+	"context"
+	"encoding/json"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+const (
+	recreatePodSet = "pod"
+)
+
+// childStatus is the part of the status of a MyResource that is derived from its children.
+type childStatus struct {
+	podName     string
+	className   string
+	serviceName string
+	rollout     *v1alpha1.RolloutStatus
+	revision    string
+	schedule    *v1alpha1.ScheduleStatus
+	clusters    []v1alpha1.ClusterStatus
+}
+
+// usesBlueGreen reports whether spec changes of myresource are rolled out blue/green.
+func usesBlueGreen(myresource *v1alpha1.MyResource) bool {
+	return myresource.Spec.Strategy == v1alpha1.StrategyBlueGreen
+}
+
+// reconcileRecreate keeps a single pod set of the current revision and replaces outdated pods in place.
+func (c *Controller) reconcileRecreate(ctx context.Context, myresource *v1alpha1.MyResource, template *corev1.Pod, revision string, exposure *v1alpha1.Exposure) (reconcile.Result, *childStatus, error) {
+	names, ready, err := c.ensurePodSet(ctx, myresource, template, recreatePodSet, revision)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	err = c.deleteStalePods(ctx, myresource, names)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	err = c.ensureService(ctx, myresource, mainServiceName(myresource), exposure, childLabels(myresource))
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	err = c.deleteService(ctx, myresource, previewServiceName(myresource))
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	return reconcile.Result{}, &childStatus{
+		podName:     names[0],
+		serviceName: mainServiceName(myresource),
+		rollout: &v1alpha1.RolloutStatus{
+			ActiveRevision: revision,
+			Step:           stepOf(ready),
+		},
+	}, nil
+}
+
+// reconcileBlueGreen brings up the pods of a new revision next to the active ones and exposes them
+// through the preview Service. Once the preview is ready and promoted, the main Service is switched
+// over and the previously active pods are deleted. After a switch from the Recreate strategy its pod set
+// is the active one until it is replaced.
+func (c *Controller) reconcileBlueGreen(ctx context.Context, myresource *v1alpha1.MyResource, template *corev1.Pod, revision string, exposure *v1alpha1.Exposure) (reconcile.Result, *childStatus, error) {
+	rollout := &v1alpha1.RolloutStatus{}
+	if myresource.Status.Rollout != nil {
+		rollout = myresource.Status.Rollout.DeepCopy()
+	}
+
+	active := rollout.ActiveRevision
+	if active == "" || active == revision {
+		return c.reconcileStableBlueGreen(ctx, myresource, template, revision, exposure)
+	}
+
+	activeTemplate, err := c.activeTemplate(ctx, myresource, active)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+	activeSet := active
+	recreated, err := c.existingPods(ctx, myresource, recreatePodSet)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+	if len(recreated) > 0 {
+		activeSet = recreatePodSet
+	}
+	activeNames, _, err := c.ensurePodSet(ctx, myresource, activeTemplate, activeSet, active)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	previewNames, previewReady, err := c.ensurePodSet(ctx, myresource, template, revision, revision)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	err = c.deleteStalePods(ctx, myresource, append(append([]string{}, activeNames...), previewNames...))
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	err = c.ensureService(ctx, myresource, mainServiceName(myresource), exposure, revisionSelector(myresource, active))
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	err = c.ensureService(ctx, myresource, previewServiceName(myresource), exposure, revisionSelector(myresource, revision))
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	if rollout.PreviewRevision != revision {
+		rollout.PreviewRevision = revision
+		rollout.PreviewReadyTime = nil
+	}
+
+	status := &childStatus{
+		podName:     activeNames[0],
+		serviceName: mainServiceName(myresource),
+		rollout:     rollout,
+	}
+
+	if !previewReady {
+		rollout.Step = v1alpha1.RolloutStepProgressing
+		rollout.PreviewReadyTime = nil
+		return reconcile.Result{}, status, nil
+	}
+
+	now := metav1.NewTime(c.clock.Now())
+	if rollout.PreviewReadyTime == nil {
+		rollout.PreviewReadyTime = &now
+	}
+
+	promote, requeueAfter := promotionDue(myresource, revision, rollout.PreviewReadyTime.Time, now.Time)
+	if !promote {
+		rollout.Step = v1alpha1.RolloutStepAwaitingPromotion
+		return reconcile.Result{RequeueAfter: requeueAfter}, status, nil
+	}
+
+	log.FromContext(ctx).Info("promoting revision of MyResource", "revision", revision)
+	err = c.ensureService(ctx, myresource, mainServiceName(myresource), exposure, revisionSelector(myresource, revision))
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	err = c.deleteStalePods(ctx, myresource, previewNames)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	err = c.deleteService(ctx, myresource, previewServiceName(myresource))
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	status.podName = previewNames[0]
+	status.rollout = &v1alpha1.RolloutStatus{
+		ActiveRevision: revision,
+		Step:           v1alpha1.RolloutStepStable,
+	}
+	return reconcile.Result{}, status, nil
+}
+
+// activeTemplate returns the pod template of the active revision of a blue/green rollout of myresource, so that
+// its missing pods are recreated while the preview comes up. The template is rebuilt from the newest recorded
+// spec that results in pods of the active revision. It returns nil if there is none, e.g. because the revision
+// history was pruned or the echo server image changed since.
+func (c *Controller) activeTemplate(ctx context.Context, myresource *v1alpha1.MyResource, active string) (*corev1.Pod, error) {
+	revisions, err := c.listRevisions(ctx, myresource)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.clock.Now()
+	for i := len(revisions) - 1; i >= 0; i-- {
+		recorded := myresource.DeepCopy()
+		recorded.Spec = v1alpha1.MyResourceSpec{}
+		if json.Unmarshal(revisions[i].Data.Raw, &recorded.Spec) != nil {
+			continue
+		}
+		schedule, err := evaluateSchedule(recorded, now)
+		if err != nil {
+			continue
+		}
+		recorded.Spec.Message = scheduledMessage(recorded, schedule)
+		class, err := resolveClass(ctx, c.client, recorded)
+		if reconcileutil.IsTransient(err) {
+			return nil, err
+		}
+		if err != nil {
+			continue
+		}
+
+		template := c.podTemplate(recorded)
+		applyClass(template, recorded, class)
+		if podRevision(template) == active {
+			return template, nil
+		}
+	}
+
+	log.FromContext(ctx).Info("no recorded revision matches the active pods, missing active pods are not recreated", "revision", active)
+	return nil, nil
+}
+
+// reconcileStableBlueGreen keeps the pod set of revision, which is the active one, and removes any preview.
+// After a switch from the Recreate strategy the pods of its pod set keep serving until this pod set is ready.
+func (c *Controller) reconcileStableBlueGreen(ctx context.Context, myresource *v1alpha1.MyResource, template *corev1.Pod, revision string, exposure *v1alpha1.Exposure) (reconcile.Result, *childStatus, error) {
+	names, ready, err := c.ensurePodSet(ctx, myresource, template, revision, revision)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	keep := names
+	if !ready {
+		recreated, err := c.existingPods(ctx, myresource, recreatePodSet)
+		if err != nil {
+			return reconcile.Result{}, nil, err
+		}
+		keep = append(append([]string{}, names...), recreated...)
+	}
+	err = c.deleteStalePods(ctx, myresource, keep)
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	err = c.ensureService(ctx, myresource, mainServiceName(myresource), exposure, revisionSelector(myresource, revision))
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	err = c.deleteService(ctx, myresource, previewServiceName(myresource))
+	if err != nil {
+		return reconcile.Result{}, nil, err
+	}
+
+	return reconcile.Result{}, &childStatus{
+		podName:     names[0],
+		serviceName: mainServiceName(myresource),
+		rollout: &v1alpha1.RolloutStatus{
+			ActiveRevision: revision,
+			Step:           stepOf(ready),
+		},
+	}, nil
+}
+
+// promotionDue reports whether the ready preview of revision is to be promoted now. If it isn't,
+// it returns when automatic promotion is due, or zero if the preview waits for the promote annotation.
+func promotionDue(myresource *v1alpha1.MyResource, revision string, readyTime time.Time, now time.Time) (bool, time.Duration) {
+	if myresource.Annotations[v1alpha1.PromoteAnnotation] == revision {
+		return true, 0
+	}
+
+	if myresource.Spec.BlueGreen == nil || myresource.Spec.BlueGreen.AutoPromotionSeconds == nil {
+		return false, 0
+	}
+
+	promotionTime := readyTime.Add(time.Duration(*myresource.Spec.BlueGreen.AutoPromotionSeconds) * time.Second)
+	if !now.Before(promotionTime) {
+		return true, 0
+	}
+	return false, promotionTime.Sub(now)
+}
+
+func stepOf(ready bool) v1alpha1.RolloutStep {
+	if ready {
+		return v1alpha1.RolloutStepStable
+	}
+	return v1alpha1.RolloutStepProgressing
+}
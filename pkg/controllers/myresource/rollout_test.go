@@ -0,0 +1,291 @@
+package myresource
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"testing"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func podsOf(t *testing.T, c *kittesting.Cluster, revision string) []corev1.Pod {
+	t.Helper()
+	var pods []corev1.Pod
+	for _, pod := range listPods(t, c) {
+		if pod.Labels[labelRevision] == revision && pod.DeletionTimestamp == nil {
+			pods = append(pods, pod)
+		}
+	}
+	return pods
+}
+
+func mainServiceRevision(t *testing.T, c *kittesting.Cluster, myresource *v1alpha1.MyResource) string {
+	t.Helper()
+	service := &corev1.Service{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: mainServiceName(myresource)}, service)
+	if err != nil {
+		t.Fatalf("failed to get main Service: %v", err)
+	}
+	return service.Spec.Selector[labelRevision]
+}
+
+func expectRollout(t *testing.T, myresource *v1alpha1.MyResource, step v1alpha1.RolloutStep, active string, preview string) {
+	t.Helper()
+	rollout := myresource.Status.Rollout
+	if rollout == nil || rollout.Step != step || rollout.ActiveRevision != active || rollout.PreviewRevision != preview {
+		t.Fatalf("rollout is %+v, expected step %s with active revision %q and preview revision %q",
+			rollout, step, active, preview)
+	}
+}
+
+// startBlueGreen reconciles a ready blue/green MyResource with message v1 and changes the message to v2.
+// It returns the revisions of both messages.
+func startBlueGreen(t *testing.T, cluster *kittesting.Cluster, controller *Controller) (string, string) {
+	t.Helper()
+	reconcileMyResource(t, controller, "echo")
+	blue := getMyResource(t, cluster, "echo").Status.Rollout.ActiveRevision
+	setPodsReady(t, cluster, blue)
+	reconcileMyResource(t, controller, "echo")
+	expectRollout(t, getMyResource(t, cluster, "echo"), v1alpha1.RolloutStepStable, blue, "")
+
+	changeMyResource(t, cluster, "echo", func(myresource *v1alpha1.MyResource) {
+		myresource.Spec.Message = "v2"
+	})
+	reconcileMyResource(t, controller, "echo")
+	myresource := getMyResource(t, cluster, "echo")
+	green := myresource.Status.Rollout.PreviewRevision
+	expectRollout(t, myresource, v1alpha1.RolloutStepProgressing, blue, green)
+	return blue, green
+}
+
+func newBlueGreenMyResource() *v1alpha1.MyResource {
+	return kittesting.NewMyResource(testNamespace, "echo").
+		WithMessage("v1").
+		WithReplicas(2).
+		WithStrategy(v1alpha1.StrategyBlueGreen).
+		Build()
+}
+
+func TestBlueGreenPromotedByAnnotation(t *testing.T) {
+	cluster := newTestCluster(t, newBlueGreenMyResource())
+	controller := newTestController(t, cluster, nil)
+	blue, green := startBlueGreen(t, cluster, controller)
+
+	if pods := podsOf(t, cluster, green); len(pods) != 2 {
+		t.Errorf("got %d preview pods, expected 2", len(pods))
+	}
+	if revision := mainServiceRevision(t, cluster, getMyResource(t, cluster, "echo")); revision != blue {
+		t.Errorf("main Service selects revision %q during the rollout, expected the active %q", revision, blue)
+	}
+
+	setPodsReady(t, cluster, green)
+	result := reconcileMyResource(t, controller, "echo")
+	expectRollout(t, getMyResource(t, cluster, "echo"), v1alpha1.RolloutStepAwaitingPromotion, blue, green)
+	if result.RequeueAfter != 0 {
+		t.Errorf("preview without auto promotion is requeued after %s", result.RequeueAfter)
+	}
+
+	changeMyResource(t, cluster, "echo", func(myresource *v1alpha1.MyResource) {
+		myresource.Annotations = map[string]string{v1alpha1.PromoteAnnotation: green}
+	})
+	reconcileMyResource(t, controller, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	expectRollout(t, myresource, v1alpha1.RolloutStepStable, green, "")
+	if revision := mainServiceRevision(t, cluster, myresource); revision != green {
+		t.Errorf("main Service selects revision %q after the promotion, expected %q", revision, green)
+	}
+	if pods := podsOf(t, cluster, blue); len(pods) > 0 {
+		t.Errorf("%d pods of the previously active revision were kept", len(pods))
+	}
+}
+
+func TestBlueGreenAutoPromotion(t *testing.T) {
+	myresource := newBlueGreenMyResource()
+	seconds := int32(60)
+	myresource.Spec.BlueGreen = &v1alpha1.BlueGreenStrategy{AutoPromotionSeconds: &seconds}
+	cluster := newTestCluster(t, myresource)
+	fakeClock := clock.NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	controller := newTestController(t, cluster, func(options *Options) {
+		options.Clock = fakeClock
+	})
+	blue, green := startBlueGreen(t, cluster, controller)
+
+	setPodsReady(t, cluster, green)
+	result := reconcileMyResource(t, controller, "echo")
+	expectRollout(t, getMyResource(t, cluster, "echo"), v1alpha1.RolloutStepAwaitingPromotion, blue, green)
+	if result.RequeueAfter != time.Minute {
+		t.Errorf("preview is requeued after %s, expected the auto promotion delay", result.RequeueAfter)
+	}
+
+	fakeClock.Step(30 * time.Second)
+	result = reconcileMyResource(t, controller, "echo")
+	expectRollout(t, getMyResource(t, cluster, "echo"), v1alpha1.RolloutStepAwaitingPromotion, blue, green)
+	if result.RequeueAfter != 30*time.Second {
+		t.Errorf("preview is requeued after %s, expected the rest of the auto promotion delay", result.RequeueAfter)
+	}
+
+	fakeClock.Step(30 * time.Second)
+	reconcileMyResource(t, controller, "echo")
+	expectRollout(t, getMyResource(t, cluster, "echo"), v1alpha1.RolloutStepStable, green, "")
+}
+
+func TestBlueGreenRecreatesActivePods(t *testing.T) {
+	cluster := newTestCluster(t, newBlueGreenMyResource())
+	controller := newTestController(t, cluster, nil)
+	blue, green := startBlueGreen(t, cluster, controller)
+
+	active := podsOf(t, cluster, blue)
+	if len(active) != 2 {
+		t.Fatalf("got %d active pods, expected 2", len(active))
+	}
+	if err := cluster.Delete(context.Background(), &active[0]); err != nil {
+		t.Fatalf("failed to delete active pod: %v", err)
+	}
+
+	reconcileMyResource(t, controller, "echo")
+
+	recreated := podsOf(t, cluster, blue)
+	if len(recreated) != 2 {
+		t.Fatalf("got %d active pods during the rollout, expected the deleted one to be recreated", len(recreated))
+	}
+	for _, pod := range recreated {
+		if !hasEnv(pod.Spec.Containers[0].Env, "ECHO_MESSAGE", "v1") {
+			t.Errorf("active pod %q doesn't serve the active message: %+v", pod.Name, pod.Spec.Containers[0].Env)
+		}
+	}
+	expectRollout(t, getMyResource(t, cluster, "echo"), v1alpha1.RolloutStepProgressing, blue, green)
+}
+
+func TestBlueGreenPreviewIsReplacedByNewerRevision(t *testing.T) {
+	cluster := newTestCluster(t, newBlueGreenMyResource())
+	controller := newTestController(t, cluster, nil)
+	blue, green := startBlueGreen(t, cluster, controller)
+
+	changeMyResource(t, cluster, "echo", func(myresource *v1alpha1.MyResource) {
+		myresource.Spec.Message = "v3"
+	})
+	reconcileMyResource(t, controller, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	newer := myresource.Status.Rollout.PreviewRevision
+	if newer == green {
+		t.Fatalf("preview revision wasn't replaced")
+	}
+	expectRollout(t, myresource, v1alpha1.RolloutStepProgressing, blue, newer)
+	if pods := podsOf(t, cluster, green); len(pods) > 0 {
+		t.Errorf("%d pods of the replaced preview were kept", len(pods))
+	}
+	if pods := podsOf(t, cluster, blue); len(pods) != 2 {
+		t.Errorf("got %d active pods, expected 2", len(pods))
+	}
+}
+
+// podNames returns the names of the pods of myresource that aren't being deleted.
+func podNames(t *testing.T, c *kittesting.Cluster) map[string]bool {
+	t.Helper()
+	names := map[string]bool{}
+	for _, pod := range listPods(t, c) {
+		if pod.DeletionTimestamp == nil {
+			names[pod.Name] = true
+		}
+	}
+	return names
+}
+
+// startRecreate reconciles a ready MyResource with message v1 and the Recreate strategy and returns its revision.
+func startRecreate(t *testing.T, cluster *kittesting.Cluster, controller *Controller) string {
+	t.Helper()
+	reconcileMyResource(t, controller, "echo")
+	revision := getMyResource(t, cluster, "echo").Status.Rollout.ActiveRevision
+	setPodsReady(t, cluster, revision)
+	reconcileMyResource(t, controller, "echo")
+	return revision
+}
+
+func TestSwitchToBlueGreenKeepsRecreatedPodsUntilReady(t *testing.T) {
+	myresource := newBlueGreenMyResource()
+	myresource.Spec.Strategy = v1alpha1.StrategyRecreate
+	cluster := newTestCluster(t, myresource)
+	controller := newTestController(t, cluster, nil)
+	revision := startRecreate(t, cluster, controller)
+	recreated := podNames(t, cluster)
+
+	changeMyResource(t, cluster, "echo", func(myresource *v1alpha1.MyResource) {
+		myresource.Spec.Strategy = v1alpha1.StrategyBlueGreen
+	})
+	reconcileMyResource(t, controller, "echo")
+
+	names := podNames(t, cluster)
+	for name := range recreated {
+		if !names[name] {
+			t.Errorf("pod %q was deleted before the blue/green pod set is ready", name)
+		}
+	}
+	if len(names) != 4 {
+		t.Errorf("got pods %v, expected the blue/green pod set next to the recreated pods", names)
+	}
+	if selected := mainServiceRevision(t, cluster, getMyResource(t, cluster, "echo")); selected != revision {
+		t.Errorf("main Service selects revision %q, expected %q", selected, revision)
+	}
+
+	setPodsReady(t, cluster, revision)
+	reconcileMyResource(t, controller, "echo")
+
+	names = podNames(t, cluster)
+	for name := range recreated {
+		if names[name] {
+			t.Errorf("recreated pod %q was kept after the blue/green pod set became ready", name)
+		}
+	}
+	if len(names) != 2 {
+		t.Errorf("got pods %v, expected the blue/green pod set", names)
+	}
+	expectRollout(t, getMyResource(t, cluster, "echo"), v1alpha1.RolloutStepStable, revision, "")
+}
+
+func TestSwitchToBlueGreenWithNewRevisionKeepsRecreatedPodsActive(t *testing.T) {
+	myresource := newBlueGreenMyResource()
+	myresource.Spec.Strategy = v1alpha1.StrategyRecreate
+	cluster := newTestCluster(t, myresource)
+	controller := newTestController(t, cluster, nil)
+	blue := startRecreate(t, cluster, controller)
+	recreated := podNames(t, cluster)
+
+	changeMyResource(t, cluster, "echo", func(myresource *v1alpha1.MyResource) {
+		myresource.Spec.Strategy = v1alpha1.StrategyBlueGreen
+		myresource.Spec.Message = "v2"
+	})
+	reconcileMyResource(t, controller, "echo")
+
+	myresource = getMyResource(t, cluster, "echo")
+	green := myresource.Status.Rollout.PreviewRevision
+	expectRollout(t, myresource, v1alpha1.RolloutStepProgressing, blue, green)
+	names := podNames(t, cluster)
+	for name := range recreated {
+		if !names[name] {
+			t.Errorf("active pod %q was deleted during the rollout", name)
+		}
+	}
+	if pods := podsOf(t, cluster, blue); len(pods) != 2 {
+		t.Errorf("got %d active pods, expected the 2 recreated ones", len(pods))
+	}
+
+	setPodsReady(t, cluster, green)
+	changeMyResource(t, cluster, "echo", func(myresource *v1alpha1.MyResource) {
+		myresource.Annotations = map[string]string{v1alpha1.PromoteAnnotation: green}
+	})
+	reconcileMyResource(t, controller, "echo")
+
+	expectRollout(t, getMyResource(t, cluster, "echo"), v1alpha1.RolloutStepStable, green, "")
+	names = podNames(t, cluster)
+	for name := range recreated {
+		if names[name] {
+			t.Errorf("recreated pod %q was kept after the promotion", name)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package myresource
+
+import (
+	"encoding/json"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/echoserver"
+)
+
+const (
+	routesKey = "routes"
+)
+
+// routeTable returns the routes of myresource in the JSON format the echo server reads.
+func routeTable(myresource *v1alpha1.MyResource) string {
+	routes := make([]echoserver.Route, 0, len(myresource.Spec.Routes))
+	for _, route := range myresource.Spec.Routes {
+		routes = append(routes, echoserver.Route{
+			Path:        route.Path,
+			PathType:    string(route.PathType),
+			Body:        route.Body,
+			StatusCode:  int(route.StatusCode),
+			ContentType: route.ContentType,
+			Headers:     route.Headers,
+		})
+	}
+
+	// marshalling can't fail, the route table consists of strings and numbers only
+	data, _ := json.Marshal(routes)
+	return string(data)
+}
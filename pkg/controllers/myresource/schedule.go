@@ -0,0 +1,101 @@
+package myresource
+
+import (
+	"fmt"
+	"github.com/robfig/cron/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+// lookbackWindows are searched in order for the last time a cron expression fired. Short windows
+// come first, so that frequently firing expressions don't need to be iterated over a long period.
+// The longest window covers the rarest expressions: February 29 is up to eight years apart, as
+// 2100 is no leap year.
+var lookbackWindows = []time.Duration{
+	time.Hour,
+	24 * time.Hour,
+	31 * 24 * time.Hour,
+	366 * 24 * time.Hour,
+	(8*365 + 2) * 24 * time.Hour,
+}
+
+// evaluateSchedule returns the status of the schedule of myresource at now.
+func evaluateSchedule(myresource *v1alpha1.MyResource, now time.Time) (*v1alpha1.ScheduleStatus, error) {
+	if len(myresource.Spec.Schedule) == 0 {
+		return nil, nil
+	}
+
+	status := &v1alpha1.ScheduleStatus{}
+	var lastChange, nextChange time.Time
+	for i, entry := range myresource.Spec.Schedule {
+		schedule, location, err := parseScheduleEntry(entry)
+		if err != nil {
+			return nil, reconcileutil.Terminal(fmt.Errorf("invalid schedule entry %d: %w", i, err))
+		}
+
+		localNow := now.In(location)
+		if fired, ok := lastFireTime(schedule, localNow); ok && fired.After(lastChange) {
+			lastChange = fired
+			index := int32(i)
+			status.ActiveEntry = &index
+		}
+		if next := schedule.Next(localNow); !next.IsZero() && (nextChange.IsZero() || next.Before(nextChange)) {
+			nextChange = next
+		}
+	}
+
+	if !lastChange.IsZero() {
+		status.LastChangeTime = &metav1.Time{Time: lastChange}
+	}
+	if !nextChange.IsZero() {
+		status.NextChangeTime = &metav1.Time{Time: nextChange}
+	}
+	return status, nil
+}
+
+func parseScheduleEntry(entry v1alpha1.ScheduleEntry) (cron.Schedule, *time.Location, error) {
+	location := time.UTC
+	if entry.TimeZone != "" {
+		var err error
+		location, err = time.LoadLocation(entry.TimeZone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unknown time zone %q: %w", entry.TimeZone, err)
+		}
+	}
+
+	schedule, err := cron.ParseStandard(entry.Cron)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid cron expression %q: %w", entry.Cron, err)
+	}
+	return schedule, location, nil
+}
+
+// lastFireTime returns the last time schedule fired at or before now.
+func lastFireTime(schedule cron.Schedule, now time.Time) (time.Time, bool) {
+	for _, window := range lookbackWindows {
+		fired := schedule.Next(now.Add(-window))
+		if fired.IsZero() || fired.After(now) {
+			continue
+		}
+
+		for {
+			next := schedule.Next(fired)
+			if next.IsZero() || next.After(now) {
+				return fired, true
+			}
+			fired = next
+		}
+	}
+	return time.Time{}, false
+}
+
+// scheduledMessage returns the message of myresource that is active according to status.
+func scheduledMessage(myresource *v1alpha1.MyResource, status *v1alpha1.ScheduleStatus) string {
+	if status == nil || status.ActiveEntry == nil || int(*status.ActiveEntry) >= len(myresource.Spec.Schedule) {
+		return myresource.Spec.Message
+	}
+	return myresource.Spec.Schedule[*status.ActiveEntry].Message
+}
@@ -0,0 +1,221 @@
+package myresource
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"strconv"
+	"testing"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load time zone %q: %v", name, err)
+	}
+	return location
+}
+
+func TestEvaluateSchedule(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name        string
+		entries     []v1alpha1.ScheduleEntry
+		now         time.Time
+		activeEntry *int32
+		lastChange  time.Time
+		nextChange  time.Time
+	}{
+		{
+			name: "latest entry wins",
+			entries: []v1alpha1.ScheduleEntry{
+				{Cron: "0 8 * * *", Message: "day"},
+				{Cron: "0 20 * * *", Message: "night"},
+			},
+			now:         time.Date(2026, 6, 1, 21, 0, 0, 0, time.UTC),
+			activeEntry: int32Ptr(1),
+			lastChange:  time.Date(2026, 6, 1, 20, 0, 0, 0, time.UTC),
+			nextChange:  time.Date(2026, 6, 2, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "entry fires at now",
+			entries:     []v1alpha1.ScheduleEntry{{Cron: "0 8 * * *", Message: "day"}},
+			now:         time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC),
+			activeEntry: int32Ptr(0),
+			lastChange:  time.Date(2026, 6, 1, 8, 0, 0, 0, time.UTC),
+			nextChange:  time.Date(2026, 6, 2, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "time zone",
+			entries:     []v1alpha1.ScheduleEntry{{Cron: "0 9 * * *", TimeZone: "America/New_York", Message: "morning"}},
+			now:         time.Date(2026, 6, 1, 8, 0, 0, 0, newYork),
+			activeEntry: int32Ptr(0),
+			lastChange:  time.Date(2026, 5, 31, 13, 0, 0, 0, time.UTC),
+			nextChange:  time.Date(2026, 6, 1, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "entries in different time zones",
+			entries: []v1alpha1.ScheduleEntry{
+				{Cron: "0 9 * * *", TimeZone: "Europe/Berlin", Message: "berlin"},
+				{Cron: "0 9 * * *", TimeZone: "America/New_York", Message: "new york"},
+			},
+			now:         time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC),
+			activeEntry: int32Ptr(0),
+			lastChange:  time.Date(2026, 6, 1, 7, 0, 0, 0, time.UTC),
+			nextChange:  time.Date(2026, 6, 1, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "skipped by the change to daylight saving time",
+			entries:     []v1alpha1.ScheduleEntry{{Cron: "30 2 * * *", TimeZone: "Europe/Berlin", Message: "night"}},
+			now:         time.Date(2026, 3, 29, 12, 0, 0, 0, berlin),
+			activeEntry: int32Ptr(0),
+			lastChange:  time.Date(2026, 3, 28, 2, 30, 0, 0, berlin),
+			nextChange:  time.Date(2026, 3, 30, 2, 30, 0, 0, berlin),
+		},
+		{
+			name:        "fires in both repeated hours at the change from daylight saving time",
+			entries:     []v1alpha1.ScheduleEntry{{Cron: "30 2 * * *", TimeZone: "Europe/Berlin", Message: "night"}},
+			now:         time.Date(2026, 10, 25, 1, 45, 0, 0, time.UTC),
+			activeEntry: int32Ptr(0),
+			lastChange:  time.Date(2026, 10, 25, 1, 30, 0, 0, time.UTC),
+			nextChange:  time.Date(2026, 10, 26, 1, 30, 0, 0, time.UTC),
+		},
+		{
+			name:        "once a year",
+			entries:     []v1alpha1.ScheduleEntry{{Cron: "0 0 1 1 *", Message: "happy new year"}},
+			now:         time.Date(2026, 12, 31, 23, 0, 0, 0, time.UTC),
+			activeEntry: int32Ptr(0),
+			lastChange:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			nextChange:  time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "leap day",
+			entries:     []v1alpha1.ScheduleEntry{{Cron: "0 12 29 2 *", Message: "leap day"}},
+			now:         time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+			activeEntry: int32Ptr(0),
+			lastChange:  time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+			nextChange:  time.Date(2028, 2, 29, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:        "leap day across a century that is no leap year",
+			entries:     []v1alpha1.ScheduleEntry{{Cron: "0 12 29 2 *", Message: "leap day"}},
+			now:         time.Date(2103, 3, 1, 0, 0, 0, 0, time.UTC),
+			activeEntry: int32Ptr(0),
+			lastChange:  time.Date(2096, 2, 29, 12, 0, 0, 0, time.UTC),
+			nextChange:  time.Date(2104, 2, 29, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "never fires",
+			entries: []v1alpha1.ScheduleEntry{{Cron: "0 0 31 2 *", Message: "never"}},
+			now:     time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			myresource := kittesting.NewMyResource(testNamespace, "echo").WithMessage("default").Build()
+			myresource.Spec.Schedule = test.entries
+
+			status, err := evaluateSchedule(myresource, test.now)
+			if err != nil {
+				t.Fatalf("failed to evaluate schedule: %v", err)
+			}
+			if (status.ActiveEntry == nil) != (test.activeEntry == nil) ||
+				(status.ActiveEntry != nil && *status.ActiveEntry != *test.activeEntry) {
+				t.Errorf("active entry is %v, expected %v", formatEntry(status.ActiveEntry), formatEntry(test.activeEntry))
+			}
+			expectTime(t, "last change", status.LastChangeTime, test.lastChange)
+			expectTime(t, "next change", status.NextChangeTime, test.nextChange)
+		})
+	}
+}
+
+func TestEvaluateScheduleRejectsInvalidEntries(t *testing.T) {
+	tests := map[string]v1alpha1.ScheduleEntry{
+		"invalid cron expression": {Cron: "every day"},
+		"unknown time zone":       {Cron: "0 8 * * *", TimeZone: "Europe/Atlantis"},
+	}
+	for name, entry := range tests {
+		t.Run(name, func(t *testing.T) {
+			myresource := kittesting.NewMyResource(testNamespace, "echo").WithSchedule(entry).Build()
+			_, err := evaluateSchedule(myresource, time.Now())
+			if !reconcileutil.IsTerminal(err) {
+				t.Errorf("entry %+v returned %v, expected a terminal error", entry, err)
+			}
+		})
+	}
+}
+
+func TestScheduleSwitchesMessage(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").
+		WithMessage("default").
+		WithSchedule(v1alpha1.ScheduleEntry{Cron: "0 8 * * *", Message: "day"}).
+		WithSchedule(v1alpha1.ScheduleEntry{Cron: "0 20 * * *", Message: "night"}).
+		Build())
+	fakeClock := clock.NewFakeClock(time.Date(2026, 6, 1, 10, 0, 0, 0, time.UTC))
+	controller := newTestController(t, cluster, func(options *Options) {
+		options.Clock = fakeClock
+	})
+
+	result := reconcileMyResource(t, controller, "echo")
+	if result.RequeueAfter != 10*time.Hour {
+		t.Errorf("requeued after %s, expected the time until the next entry fires", result.RequeueAfter)
+	}
+	expectMessage(t, cluster, "day")
+
+	fakeClock.Step(10 * time.Hour)
+	// the first reconcile deletes the outdated pod, the second one creates its replacement
+	reconcileMyResource(t, controller, "echo")
+	result = reconcileMyResource(t, controller, "echo")
+	if result.RequeueAfter != 12*time.Hour {
+		t.Errorf("requeued after %s, expected the time until the next entry fires", result.RequeueAfter)
+	}
+	expectMessage(t, cluster, "night")
+
+	myresource := getMyResource(t, cluster, "echo")
+	if myresource.Spec.Message != "default" {
+		t.Errorf("the schedule changed spec.message to %q", myresource.Spec.Message)
+	}
+	if myresource.Status.Schedule == nil || myresource.Status.Schedule.ActiveEntry == nil || *myresource.Status.Schedule.ActiveEntry != 1 {
+		t.Errorf("schedule status is %+v, expected entry 1 to be active", myresource.Status.Schedule)
+	}
+}
+
+func expectMessage(t *testing.T, cluster *kittesting.Cluster, message string) {
+	t.Helper()
+	pods := listPods(t, cluster)
+	if len(pods) != 1 || !hasEnv(pods[0].Spec.Containers[0].Env, "ECHO_MESSAGE", message) {
+		t.Errorf("the pods don't serve message %q: %+v", message, pods)
+	}
+}
+
+// expectTime compares actual with expected, the zero time stands for no time at all.
+func expectTime(t *testing.T, name string, actual *metav1.Time, expected time.Time) {
+	t.Helper()
+	if actual == nil {
+		if !expected.IsZero() {
+			t.Errorf("%s is unset, expected %s", name, expected.UTC())
+		}
+		return
+	}
+	if !actual.Time.Equal(expected) {
+		t.Errorf("%s is %s, expected %s", name, actual.UTC(), expected.UTC())
+	}
+}
+
+func formatEntry(entry *int32) string {
+	if entry == nil {
+		return "none"
+	}
+	return strconv.Itoa(int(*entry))
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
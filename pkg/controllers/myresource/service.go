@@ -0,0 +1,166 @@
+package myresource
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+const (
+	servicePort = 80
+)
+
+func mainServiceName(myresource *v1alpha1.MyResource) string {
+	return childName(myresource.Name, "svc")
+}
+
+func previewServiceName(myresource *v1alpha1.MyResource) string {
+	return childName(myresource.Name, "preview")
+}
+
+// ensureService creates or updates the Service called name, which exposes the pods matching selector.
+func (c *Controller) ensureService(ctx context.Context, myresource *v1alpha1.MyResource, name string, exposure *v1alpha1.Exposure, selector map[string]string) error {
+	desired := newService(myresource, name, exposure, selector)
+
+	service := &corev1.Service{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: name, Namespace: myresource.Namespace}, service)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcileutil.Transient(fmt.Errorf("failed to get Service %q: %w", name, err))
+		}
+
+		log.FromContext(ctx).Info("creating Service", "service", name)
+		err = c.client.Create(ctx, desired)
+		if err != nil {
+			return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to create Service %q: %w", name, err))
+		}
+		return nil
+	}
+
+	owned, err := c.claim(ctx, myresource, service)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		return &nameConflictError{obj: service}
+	}
+
+	keepNodePorts(desired, service)
+	annotations := map[string]string{}
+	for key, value := range service.Annotations {
+		annotations[key] = value
+	}
+	for key, value := range desired.Annotations {
+		annotations[key] = value
+	}
+
+	if equality.Semantic.DeepEqual(service.Spec.Selector, desired.Spec.Selector) &&
+		equality.Semantic.DeepEqual(service.Spec.Ports, desired.Spec.Ports) &&
+		service.Spec.Type == desired.Spec.Type &&
+		equality.Semantic.DeepEqual(service.Annotations, annotations) {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("updating Service", "service", name)
+	service.Annotations = annotations
+	service.Spec.Type = desired.Spec.Type
+	service.Spec.Selector = desired.Spec.Selector
+	service.Spec.Ports = desired.Spec.Ports
+	err = c.client.Update(ctx, service)
+	if err != nil {
+		return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to update Service %q: %w", name, err))
+	}
+	return nil
+}
+
+// deleteService deletes the Service called name if it is controlled by myresource.
+func (c *Controller) deleteService(ctx context.Context, myresource *v1alpha1.MyResource, name string) error {
+	service := &corev1.Service{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: name, Namespace: myresource.Namespace}, service)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return reconcileutil.Transient(fmt.Errorf("failed to get Service %q: %w", name, err))
+	}
+
+	if !metav1.IsControlledBy(service, myresource) {
+		return nil
+	}
+
+	log.FromContext(ctx).Info("deleting Service", "service", name)
+	return c.deleteChild(ctx, service)
+}
+
+// revisionSelector returns the selector of the pods of myresource with the given revision.
+func revisionSelector(myresource *v1alpha1.MyResource, revision string) map[string]string {
+	selector := childLabels(myresource)
+	selector[labelRevision] = revision
+	return selector
+}
+
+// keepNodePorts copies the node ports allocated for service into desired, so that they aren't reallocated
+// on every update.
+func keepNodePorts(desired *corev1.Service, service *corev1.Service) {
+	if desired.Spec.Type == corev1.ServiceTypeClusterIP {
+		return
+	}
+	for i := range desired.Spec.Ports {
+		for _, port := range service.Spec.Ports {
+			if port.Name == desired.Spec.Ports[i].Name {
+				desired.Spec.Ports[i].NodePort = port.NodePort
+			}
+		}
+	}
+}
+
+func newService(myresource *v1alpha1.MyResource, name string, exposure *v1alpha1.Exposure, selector map[string]string) *corev1.Service {
+	serviceType := corev1.ServiceTypeClusterIP
+	if exposure.ServiceType != "" {
+		serviceType = exposure.ServiceType
+	}
+	port := int32(servicePort)
+	if exposure.Port != nil {
+		port = *exposure.Port
+	}
+	var annotations map[string]string
+	if len(exposure.Annotations) > 0 {
+		annotations = map[string]string{}
+		for key, value := range exposure.Annotations {
+			annotations[key] = value
+		}
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: myresource.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(myresource, v1alpha1.SchemeGroupVersion.WithKind("MyResource")),
+			},
+			Labels:      childLabels(myresource),
+			Annotations: annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Selector: selector,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       port,
+					TargetPort: intstr.FromString("http"),
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,79 @@
+package myresource
+
+import (
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/echotemplate"
+)
+
+// usesTemplate reports whether the message of myresource is rendered as template.
+func usesTemplate(myresource *v1alpha1.MyResource) bool {
+	return myresource.Spec.MessageFormat == v1alpha1.MessageFormatTemplate
+}
+
+// validateTemplate returns an error if a message or a route body of myresource is a template that can't be rendered.
+func validateTemplate(myresource *v1alpha1.MyResource) error {
+	if !usesTemplate(myresource) {
+		return nil
+	}
+
+	err := echotemplate.Validate(myresource.Spec.Message)
+	if err != nil {
+		return err
+	}
+	for i, entry := range myresource.Spec.Schedule {
+		err := echotemplate.Validate(entry.Message)
+		if err != nil {
+			return fmt.Errorf("schedule entry %d: %w", i, err)
+		}
+	}
+	for _, route := range myresource.Spec.Routes {
+		err := echotemplate.Validate(route.Body)
+		if err != nil {
+			return fmt.Errorf("route %q: %w", route.Path, err)
+		}
+	}
+	return nil
+}
+
+func setInvalidTemplate(status *v1alpha1.MyResourceStatus, myresource *v1alpha1.MyResource, err error) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionInvalidTemplate,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: myresource.Generation,
+		Reason:             "TemplateError",
+		Message:            err.Error(),
+	})
+}
+
+func clearInvalidTemplate(status *v1alpha1.MyResourceStatus) {
+	meta.RemoveStatusCondition(&status.Conditions, v1alpha1.ConditionInvalidTemplate)
+}
+
+// templateEnv returns the env vars that let the echo server render the message template of myresource.
+func templateEnv(myresource *v1alpha1.MyResource) []corev1.EnvVar {
+	env := []corev1.EnvVar{
+		{Name: "ECHO_MESSAGE_FORMAT", Value: string(v1alpha1.MessageFormatTemplate)},
+		{Name: "POD_NAME", ValueFrom: fieldRef("metadata.name")},
+		{Name: "POD_NAMESPACE", ValueFrom: fieldRef("metadata.namespace")},
+		{Name: "NODE_NAME", ValueFrom: fieldRef("spec.nodeName")},
+	}
+	if len(myresource.Spec.TemplateHeaders) > 0 {
+		env = append(env, corev1.EnvVar{
+			Name:  "ECHO_TEMPLATE_HEADERS",
+			Value: strings.Join(myresource.Spec.TemplateHeaders, ","),
+		})
+	}
+	return env
+}
+
+func fieldRef(fieldPath string) *corev1.EnvVarSource {
+	return &corev1.EnvVarSource{
+		FieldRef: &corev1.ObjectFieldSelector{FieldPath: fieldPath},
+	}
+}
@@ -0,0 +1,62 @@
+package myresource
+
+import (
+	"context"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func TestInvalidTemplateIsRejected(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").
+		WithMessage("{{ .Request.Body }}").
+		WithMessageFormat(v1alpha1.MessageFormatTemplate).
+		Build())
+	controller := newTestController(t, cluster, nil)
+
+	reconcileMyResource(t, controller, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	if !meta.IsStatusConditionTrue(myresource.Status.Conditions, v1alpha1.ConditionInvalidTemplate) {
+		t.Errorf("condition %s is not true: %+v", v1alpha1.ConditionInvalidTemplate, myresource.Status.Conditions)
+	}
+	if pods := listPods(t, cluster); len(pods) > 0 {
+		t.Errorf("a MyResource with an invalid template got %d pods", len(pods))
+	}
+
+	myresource.Spec.Message = "{{ .Request.Path }}"
+	if err := cluster.Update(context.Background(), myresource); err != nil {
+		t.Fatalf("failed to update MyResource: %v", err)
+	}
+	reconcileMyResource(t, controller, "echo")
+
+	myresource = getMyResource(t, cluster, "echo")
+	if meta.FindStatusCondition(myresource.Status.Conditions, v1alpha1.ConditionInvalidTemplate) != nil {
+		t.Errorf("condition %s was kept for a valid template", v1alpha1.ConditionInvalidTemplate)
+	}
+	pods := listPods(t, cluster)
+	if len(pods) != 1 {
+		t.Fatalf("got %d pods, expected 1", len(pods))
+	}
+	if !hasEnv(pods[0].Spec.Containers[0].Env, "ECHO_MESSAGE_FORMAT", string(v1alpha1.MessageFormatTemplate)) {
+		t.Errorf("the pod doesn't render the message as template: %+v", pods[0].Spec.Containers[0].Env)
+	}
+}
+
+func TestInvalidRouteTemplateIsRejected(t *testing.T) {
+	cluster := newTestCluster(t, kittesting.NewMyResource(testNamespace, "echo").
+		WithMessage("hello").
+		WithMessageFormat(v1alpha1.MessageFormatTemplate).
+		WithRoute(v1alpha1.Route{Path: "/broken", Body: "{{ shout }}"}).
+		Build())
+	controller := newTestController(t, cluster, nil)
+
+	reconcileMyResource(t, controller, "echo")
+
+	myresource := getMyResource(t, cluster, "echo")
+	if !meta.IsStatusConditionTrue(myresource.Status.Conditions, v1alpha1.ConditionInvalidTemplate) {
+		t.Errorf("condition %s is not true: %+v", v1alpha1.ConditionInvalidTemplate, myresource.Status.Conditions)
+	}
+}
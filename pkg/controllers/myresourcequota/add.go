@@ -0,0 +1,63 @@
+package myresourcequota
+
+import (
+	"context"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/logging"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+)
+
+func AddControllerToManager(mgr manager.Manager, options reconcileutil.Options) error {
+	controller, err := CreateController(tracing.WrapClient(mgr.GetClient()))
+	if err != nil {
+		return err
+	}
+
+	logger := mgr.GetLogger().WithName("myresourcequota")
+	return builder.ControllerManagedBy(mgr).
+		For(&v1alpha1.MyResourceQuota{}).
+		Watches(&source.Kind{Type: &v1alpha1.MyResource{}}, handler.EnqueueRequestsFromMapFunc(quotasOfNamespace(mgr.GetClient(), logger))).
+		Watches(&source.Kind{Type: &v1alpha1.MyResourceClass{}}, handler.EnqueueRequestsFromMapFunc(allQuotas(mgr.GetClient(), logger))).
+		WithOptions(options.ControllerOptions()).
+		Complete(tracing.WrapReconciler("MyResourceQuota", logging.WrapReconciler(logging.KeyMyResourceQuota, controller)))
+}
+
+// quotasOfNamespace maps a MyResource to the MyResourceQuotas in its namespace, whose usage it changes.
+func quotasOfNamespace(c client.Client, logger logr.Logger) func(obj client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		return listQuotas(c, logger, client.InNamespace(obj.GetNamespace()))
+	}
+}
+
+// allQuotas maps a MyResourceClass to every MyResourceQuota. The class can be used in any namespace and
+// its requests count against the quotas there.
+func allQuotas(c client.Client, logger logr.Logger) func(obj client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		return listQuotas(c, logger)
+	}
+}
+
+func listQuotas(c client.Client, logger logr.Logger, opts ...client.ListOption) []reconcile.Request {
+	quotas := &v1alpha1.MyResourceQuotaList{}
+	err := c.List(context.Background(), quotas, opts...)
+	if err != nil {
+		logger.Error(err, "failed to list MyResourceQuotas")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(quotas.Items))
+	for _, quota := range quotas.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: quota.Name, Namespace: quota.Namespace}})
+	}
+	return requests
+}
@@ -0,0 +1,71 @@
+package myresourcequota
+
+import (
+	"context"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/myresource"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/logging"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+)
+
+// Controller reports the usage of MyResourceQuotas. The quotas are enforced by the MyResource controller,
+// both evaluate them with myresource.EvaluateQuotas so that they agree on the admitted MyResources.
+type Controller struct {
+	client client.Client
+}
+
+func CreateController(client client.Client) (reconcile.Reconciler, error) {
+	controller := Controller{
+		client: client,
+	}
+	return &controller, nil
+}
+
+func (c *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	quota := &v1alpha1.MyResourceQuota{}
+	err := c.client.Get(ctx, req.NamespacedName, quota)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.FromContext(ctx).V(1).Info("MyResourceQuota no longer exists")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	tracing.RecordObject(ctx, quota)
+	ctx, logger := logging.WithGeneration(ctx, quota)
+
+	if quota.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+
+	logger.V(1).Info("reconciling MyResourceQuota")
+
+	evaluation, err := myresource.EvaluateQuotas(ctx, c.client, quota.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, c.updateStatus(ctx, quota, func(status *v1alpha1.MyResourceQuotaStatus) {
+		status.ObservedGeneration = quota.Generation
+		status.Used = evaluation.Used
+		status.RejectedMyResources = int32(len(evaluation.Rejected))
+	})
+}
+
+// updateStatus applies mutate to the status of quota and writes it back if anything changed.
+func (c *Controller) updateStatus(ctx context.Context, quota *v1alpha1.MyResourceQuota, mutate func(status *v1alpha1.MyResourceQuotaStatus)) error {
+	status := quota.Status.DeepCopy()
+	mutate(status)
+	if equality.Semantic.DeepEqual(status, &quota.Status) {
+		return nil
+	}
+
+	quota.Status = *status
+	return c.client.Status().Update(ctx, quota)
+}
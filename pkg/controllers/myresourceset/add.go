@@ -0,0 +1,52 @@
+package myresourceset
+
+import (
+	"context"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/logging"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+)
+
+func AddControllerToManager(mgr manager.Manager, options reconcileutil.Options) error {
+	controller, err := CreateController(tracing.WrapClient(mgr.GetClient()))
+	if err != nil {
+		return err
+	}
+
+	return builder.ControllerManagedBy(mgr).
+		For(&v1alpha1.MyResourceSet{}).
+		Owns(&v1alpha1.MyResource{}).
+		Watches(&source.Kind{Type: &corev1.Namespace{}}, handler.EnqueueRequestsFromMapFunc(allSets(mgr.GetClient(), mgr.GetLogger().WithName("myresourceset")))).
+		WithOptions(options.ControllerOptions()).
+		Complete(tracing.WrapReconciler("MyResourceSet", logging.WrapReconciler(logging.KeyMyResourceSet, controller)))
+}
+
+// allSets maps a namespace to every MyResourceSet. A label change can make a namespace match a set or stop
+// matching it, so each set has to check its selector again.
+func allSets(c client.Client, logger logr.Logger) func(obj client.Object) []reconcile.Request {
+	return func(obj client.Object) []reconcile.Request {
+		sets := &v1alpha1.MyResourceSetList{}
+		err := c.List(context.Background(), sets)
+		if err != nil {
+			logger.Error(err, "failed to list MyResourceSets for namespace", "namespace", obj.GetName())
+			return nil
+		}
+
+		requests := make([]reconcile.Request, 0, len(sets.Items))
+		for _, set := range sets.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: set.Name}})
+		}
+		return requests
+	}
+}
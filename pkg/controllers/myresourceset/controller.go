@@ -0,0 +1,281 @@
+package myresourceset
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sort"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/logging"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+)
+
+type Controller struct {
+	client client.Client
+}
+
+func CreateController(client client.Client) (reconcile.Reconciler, error) {
+	controller := Controller{
+		client: client,
+	}
+	return &controller, nil
+}
+
+func (c *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	set := &v1alpha1.MyResourceSet{}
+	err := c.client.Get(ctx, req.NamespacedName, set)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			log.FromContext(ctx).V(1).Info("MyResourceSet no longer exists")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	tracing.RecordObject(ctx, set)
+	ctx, logger := logging.WithGeneration(ctx, set)
+
+	if set.DeletionTimestamp != nil {
+		// the MyResources are deleted by the garbage collector
+		return reconcile.Result{}, nil
+	}
+
+	logger.V(1).Info("reconciling MyResourceSet")
+
+	selector, err := metav1.LabelSelectorAsSelector(&set.Spec.NamespaceSelector)
+	if err != nil {
+		logger.Error(err, "MyResourceSet has an invalid namespace selector")
+		return reconcile.Result{}, c.updateStatus(ctx, set, func(status *v1alpha1.MyResourceSetStatus) {
+			setReady(status, set, metav1.ConditionFalse, "InvalidSelector", err.Error())
+		})
+	}
+
+	namespaces, err := c.matchingNamespaces(ctx, selector)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	var retryErr error
+	statuses := make([]v1alpha1.NamespaceStatus, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		myresource, err := c.ensureMyResource(ctx, set, namespace)
+		if err != nil && !reconcileutil.IsTerminal(err) {
+			logger.V(1).Info("failed to reconcile MyResource of MyResourceSet", "namespace", namespace, "error", err.Error())
+			if retryErr == nil {
+				retryErr = err
+			}
+		}
+		statuses = append(statuses, namespaceStatus(namespace, myresource, err))
+	}
+
+	err = c.deleteUnselected(ctx, set, namespaces)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = c.updateStatus(ctx, set, func(status *v1alpha1.MyResourceSetStatus) {
+		aggregateStatus(status, set, statuses)
+	})
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{}, retryErr
+}
+
+// matchingNamespaces returns the sorted names of the namespaces selector matches. Terminating namespaces are skipped,
+// nothing can be created in them.
+func (c *Controller) matchingNamespaces(ctx context.Context, selector labels.Selector) ([]string, error) {
+	namespaceList := &corev1.NamespaceList{}
+	err := c.client.List(ctx, namespaceList, client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, reconcileutil.Transient(fmt.Errorf("failed to list namespaces: %w", err))
+	}
+
+	namespaces := make([]string, 0, len(namespaceList.Items))
+	for _, namespace := range namespaceList.Items {
+		if namespace.DeletionTimestamp == nil {
+			namespaces = append(namespaces, namespace.Name)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// ensureMyResource creates or updates the MyResource of set in namespace. A MyResource of the same name
+// that isn't controlled by set is left alone and reported as terminal error.
+func (c *Controller) ensureMyResource(ctx context.Context, set *v1alpha1.MyResourceSet, namespace string) (*v1alpha1.MyResource, error) {
+	desired := newMyResource(set, namespace)
+
+	myresource := &v1alpha1.MyResource{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: namespace}, myresource)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, reconcileutil.Transient(fmt.Errorf("failed to get MyResource: %w", err))
+		}
+
+		log.FromContext(ctx).Info("creating MyResource of MyResourceSet", logging.KeyMyResource, logging.Ref(namespace, desired.Name))
+		err = c.client.Create(ctx, desired)
+		if err != nil {
+			return nil, reconcileutil.ClassifyAPIError(fmt.Errorf("failed to create MyResource: %w", err))
+		}
+		return desired, nil
+	}
+
+	if !metav1.IsControlledBy(myresource, set) {
+		return myresource, reconcileutil.Terminal(fmt.Errorf("MyResource %q exists but is not controlled by this MyResourceSet", myresource.Name))
+	}
+
+	// labels and annotations of the template are added, others are kept so that the MyResource can still
+	// be suspended or promoted through annotations. The spec always follows the template, a rollback is
+	// refused by the MyResource controller as it would be undone here.
+	labels := merge(myresource.Labels, desired.Labels)
+	annotations := merge(myresource.Annotations, desired.Annotations)
+	if equality.Semantic.DeepEqual(myresource.Spec, desired.Spec) &&
+		equality.Semantic.DeepEqual(myresource.Labels, labels) &&
+		equality.Semantic.DeepEqual(myresource.Annotations, annotations) {
+		return myresource, nil
+	}
+
+	log.FromContext(ctx).Info("updating MyResource of MyResourceSet", logging.KeyMyResource, logging.Ref(namespace, desired.Name))
+	myresource.Labels = labels
+	myresource.Annotations = annotations
+	myresource.Spec = desired.Spec
+	err = c.client.Update(ctx, myresource)
+	if err != nil {
+		return myresource, reconcileutil.ClassifyAPIError(fmt.Errorf("failed to update MyResource: %w", err))
+	}
+	return myresource, nil
+}
+
+// deleteUnselected deletes the MyResources of set outside of namespaces.
+func (c *Controller) deleteUnselected(ctx context.Context, set *v1alpha1.MyResourceSet, namespaces []string) error {
+	selected := map[string]bool{}
+	for _, namespace := range namespaces {
+		selected[namespace] = true
+	}
+
+	myresources := &v1alpha1.MyResourceList{}
+	err := c.client.List(ctx, myresources)
+	if err != nil {
+		return reconcileutil.Transient(fmt.Errorf("failed to list MyResources of MyResourceSet %q: %w", set.Name, err))
+	}
+
+	for i := range myresources.Items {
+		myresource := &myresources.Items[i]
+		if selected[myresource.Namespace] || !metav1.IsControlledBy(myresource, set) || myresource.DeletionTimestamp != nil {
+			continue
+		}
+
+		log.FromContext(ctx).Info("deleting MyResource of MyResourceSet", logging.KeyMyResource, logging.RefOf(myresource))
+		err = c.client.Delete(ctx, myresource, client.Preconditions{UID: &myresource.UID})
+		if err != nil && !errors.IsNotFound(err) {
+			return reconcileutil.ClassifyAPIError(fmt.Errorf("failed to delete MyResource %s/%s: %w", myresource.Namespace, myresource.Name, err))
+		}
+	}
+	return nil
+}
+
+// updateStatus applies mutate to the status of set and writes it back if anything changed.
+func (c *Controller) updateStatus(ctx context.Context, set *v1alpha1.MyResourceSet, mutate func(status *v1alpha1.MyResourceSetStatus)) error {
+	status := set.Status.DeepCopy()
+	mutate(status)
+	if equality.Semantic.DeepEqual(status, &set.Status) {
+		return nil
+	}
+
+	set.Status = *status
+	return c.client.Status().Update(ctx, set)
+}
+
+func newMyResource(set *v1alpha1.MyResourceSet, namespace string) *v1alpha1.MyResource {
+	return &v1alpha1.MyResource{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        set.Name,
+			Namespace:   namespace,
+			Labels:      merge(nil, set.Spec.Template.Labels),
+			Annotations: merge(nil, set.Spec.Template.Annotations),
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(set, v1alpha1.SchemeGroupVersion.WithKind("MyResourceSet")),
+			},
+		},
+		Spec: *set.Spec.Template.Spec.DeepCopy(),
+	}
+}
+
+// merge returns a copy of current with the entries of desired added, or current if both are empty.
+func merge(current map[string]string, desired map[string]string) map[string]string {
+	if len(current) == 0 && len(desired) == 0 {
+		return current
+	}
+
+	merged := make(map[string]string, len(current)+len(desired))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range desired {
+		merged[key] = value
+	}
+	return merged
+}
+
+// namespaceStatus reports the readiness of myresource, which failed to be reconciled with err.
+func namespaceStatus(namespace string, myresource *v1alpha1.MyResource, err error) v1alpha1.NamespaceStatus {
+	status := v1alpha1.NamespaceStatus{Namespace: namespace}
+	switch {
+	case err != nil:
+		status.Message = err.Error()
+	case myresource.Status.LastError != "":
+		status.Message = myresource.Status.LastError
+	case meta.IsStatusConditionTrue(myresource.Status.Conditions, v1alpha1.ConditionNameConflict):
+		status.Message = meta.FindStatusCondition(myresource.Status.Conditions, v1alpha1.ConditionNameConflict).Message
+	case meta.IsStatusConditionTrue(myresource.Status.Conditions, v1alpha1.ConditionQuotaExceeded):
+		status.Message = meta.FindStatusCondition(myresource.Status.Conditions, v1alpha1.ConditionQuotaExceeded).Message
+	case meta.IsStatusConditionTrue(myresource.Status.Conditions, v1alpha1.ConditionSuspended):
+		status.Message = "reconciliation is suspended"
+	case myresource.Status.Rollout == nil || myresource.Status.Rollout.Step != v1alpha1.RolloutStepStable:
+		status.Message = "rollout is in progress"
+	default:
+		status.Ready = true
+	}
+	return status
+}
+
+func aggregateStatus(status *v1alpha1.MyResourceSetStatus, set *v1alpha1.MyResourceSet, statuses []v1alpha1.NamespaceStatus) {
+	ready := int32(0)
+	for _, namespaceStatus := range statuses {
+		if namespaceStatus.Ready {
+			ready++
+		}
+	}
+
+	status.ObservedGeneration = set.Generation
+	status.Namespaces = int32(len(statuses))
+	status.ReadyNamespaces = ready
+	status.NamespaceStatuses = statuses
+	if ready == status.Namespaces {
+		setReady(status, set, metav1.ConditionTrue, "AllReady", fmt.Sprintf("the MyResources in all %d namespaces are ready", ready))
+		return
+	}
+	setReady(status, set, metav1.ConditionFalse, "NotAllReady",
+		fmt.Sprintf("the MyResources in %d of %d namespaces are ready", ready, status.Namespaces))
+}
+
+func setReady(status *v1alpha1.MyResourceSetStatus, set *v1alpha1.MyResourceSet, conditionStatus metav1.ConditionStatus, reason string, message string) {
+	meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+		Type:               v1alpha1.ConditionReady,
+		Status:             conditionStatus,
+		ObservedGeneration: set.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
@@ -0,0 +1,193 @@
+package myresourceset
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func newNamespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func newSet(message string) *v1alpha1.MyResourceSet {
+	return &v1alpha1.MyResourceSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "echo", UID: "set-uid"},
+		Spec: v1alpha1.MyResourceSetSpec{
+			NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"echo": "true"}},
+			Template: v1alpha1.MyResourceTemplate{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"team": "echo"}},
+				Spec:       v1alpha1.MyResourceSpec{Message: message},
+			},
+		},
+	}
+}
+
+func newTestCluster(t *testing.T, objs ...client.Object) *kittesting.Cluster {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	cluster, err := kittesting.NewCluster(scheme, objs...)
+	if err != nil {
+		t.Fatalf("failed to create cluster: %v", err)
+	}
+	return cluster
+}
+
+func reconcileSet(t *testing.T, c client.Client) *v1alpha1.MyResourceSet {
+	t.Helper()
+	controller, err := CreateController(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = controller.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: "echo"}})
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	set := &v1alpha1.MyResourceSet{}
+	err = c.Get(context.Background(), types.NamespacedName{Name: "echo"}, set)
+	if err != nil {
+		t.Fatalf("failed to get MyResourceSet: %v", err)
+	}
+	return set
+}
+
+func getMyResource(c client.Client, namespace string) (*v1alpha1.MyResource, error) {
+	myresource := &v1alpha1.MyResource{}
+	err := c.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: "echo"}, myresource)
+	return myresource, err
+}
+
+func TestFanOut(t *testing.T) {
+	cluster := newTestCluster(t, newSet("hello"),
+		newNamespace("selected-a", map[string]string{"echo": "true"}),
+		newNamespace("selected-b", map[string]string{"echo": "true"}),
+		newNamespace("other", nil))
+
+	set := reconcileSet(t, cluster)
+
+	for _, namespace := range []string{"selected-a", "selected-b"} {
+		myresource, err := getMyResource(cluster, namespace)
+		if err != nil {
+			t.Fatalf("no MyResource in namespace %q: %v", namespace, err)
+		}
+		if myresource.Spec.Message != "hello" || myresource.Labels["team"] != "echo" || !metav1.IsControlledBy(myresource, set) {
+			t.Errorf("MyResource in namespace %q doesn't follow the template: %+v", namespace, myresource)
+		}
+	}
+	if _, err := getMyResource(cluster, "other"); !apierrors.IsNotFound(err) {
+		t.Errorf("MyResource was created in a namespace that isn't selected: %v", err)
+	}
+	if set.Status.Namespaces != 2 || set.Status.ReadyNamespaces != 0 {
+		t.Errorf("status counts %d namespaces with %d ready, expected 2 with none ready",
+			set.Status.Namespaces, set.Status.ReadyNamespaces)
+	}
+	if meta.IsStatusConditionTrue(set.Status.Conditions, v1alpha1.ConditionReady) {
+		t.Error("MyResourceSet is ready before its MyResources are")
+	}
+}
+
+func TestFanOutFollowsNamespaces(t *testing.T) {
+	selected := newNamespace("selected", map[string]string{"echo": "true"})
+	cluster := newTestCluster(t, newSet("hello"), selected, newNamespace("later", nil))
+	reconcileSet(t, cluster)
+
+	selected.Labels = nil
+	if err := cluster.Update(context.Background(), selected); err != nil {
+		t.Fatal(err)
+	}
+	later := &corev1.Namespace{}
+	if err := cluster.Get(context.Background(), types.NamespacedName{Name: "later"}, later); err != nil {
+		t.Fatal(err)
+	}
+	later.Labels = map[string]string{"echo": "true"}
+	if err := cluster.Update(context.Background(), later); err != nil {
+		t.Fatal(err)
+	}
+	reconcileSet(t, cluster)
+
+	if _, err := getMyResource(cluster, "selected"); !apierrors.IsNotFound(err) {
+		t.Errorf("MyResource of a namespace that is no longer selected was kept: %v", err)
+	}
+	if _, err := getMyResource(cluster, "later"); err != nil {
+		t.Errorf("no MyResource in the newly selected namespace: %v", err)
+	}
+}
+
+func TestFanOutAppliesTemplateAndKeepsAnnotations(t *testing.T) {
+	cluster := newTestCluster(t, newSet("hello"), newNamespace("selected", map[string]string{"echo": "true"}))
+	reconcileSet(t, cluster)
+
+	myresource, err := getMyResource(cluster, "selected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	myresource.Annotations = map[string]string{v1alpha1.SuspendAnnotation: "true"}
+	myresource.Spec.Message = "changed"
+	if err := cluster.Update(context.Background(), myresource); err != nil {
+		t.Fatal(err)
+	}
+	reconcileSet(t, cluster)
+
+	myresource, err = getMyResource(cluster, "selected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if myresource.Spec.Message != "hello" {
+		t.Errorf("spec.message is %q, expected the template to be applied again", myresource.Spec.Message)
+	}
+	if myresource.Annotations[v1alpha1.SuspendAnnotation] != "true" {
+		t.Errorf("annotations %v lost the suspend annotation", myresource.Annotations)
+	}
+}
+
+func TestFanOutLeavesForeignMyResource(t *testing.T) {
+	foreign := kittesting.NewMyResource("selected", "echo").WithMessage("foreign").Build()
+	cluster := newTestCluster(t, newSet("hello"), newNamespace("selected", map[string]string{"echo": "true"}), foreign)
+
+	set := reconcileSet(t, cluster)
+
+	myresource, err := getMyResource(cluster, "selected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if myresource.Spec.Message != "foreign" {
+		t.Errorf("MyResource not controlled by the MyResourceSet was changed to message %q", myresource.Spec.Message)
+	}
+	if len(set.Status.NamespaceStatuses) != 1 || set.Status.NamespaceStatuses[0].Message == "" {
+		t.Errorf("namespace statuses %+v don't report the foreign MyResource", set.Status.NamespaceStatuses)
+	}
+}
+
+func TestFanOutReportsReadyMyResources(t *testing.T) {
+	cluster := newTestCluster(t, newSet("hello"), newNamespace("selected", map[string]string{"echo": "true"}))
+	reconcileSet(t, cluster)
+
+	myresource, err := getMyResource(cluster, "selected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	myresource.Status.Rollout = &v1alpha1.RolloutStatus{Step: v1alpha1.RolloutStepStable}
+	if err := cluster.Status().Update(context.Background(), myresource); err != nil {
+		t.Fatal(err)
+	}
+	set := reconcileSet(t, cluster)
+
+	if set.Status.ReadyNamespaces != 1 || !meta.IsStatusConditionTrue(set.Status.Conditions, v1alpha1.ConditionReady) {
+		t.Errorf("status %+v doesn't report the ready MyResource", set.Status)
+	}
+}
@@ -2,15 +2,41 @@ package pod
 
 import (
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/logging"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
 )
 
-func AddControllerToManager(mgr manager.Manager) error {
-	controller, err := CreateController(mgr.GetClient())
+func AddControllerToManager(mgr manager.Manager, options reconcileutil.Options) error {
+	controller, err := CreateController(tracing.WrapClient(mgr.GetClient()), nil)
 	if err != nil {
 		return err
 	}
 
-	return builder.ControllerManagedBy(mgr).For(&v1.Pod{}).Complete(controller)
+	return builder.ControllerManagedBy(mgr).
+		For(&v1.Pod{}).
+		Watches(&source.Kind{Type: &v1alpha1.MyResource{}}, handler.EnqueueRequestsFromMapFunc(podOfMyResource)).
+		WithOptions(options.ControllerOptions()).
+		Complete(tracing.WrapReconciler("Pod", logging.WrapReconciler(logging.KeyPod, controller)))
+}
+
+// podOfMyResource maps a MyResource to its current pod, so that message changes are observed.
+func podOfMyResource(obj client.Object) []reconcile.Request {
+	myresource, ok := obj.(*v1alpha1.MyResource)
+	if !ok || myresource.Status.PodName == "" {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: myresource.Status.PodName, Namespace: myresource.Namespace}},
+	}
 }
@@ -3,19 +3,40 @@ package pod
 import (
 	"context"
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/klog/v2"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"time"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/echoserver"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/logging"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+)
+
+const (
+	// probeRetryInterval is how often a hot reloading pod is asked again whether it serves the current message.
+	probeRetryInterval = 5 * time.Second
 )
 
 type Controller struct {
 	client client.Client
+	prober MessageProber
 }
 
-func CreateController(client client.Client) (reconcile.Reconciler, error) {
+// CreateController returns the Pod controller. If prober is nil, pods are probed over HTTP.
+func CreateController(client client.Client, prober MessageProber) (reconcile.Reconciler, error) {
+	if prober == nil {
+		prober = NewHTTPMessageProber()
+	}
+
 	controller := Controller{
 		client: client,
+		prober: prober,
 	}
 	return &controller, nil
 }
@@ -24,25 +45,145 @@ func (c *Controller) Reconcile(ctx context.Context, req reconcile.Request) (reco
 	pod := &v1.Pod{}
 	err := c.client.Get(ctx, req.NamespacedName, pod)
 	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
 		return reconcile.Result{}, err
 	}
+	tracing.RecordObject(ctx, pod)
 
 	ownerRef := metav1.GetControllerOf(pod)
-	if ownerRef != nil {
-		if ownerRef.Kind != "MyResource" {
+	if ownerRef == nil || ownerRef.Kind != "MyResource" {
+		return reconcile.Result{}, nil
+	}
+
+	logger := log.FromContext(ctx).WithValues(logging.KeyMyResource, logging.Ref(req.Namespace, ownerRef.Name))
+	ctx = log.IntoContext(ctx, logger)
+	logger.V(1).Info("reconciling Pod", "phase", pod.Status.Phase)
+
+	if pod.DeletionTimestamp != nil || !reconcileutil.IsPodReady(pod) {
+		return reconcile.Result{}, nil
+	}
+
+	myresource := &v1alpha1.MyResource{}
+	err = c.client.Get(ctx, types.NamespacedName{Name: ownerRef.Name, Namespace: req.Namespace}, myresource)
+	if err != nil {
+		if errors.IsNotFound(err) {
 			return reconcile.Result{}, nil
 		}
+		return reconcile.Result{}, err
+	}
+
+	if myresource.UID != ownerRef.UID || myresource.Status.PodName == "" ||
+		myresource.Status.ObservedMessage == desiredMessage(myresource) {
+		return reconcile.Result{}, nil
+	}
+
+	pods, err := c.currentPods(ctx, myresource)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !containsPod(pods, pod) {
+		return reconcile.Result{}, nil
+	}
+
+	return c.observeMessage(ctx, myresource, pods)
+}
+
+// currentPods returns the pods of myresource with the revision of status.podName, the pods that serve the
+// message of myresource. It returns nil if not all replicas of that revision are ready, they are checked again
+// when the missing ones become ready.
+func (c *Controller) currentPods(ctx context.Context, myresource *v1alpha1.MyResource) ([]*v1.Pod, error) {
+	current := &v1.Pod{}
+	err := c.client.Get(ctx, types.NamespacedName{Name: myresource.Status.PodName, Namespace: myresource.Namespace}, current)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	revision, ok := current.Labels[v1alpha1.RevisionLabel]
+	if !ok {
+		return nil, nil
+	}
 
-		klog.V(4).Infof("reconciling Pod %q, phase %q", req.NamespacedName, pod.Status.Phase)
+	podList := &v1.PodList{}
+	err = c.client.List(ctx, podList, client.InNamespace(myresource.Namespace),
+		client.MatchingLabels{v1alpha1.RevisionLabel: revision})
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []*v1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if !metav1.IsControlledBy(pod, myresource) || pod.DeletionTimestamp != nil {
+			continue
+		}
+		if !reconcileutil.IsPodReady(pod) {
+			return nil, nil
+		}
+		pods = append(pods, pod)
+	}
+	if len(pods) < replicas(myresource) {
+		return nil, nil
+	}
+	return pods, nil
+}
 
-		/*
-			myresource := &v1alpha1.MyResource{}
-			err := c.client.Get(ctx, types.NamespacedName{Name: ownerRef.Name, Namespace: req.Namespace}, myresource)
-			if err == nil {
+func containsPod(pods []*v1.Pod, pod *v1.Pod) bool {
+	for _, p := range pods {
+		if p.UID == pod.UID {
+			return true
+		}
+	}
+	return false
+}
 
-			}
-		*/
+// observeMessage records the message of myresource as observed once all pods confirm to serve it.
+func (c *Controller) observeMessage(ctx context.Context, myresource *v1alpha1.MyResource, pods []*v1.Pod) (reconcile.Result, error) {
+	retry := reconcile.Result{}
+	if myresource.Spec.ReloadStrategy == v1alpha1.ReloadStrategyHotReload {
+		retry.RequeueAfter = probeRetryInterval
 	}
 
+	message := desiredMessage(myresource)
+	for _, pod := range pods {
+		hash, err := c.prober.MessageHash(ctx, pod)
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("failed to probe message of pod", "probedPod", logging.RefOf(pod), "error", err.Error())
+			return retry, nil
+		}
+		if hash != echoserver.MessageHash(message) {
+			log.FromContext(ctx).V(1).Info("pod doesn't serve the current message of MyResource yet", "probedPod", logging.RefOf(pod))
+			return retry, nil
+		}
+	}
+
+	myresource.Status.ObservedMessage = message
+	err := c.client.Status().Update(ctx, myresource)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	log.FromContext(ctx).Info("pods serve the current message of MyResource", "pods", len(pods))
 	return reconcile.Result{}, nil
 }
+
+// replicas returns the number of pods serving myresource.
+func replicas(myresource *v1alpha1.MyResource) int {
+	if myresource.Spec.Replicas == nil {
+		return 1
+	}
+	return int(*myresource.Spec.Replicas)
+}
+
+// desiredMessage returns the message the pods of myresource are supposed to serve,
+// which is the active scheduled message if the schedule has one.
+func desiredMessage(myresource *v1alpha1.MyResource) string {
+	schedule := myresource.Status.Schedule
+	if schedule == nil || schedule.ActiveEntry == nil || int(*schedule.ActiveEntry) >= len(myresource.Spec.Schedule) {
+		return myresource.Spec.Message
+	}
+	return myresource.Spec.Schedule[*schedule.ActiveEntry].Message
+}
@@ -0,0 +1,136 @@
+package pod
+
+import (
+	"context"
+	"errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/echoserver"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+const testNamespace = "default"
+
+// fakeProber reports the messages of the pods by name, pods without a message can't be probed.
+type fakeProber map[string]string
+
+func (p fakeProber) MessageHash(_ context.Context, pod *corev1.Pod) (string, error) {
+	message, ok := p[pod.Name]
+	if !ok {
+		return "", errors.New("connection refused")
+	}
+	return echoserver.MessageHash(message), nil
+}
+
+// newTestPod returns a ready pod of myresource with the given revision.
+func newTestPod(myresource *v1alpha1.MyResource, name string, revision string) *kittesting.PodBuilder {
+	return kittesting.NewPod(testNamespace, name).OwnedBy(myresource).WithLabel(v1alpha1.RevisionLabel, revision).Ready()
+}
+
+func reconcilePod(t *testing.T, objs []client.Object, prober fakeProber, name string) *v1alpha1.MyResource {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	cluster, err := kittesting.NewCluster(scheme, objs...)
+	if err != nil {
+		t.Fatalf("failed to create cluster: %v", err)
+	}
+	controller, err := CreateController(cluster, prober)
+	if err != nil {
+		t.Fatalf("failed to create controller: %v", err)
+	}
+
+	_, err = controller.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: testNamespace, Name: name}})
+	if err != nil {
+		t.Fatalf("reconcile of pod %q failed: %v", name, err)
+	}
+
+	myresource := &v1alpha1.MyResource{}
+	err = cluster.Get(context.Background(), types.NamespacedName{Namespace: testNamespace, Name: "echo"}, myresource)
+	if err != nil {
+		t.Fatalf("failed to get MyResource: %v", err)
+	}
+	return myresource
+}
+
+func TestObservedMessageIsAggregatedOverCurrentPods(t *testing.T) {
+	myresource := kittesting.NewMyResource(testNamespace, "echo").WithMessage("hello").WithReplicas(3).
+		WithStatus(func(status *v1alpha1.MyResourceStatus) {
+			status.PodName = "echo-pod"
+		}).Build()
+	newPods := func(third *kittesting.PodBuilder) []client.Object {
+		return []client.Object{
+			myresource,
+			newTestPod(myresource, "echo-pod", "a").Build(),
+			newTestPod(myresource, "echo-pod-1", "a").Build(),
+			third.Build(),
+			// pods of other revisions, e.g. the preview of a blue/green rollout, don't serve the message yet
+			newTestPod(myresource, "echo-preview", "b").Build(),
+		}
+	}
+	servingAll := fakeProber{"echo-pod": "hello", "echo-pod-1": "hello", "echo-pod-2": "hello"}
+
+	tests := []struct {
+		name     string
+		objs     []client.Object
+		prober   fakeProber
+		pod      string
+		observed string
+	}{
+		{
+			name:     "all pods serve the message",
+			objs:     newPods(newTestPod(myresource, "echo-pod-2", "a")),
+			prober:   servingAll,
+			pod:      "echo-pod-1",
+			observed: "hello",
+		},
+		{
+			name:   "a pod serves an old message",
+			objs:   newPods(newTestPod(myresource, "echo-pod-2", "a")),
+			prober: fakeProber{"echo-pod": "hello", "echo-pod-1": "hello", "echo-pod-2": "bye"},
+			pod:    "echo-pod",
+		},
+		{
+			name:   "a pod can't be probed",
+			objs:   newPods(newTestPod(myresource, "echo-pod-2", "a")),
+			prober: fakeProber{"echo-pod": "hello", "echo-pod-1": "hello"},
+			pod:    "echo-pod",
+		},
+		{
+			name:   "a pod isn't ready",
+			objs:   newPods(newTestPod(myresource, "echo-pod-2", "a").NotReady()),
+			prober: servingAll,
+			pod:    "echo-pod",
+		},
+		{
+			name:   "a replica is missing",
+			objs:   newPods(newTestPod(myresource, "echo-pod-2", "a").Terminating()),
+			prober: servingAll,
+			pod:    "echo-pod",
+		},
+		{
+			name:   "pods of another revision don't report the message",
+			objs:   newPods(newTestPod(myresource, "echo-pod-2", "a")),
+			prober: fakeProber{"echo-preview": "hello"},
+			pod:    "echo-preview",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			myresource := reconcilePod(t, test.objs, test.prober, test.pod)
+			if myresource.Status.ObservedMessage != test.observed {
+				t.Errorf("observed message is %q, expected %q", myresource.Status.ObservedMessage, test.observed)
+			}
+		})
+	}
+}
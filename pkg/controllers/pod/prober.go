@@ -0,0 +1,76 @@
+package pod
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"k8s.io/api/core/v1"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/echoserver"
+)
+
+const (
+	defaultEchoServerPort = 8080
+	probeTimeout          = 2 * time.Second
+)
+
+// MessageProber asks an echo pod which message it currently serves.
+type MessageProber interface {
+	// MessageHash returns the echoserver.MessageHash of the message served by pod.
+	MessageHash(ctx context.Context, pod *v1.Pod) (string, error)
+}
+
+type httpMessageProber struct {
+	client *http.Client
+}
+
+// NewHTTPMessageProber returns a MessageProber that queries the status endpoint of the echo server.
+func NewHTTPMessageProber() MessageProber {
+	return &httpMessageProber{
+		client: &http.Client{Timeout: probeTimeout},
+	}
+}
+
+func (p *httpMessageProber) MessageHash(ctx context.Context, pod *v1.Pod) (string, error) {
+	if pod.Status.PodIP == "" {
+		return "", fmt.Errorf("pod has no IP")
+	}
+
+	url := fmt.Sprintf("http://%s%s", net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(echoServerPort(pod))), echoserver.StatusPath)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	status := echoserver.Status{}
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode status from %s: %w", url, err)
+	}
+	return status.MessageHash, nil
+}
+
+func echoServerPort(pod *v1.Pod) int {
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == "http" {
+				return int(port.ContainerPort)
+			}
+		}
+	}
+	return defaultEchoServerPort
+}
@@ -0,0 +1,75 @@
+package reconcileutil
+
+import (
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// TerminalError marks an error that will not go away by retrying the reconcile.
+type TerminalError struct {
+	err error
+}
+
+func (e *TerminalError) Error() string {
+	return e.err.Error()
+}
+
+func (e *TerminalError) Unwrap() error {
+	return e.err
+}
+
+// TransientError marks an error that is expected to resolve itself, the reconcile is retried with backoff.
+type TransientError struct {
+	err error
+}
+
+func (e *TransientError) Error() string {
+	return e.err.Error()
+}
+
+func (e *TransientError) Unwrap() error {
+	return e.err
+}
+
+// Terminal wraps err as a TerminalError. It returns nil if err is nil.
+func Terminal(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TerminalError{err: err}
+}
+
+// Transient wraps err as a TransientError. It returns nil if err is nil.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &TransientError{err: err}
+}
+
+// IsTerminal reports whether any error in the chain of err is a TerminalError.
+func IsTerminal(err error) bool {
+	var terminalErr *TerminalError
+	return errors.As(err, &terminalErr)
+}
+
+// IsTransient reports whether err should be retried. Every error which is not terminal is transient.
+func IsTransient(err error) bool {
+	return err != nil && !IsTerminal(err)
+}
+
+// ClassifyAPIError wraps an error returned by the API server as terminal if the request
+// was rejected for reasons that a retry of the same request can't fix, and as transient otherwise.
+func ClassifyAPIError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch {
+	case apierrors.IsInvalid(err), apierrors.IsBadRequest(err), apierrors.IsMethodNotSupported(err),
+		apierrors.IsRequestEntityTooLargeError(err):
+		return Terminal(err)
+	}
+	return Transient(err)
+}
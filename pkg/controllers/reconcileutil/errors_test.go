@@ -0,0 +1,104 @@
+package reconcileutil
+
+import (
+	"errors"
+	"fmt"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"testing"
+)
+
+func TestClassifyAPIError(t *testing.T) {
+	pods := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name     string
+		err      error
+		terminal bool
+	}{
+		{
+			name: "conflict",
+			err:  apierrors.NewConflict(pods, "echo", errors.New("the object has been modified")),
+		},
+		{
+			name: "not found",
+			err:  apierrors.NewNotFound(pods, "echo"),
+		},
+		{
+			name: "forbidden",
+			err:  apierrors.NewForbidden(pods, "echo", errors.New("exceeded quota")),
+		},
+		{
+			name: "too many requests",
+			err:  apierrors.NewTooManyRequests("slow down", 1),
+		},
+		{
+			name: "error of the connection",
+			err:  errors.New("connection refused"),
+		},
+		{
+			name: "invalid",
+			err: apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "echo", field.ErrorList{
+				field.Required(field.NewPath("spec", "containers"), ""),
+			}),
+			terminal: true,
+		},
+		{
+			name:     "bad request",
+			err:      apierrors.NewBadRequest("malformed"),
+			terminal: true,
+		},
+		{
+			name:     "method not supported",
+			err:      apierrors.NewMethodNotSupported(pods, "patch"),
+			terminal: true,
+		},
+		{
+			name:     "request entity too large",
+			err:      apierrors.NewRequestEntityTooLargeError("too large"),
+			terminal: true,
+		},
+		{
+			name:     "wrapped invalid",
+			err:      fmt.Errorf("failed to create pod: %w", apierrors.NewInvalid(schema.GroupKind{Kind: "Pod"}, "echo", nil)),
+			terminal: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			classified := ClassifyAPIError(test.err)
+			if IsTerminal(classified) != test.terminal {
+				t.Errorf("IsTerminal is %t, expected %t", IsTerminal(classified), test.terminal)
+			}
+			if IsTransient(classified) == test.terminal {
+				t.Errorf("IsTransient is %t, expected %t", IsTransient(classified), !test.terminal)
+			}
+			if !errors.Is(classified, test.err) {
+				t.Errorf("classified error %v doesn't wrap %v", classified, test.err)
+			}
+			if classified.Error() != test.err.Error() {
+				t.Errorf("classified error reads %q, expected %q", classified.Error(), test.err.Error())
+			}
+		})
+	}
+}
+
+func TestClassifyAPIErrorOfNil(t *testing.T) {
+	if err := ClassifyAPIError(nil); err != nil {
+		t.Errorf("nil was classified as %v", err)
+	}
+	if IsTransient(nil) || IsTerminal(nil) {
+		t.Errorf("nil is classified as an error")
+	}
+}
+
+func TestTerminalErrorStaysTerminalWhenWrapped(t *testing.T) {
+	err := fmt.Errorf("reconcile failed: %w", Terminal(errors.New("invalid spec")))
+	if !IsTerminal(err) || IsTransient(err) {
+		t.Errorf("wrapped terminal error isn't terminal")
+	}
+	if IsTerminal(Transient(errors.New("timeout"))) {
+		t.Errorf("transient error is terminal")
+	}
+}
@@ -0,0 +1,66 @@
+package reconcileutil
+
+import (
+	"flag"
+	"fmt"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"time"
+)
+
+const (
+	defaultMaxConcurrentReconciles = 1
+	defaultBaseDelay               = 5 * time.Millisecond
+	defaultMaxDelay                = 5 * time.Minute
+)
+
+// Options holds the concurrency and backoff settings of a controller.
+type Options struct {
+	// MaxConcurrentReconciles is the number of reconciles that may run in parallel.
+	MaxConcurrentReconciles int
+	// BaseDelay is the delay before the first retry of a failed reconcile, it doubles with every failure.
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries of a failed reconcile.
+	MaxDelay time.Duration
+}
+
+// DefaultOptions returns the Options used when no flags are given.
+func DefaultOptions() Options {
+	return Options{
+		MaxConcurrentReconciles: defaultMaxConcurrentReconciles,
+		BaseDelay:               defaultBaseDelay,
+		MaxDelay:                defaultMaxDelay,
+	}
+}
+
+// AddFlags registers flags for the options of the controller called name on flagSet.
+func (o *Options) AddFlags(flagSet *flag.FlagSet, name string) {
+	flagSet.IntVar(&o.MaxConcurrentReconciles, fmt.Sprintf("%s-max-concurrent-reconciles", name), o.MaxConcurrentReconciles,
+		fmt.Sprintf("maximum number of concurrent reconciles of the %s controller", name))
+	flagSet.DurationVar(&o.BaseDelay, fmt.Sprintf("%s-retry-base-delay", name), o.BaseDelay,
+		fmt.Sprintf("initial delay before a failed reconcile of the %s controller is retried", name))
+	flagSet.DurationVar(&o.MaxDelay, fmt.Sprintf("%s-retry-max-delay", name), o.MaxDelay,
+		fmt.Sprintf("maximum delay before a failed reconcile of the %s controller is retried", name))
+}
+
+// ControllerOptions converts o into the options of a controller-runtime controller.
+func (o Options) ControllerOptions() controller.Options {
+	defaults := DefaultOptions()
+	if o.MaxConcurrentReconciles <= 0 {
+		o.MaxConcurrentReconciles = defaults.MaxConcurrentReconciles
+	}
+	if o.BaseDelay <= 0 {
+		o.BaseDelay = defaults.BaseDelay
+	}
+	if o.MaxDelay <= 0 {
+		o.MaxDelay = defaults.MaxDelay
+	}
+	if o.MaxDelay < o.BaseDelay {
+		o.MaxDelay = o.BaseDelay
+	}
+
+	return controller.Options{
+		MaxConcurrentReconciles: o.MaxConcurrentReconciles,
+		RateLimiter:             workqueue.NewItemExponentialFailureRateLimiter(o.BaseDelay, o.MaxDelay),
+	}
+}
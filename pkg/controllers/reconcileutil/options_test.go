@@ -0,0 +1,86 @@
+package reconcileutil
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestControllerOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       Options
+		maxConcurrent int
+		firstDelay    time.Duration
+		maxDelay      time.Duration
+	}{
+		{
+			name:          "defaults",
+			options:       DefaultOptions(),
+			maxConcurrent: defaultMaxConcurrentReconciles,
+			firstDelay:    defaultBaseDelay,
+			maxDelay:      defaultMaxDelay,
+		},
+		{
+			name:          "unset options fall back to the defaults",
+			options:       Options{},
+			maxConcurrent: defaultMaxConcurrentReconciles,
+			firstDelay:    defaultBaseDelay,
+			maxDelay:      defaultMaxDelay,
+		},
+		{
+			name:          "negative options fall back to the defaults",
+			options:       Options{MaxConcurrentReconciles: -1, BaseDelay: -time.Second, MaxDelay: -time.Minute},
+			maxConcurrent: defaultMaxConcurrentReconciles,
+			firstDelay:    defaultBaseDelay,
+			maxDelay:      defaultMaxDelay,
+		},
+		{
+			name:          "maximum delay is at least the base delay",
+			options:       Options{MaxConcurrentReconciles: 2, BaseDelay: time.Second, MaxDelay: time.Millisecond},
+			maxConcurrent: 2,
+			firstDelay:    time.Second,
+			maxDelay:      time.Second,
+		},
+		{
+			name:          "options are kept",
+			options:       Options{MaxConcurrentReconciles: 4, BaseDelay: 10 * time.Millisecond, MaxDelay: time.Second},
+			maxConcurrent: 4,
+			firstDelay:    10 * time.Millisecond,
+			maxDelay:      time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := test.options.ControllerOptions()
+			if options.MaxConcurrentReconciles != test.maxConcurrent {
+				t.Errorf("MaxConcurrentReconciles is %d, expected %d", options.MaxConcurrentReconciles, test.maxConcurrent)
+			}
+			if delay := options.RateLimiter.When("item"); delay != test.firstDelay {
+				t.Errorf("first retry after %s, expected %s", delay, test.firstDelay)
+			}
+			for i := 0; i < 64; i++ {
+				options.RateLimiter.When("item")
+			}
+			if delay := options.RateLimiter.When("item"); delay != test.maxDelay {
+				t.Errorf("retries are delayed by up to %s, expected %s", delay, test.maxDelay)
+			}
+		})
+	}
+}
+
+func TestOptionsAddFlags(t *testing.T) {
+	options := DefaultOptions()
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	options.AddFlags(flagSet, "myresource")
+
+	err := flagSet.Parse([]string{"--myresource-max-concurrent-reconciles=3", "--myresource-retry-max-delay=1m"})
+	if err != nil {
+		t.Fatalf("failed to parse flags: %v", err)
+	}
+	expected := Options{MaxConcurrentReconciles: 3, BaseDelay: defaultBaseDelay, MaxDelay: time.Minute}
+	if options != expected {
+		t.Errorf("got options %+v, expected %+v", options, expected)
+	}
+}
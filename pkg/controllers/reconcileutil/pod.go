@@ -0,0 +1,15 @@
+package reconcileutil
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// IsPodReady reports whether the Ready condition of pod is true.
+func IsPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package reconcileutil
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeRequeue = "requeue"
+	OutcomeError   = "error"
+)
+
+// Outcome summarizes what a reconcile returned as OutcomeSuccess, OutcomeRequeue or OutcomeError.
+func Outcome(result reconcile.Result, err error) string {
+	switch {
+	case err != nil:
+		return OutcomeError
+	case result.Requeue || result.RequeueAfter > 0:
+		return OutcomeRequeue
+	default:
+		return OutcomeSuccess
+	}
+}
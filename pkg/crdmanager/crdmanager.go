@@ -5,68 +5,127 @@ import (
 	"context"
 	"embed"
 	"fmt"
+	"io/fs"
 	v1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/util/yaml"
-	"k8s.io/klog/v2"
+	"k8s.io/client-go/rest"
 	"path"
+	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"strings"
 	"time"
 
 	apiextinstall "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/install"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
 )
 
 const (
 	embedFSCrdRootDir = "crdresources"
+
+	defaultTimeout      = 30 * time.Second
+	defaultPollInterval = 1 * time.Second
 )
 
 //go:embed crdresources/*.yaml
 var importedCrdFS embed.FS
 
+// Options configures how a CRDManager registers its CRDs.
+type Options struct {
+	// Timeout bounds how long EnsureCRDs waits for the CRDs to become established.
+	// Defaults to 30 seconds.
+	Timeout time.Duration
+	// FieldManager is recorded as the field owner of created and patched CRDs.
+	FieldManager string
+	// Labels are added to every CRD before it is registered.
+	Labels map[string]string
+	// DryRun only validates the CRDs with a server-side dry-run. EnsureCRDs then waits for the CRDs to be
+	// registered by someone else, e.g. the controller that applies the changes planned in dry-run mode.
+	DryRun bool
+}
+
+// CRDManager registers the CRD manifests found in a directory of an fs.FS.
 type CRDManager struct {
-	client       client.Client
-	crdRawDataFS *embed.FS
+	client  client.Client
+	crdFS   fs.FS
+	rootDir string
+	options Options
+}
+
+// CreateCrdManager returns a CRDManager for the CRDs embedded in this binary.
+func CreateCrdManager(mgr manager.Manager, options Options) (*CRDManager, error) {
+	return New(mgr.GetConfig(), importedCrdFS, embedFSCrdRootDir, options)
 }
 
-func CreateCrdManager(mgr manager.Manager) (*CRDManager, error) {
+// New returns a CRDManager that registers the CRDs in rootDir of crdFS using the given rest config.
+func New(config *rest.Config, crdFS fs.FS, rootDir string, options Options) (*CRDManager, error) {
 	apiExtensionScheme := runtime.NewScheme()
 	apiextinstall.Install(apiExtensionScheme)
-	kubeClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: apiExtensionScheme})
+	kubeClient, err := client.New(config, client.Options{Scheme: apiExtensionScheme})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create client for registering CRDs: %w", err)
 	}
 
-	_, err = importedCrdFS.ReadDir(embedFSCrdRootDir)
+	return NewForClient(kubeClient, crdFS, rootDir, options)
+}
+
+// NewForClient returns a CRDManager that registers the CRDs in rootDir of crdFS using kubeClient.
+// The scheme of kubeClient must know the apiextensions.k8s.io/v1 types.
+func NewForClient(kubeClient client.Client, crdFS fs.FS, rootDir string, options Options) (*CRDManager, error) {
+	_, err := fs.ReadDir(crdFS, rootDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read embedded CRDs: %w", err)
+		return nil, fmt.Errorf("failed to read CRD directory %q: %w", rootDir, err)
+	}
+
+	if options.Timeout <= 0 {
+		options.Timeout = defaultTimeout
 	}
 
 	return &CRDManager{
-		client:       kubeClient,
-		crdRawDataFS: &importedCrdFS,
+		client:  tracing.WrapClient(kubeClient),
+		crdFS:   crdFS,
+		rootDir: rootDir,
+		options: options,
 	}, nil
 }
 
-func (m *CRDManager) EnsureCRDs() error {
+// Runnable returns a manager.Runnable that ensures the CRDs when the manager is started and then calls setup.
+// The manager starts its runnables concurrently, so controllers watching the custom resources have to be
+// added by setup to be sure that the CRDs are established. A started manager starts them right away.
+// setup may be nil.
+func (m *CRDManager) Runnable(setup func() error) manager.Runnable {
+	return &runnable{crdManager: m, setup: setup}
+}
+
+// EnsureCRDs creates or updates the CRDs and waits until all of them are established. In dry-run mode the
+// CRDs are left alone, it only waits until they exist and are established.
+func (m *CRDManager) EnsureCRDs(ctx context.Context) error {
+	ctx, span := tracing.Tracer().Start(ctx, "EnsureCRDs")
+	return tracing.End(span, m.ensureCRDs(ctx))
+}
+
+func (m *CRDManager) ensureCRDs(ctx context.Context) error {
 	crdList, err := m.crdsFromDir()
 	if err != nil {
 		return err
 	}
 
-	klog.Info("registering CRDs")
+	logger := log.FromContext(ctx)
+	logger.Info("registering CRDs", "dryRun", m.options.DryRun)
 	for _, crd := range crdList {
 		existingCrd := &v1.CustomResourceDefinition{}
-		err := m.client.Get(context.TODO(), client.ObjectKey{Name: crd.Name}, existingCrd)
+		err := m.client.Get(ctx, client.ObjectKey{Name: crd.Name}, existingCrd)
 		if err != nil {
 			if apierrors.IsNotFound(err) {
-				err = m.client.Create(context.TODO(), &crd)
+				err = m.client.Create(ctx, &crd, m.createOptions()...)
 				if err != nil {
 					return err
 				}
-				klog.Infof("registered new CRD: %q", crd.Name)
+				logger.Info("registered new CRD", "crd", crd.Name, "dryRun", m.options.DryRun)
 				continue
 			}
 			return err
@@ -74,14 +133,17 @@ func (m *CRDManager) EnsureCRDs() error {
 
 		crd.ResourceVersion = existingCrd.ResourceVersion
 		crd.UID = existingCrd.UID
-		err = m.client.Patch(context.TODO(), &crd, client.MergeFrom(existingCrd))
+		err = m.client.Patch(ctx, &crd, client.MergeFrom(existingCrd), m.patchOptions()...)
 		if err != nil {
 			return err
 		}
-		klog.Infof("updated CRD: %q", crd.Name)
+		logger.Info("updated CRD", "crd", crd.Name, "dryRun", m.options.DryRun)
 	}
 
-	err = wait.Poll(1*time.Second, 30*time.Second, func() (done bool, err error) {
+	pollCtx, cancel := context.WithTimeout(ctx, m.options.Timeout)
+	defer cancel()
+
+	err = wait.PollUntil(defaultPollInterval, func() (done bool, err error) {
 		aggregatedStatus := true
 
 		for _, crd := range crdList {
@@ -89,8 +151,12 @@ func (m *CRDManager) EnsureCRDs() error {
 				return aggregatedStatus, nil
 			}
 			crdResult := &v1.CustomResourceDefinition{}
-			err := m.client.Get(context.TODO(), client.ObjectKey{Name: crd.Name}, crdResult)
+			err := m.client.Get(pollCtx, client.ObjectKey{Name: crd.Name}, crdResult)
 			if err != nil {
+				if m.options.DryRun && apierrors.IsNotFound(err) {
+					// the CRD is yet to be registered by someone else
+					return false, nil
+				}
 				return false, err
 			}
 
@@ -108,24 +174,53 @@ func (m *CRDManager) EnsureCRDs() error {
 			}
 		}
 		return aggregatedStatus, nil
-	})
+	}, pollCtx.Done())
 
 	if err != nil {
-		return err
+		if m.options.DryRun {
+			return fmt.Errorf("failed waiting for CRDs to be registered and established in dry-run mode: %w", err)
+		}
+		return fmt.Errorf("failed waiting for CRDs to become established: %w", err)
 	}
 
 	return nil
 }
 
+func (m *CRDManager) createOptions() []client.CreateOption {
+	var options []client.CreateOption
+	if m.options.FieldManager != "" {
+		options = append(options, client.FieldOwner(m.options.FieldManager))
+	}
+	if m.options.DryRun {
+		options = append(options, client.DryRunAll)
+	}
+	return options
+}
+
+func (m *CRDManager) patchOptions() []client.PatchOption {
+	var options []client.PatchOption
+	if m.options.FieldManager != "" {
+		options = append(options, client.FieldOwner(m.options.FieldManager))
+	}
+	if m.options.DryRun {
+		options = append(options, client.DryRunAll)
+	}
+	return options
+}
+
 func (m *CRDManager) crdsFromDir() ([]v1.CustomResourceDefinition, error) {
 	crdList := make([]v1.CustomResourceDefinition, 0)
-	files, err := m.crdRawDataFS.ReadDir(embedFSCrdRootDir)
+	files, err := fs.ReadDir(m.crdFS, m.rootDir)
 	if err != nil {
 		return nil, err
 	}
 
 	for _, file := range files {
-		data, err := m.crdRawDataFS.ReadFile(path.Join(embedFSCrdRootDir, file.Name()))
+		if file.IsDir() || !isManifest(file.Name()) {
+			continue
+		}
+
+		data, err := fs.ReadFile(m.crdFS, path.Join(m.rootDir, file.Name()))
 		if err != nil {
 			return nil, fmt.Errorf("failed to read CRD file %q: %w", file.Name(), err)
 		}
@@ -137,8 +232,46 @@ func (m *CRDManager) crdsFromDir() ([]v1.CustomResourceDefinition, error) {
 			return nil, fmt.Errorf("failed to decode CRD from file %q: %w", file.Name(), err)
 		}
 
+		if len(m.options.Labels) > 0 {
+			if crd.Labels == nil {
+				crd.Labels = map[string]string{}
+			}
+			for key, value := range m.options.Labels {
+				crd.Labels[key] = value
+			}
+		}
+
 		crdList = append(crdList, *crd)
 	}
 
 	return crdList, nil
 }
+
+func isManifest(fileName string) bool {
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+type runnable struct {
+	crdManager *CRDManager
+	setup      func() error
+}
+
+func (r *runnable) Start(ctx context.Context) error {
+	err := r.crdManager.EnsureCRDs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to ensure CRDs: %w", err)
+	}
+	if r.setup == nil {
+		return nil
+	}
+	return r.setup()
+}
+
+// NeedLeaderElection returns false, registering CRDs is idempotent and may run on every replica.
+func (r *runnable) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,114 @@
+package debug
+
+import (
+	"context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sync"
+	"time"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+// ReconcileState is what the last reconcile of an object returned.
+type ReconcileState struct {
+	// LastReconcileTime is when the last reconcile finished.
+	LastReconcileTime metav1.Time `json:"lastReconcileTime"`
+	// Duration is how long the last reconcile took.
+	Duration string `json:"duration"`
+	// Result is the outcome of the last reconcile, success, requeue or error.
+	Result string `json:"result"`
+	// Error is the error returned by the last reconcile.
+	Error string `json:"error,omitempty"`
+	// RequeueTime is when the object is reconciled again if the last reconcile asked for a requeue after a delay.
+	RequeueTime *metav1.Time `json:"requeueTime,omitempty"`
+	// Reconciles counts the reconciles of the object.
+	Reconciles int64 `json:"reconciles"`
+	// ConsecutiveErrors counts the failed reconciles since the last successful one.
+	ConsecutiveErrors int64 `json:"consecutiveErrors"`
+}
+
+// Recorder keeps the ReconcileState of every object reconciled by the reconcilers it wraps.
+type Recorder struct {
+	clock clock.Clock
+
+	mutex  sync.RWMutex
+	states map[types.NamespacedName]ReconcileState
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		clock:  clock.RealClock{},
+		states: map[types.NamespacedName]ReconcileState{},
+	}
+}
+
+// forgetKey is the context key of the flag set by Forget.
+type forgetKey struct{}
+
+// Forget tells the Recorder wrapping the running reconcile to drop the state of the reconciled object instead
+// of recording the outcome. Reconcilers call it when the object no longer exists.
+func Forget(ctx context.Context) {
+	if forget, ok := ctx.Value(forgetKey{}).(*bool); ok {
+		*forget = true
+	}
+}
+
+// WrapReconciler returns a reconciler that records the outcome of every reconcile of reconciler.
+func (r *Recorder) WrapReconciler(reconciler reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		start := r.clock.Now()
+		forget := false
+		result, err := reconciler.Reconcile(context.WithValue(ctx, forgetKey{}, &forget), req)
+		if forget && err == nil {
+			r.forget(req.NamespacedName)
+		} else {
+			r.record(req.NamespacedName, start, result, err)
+		}
+		return result, err
+	})
+}
+
+func (r *Recorder) record(key types.NamespacedName, start time.Time, result reconcile.Result, err error) {
+	now := r.clock.Now()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	state := r.states[key]
+	state.LastReconcileTime = metav1.NewTime(now)
+	state.Duration = now.Sub(start).String()
+	state.Result = reconcileutil.Outcome(result, err)
+	state.Error = ""
+	state.RequeueTime = nil
+	state.Reconciles++
+	if err != nil {
+		state.Error = err.Error()
+		state.ConsecutiveErrors++
+	} else {
+		state.ConsecutiveErrors = 0
+	}
+	if err == nil && result.RequeueAfter > 0 {
+		requeueTime := metav1.NewTime(now.Add(result.RequeueAfter))
+		state.RequeueTime = &requeueTime
+	}
+	r.states[key] = state
+}
+
+// State returns the ReconcileState of the object called key, if it was reconciled.
+func (r *Recorder) State(key types.NamespacedName) (ReconcileState, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	state, ok := r.states[key]
+	return state, ok
+}
+
+func (r *Recorder) forget(key types.NamespacedName) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.states, key)
+}
@@ -0,0 +1,322 @@
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"net/http"
+	"net/http/pprof"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+const (
+	// MyResourcesPath serves the JSON view of the MyResources, ?namespace= restricts it to a namespace.
+	MyResourcesPath = "/debug/myresources"
+
+	// controllerName is the name of the MyResource controller in the workqueue metrics.
+	controllerName = "myresource"
+
+	shutdownTimeout = 5 * time.Second
+)
+
+// Options configures the debug server.
+type Options struct {
+	// Address is the address the debug server listens on, the server is disabled if it is empty.
+	Address string
+}
+
+func (o *Options) AddFlags(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&o.Address, "debug-address", o.Address,
+		fmt.Sprintf("address of the debug server serving pprof and the reconcile state of MyResources at %s, disabled if empty", MyResourcesPath))
+}
+
+// Enabled reports whether the debug server is configured.
+func (o Options) Enabled() bool {
+	return o.Address != ""
+}
+
+// MyResourceState is the debug view of a MyResource.
+type MyResourceState struct {
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	Generation int64  `json:"generation"`
+	// Reconcile is the state of the last reconcile, it is missing if the MyResource wasn't reconciled yet.
+	Reconcile *ReconcileState `json:"reconcile,omitempty"`
+	// Children are the objects controlled by the MyResource in the cache of the controller.
+	Children []Child `json:"children"`
+}
+
+// Child is an object controlled by a MyResource.
+type Child struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Ready is set for pods.
+	Ready *bool `json:"ready,omitempty"`
+	// Deleting is true if the child is being deleted.
+	Deleting bool `json:"deleting,omitempty"`
+}
+
+// State is the document served at MyResourcesPath.
+type State struct {
+	// WorkqueueDepth is the number of MyResources waiting to be reconciled.
+	WorkqueueDepth int64             `json:"workqueueDepth"`
+	MyResources    []MyResourceState `json:"myresources"`
+}
+
+// Server serves pprof and the reconcile state recorded by a Recorder. It runs as a manager.Runnable.
+type Server struct {
+	address  string
+	client   client.Reader
+	recorder *Recorder
+	gatherer prometheus.Gatherer
+}
+
+// NewServer returns a debug server listening on address. The MyResources and their children are read from c,
+// usually the API reader of the manager. Reading them from the cache would start informers for kinds the
+// controller doesn't watch, they'd hold the objects of all namespaces.
+func NewServer(address string, c client.Reader, recorder *Recorder) *Server {
+	return &Server{
+		address:  address,
+		client:   c,
+		recorder: recorder,
+		gatherer: metrics.Registry,
+	}
+}
+
+// NeedLeaderElection returns false, every replica serves its own state.
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+// Start serves requests until ctx is done.
+func (s *Server) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("debug")
+	httpServer := &http.Server{
+		Addr:    s.address,
+		Handler: s.Handler(),
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("debug server listening", "address", s.address)
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("debug server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("failed to shut down debug server: %w", err)
+	}
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Handler returns the HTTP handler of the server.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc(MyResourcesPath, s.serveMyResources)
+	return mux
+}
+
+func (s *Server) serveMyResources(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	state, err := s.state(req.Context(), req.URL.Query().Get("namespace"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(state)
+}
+
+// state collects the State of the MyResources in namespace, or in all namespaces if it is empty.
+func (s *Server) state(ctx context.Context, namespace string) (*State, error) {
+	myresources := &v1alpha1.MyResourceList{}
+	err := s.client.List(ctx, myresources, client.InNamespace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list MyResources: %w", err)
+	}
+
+	state := &State{
+		WorkqueueDepth: s.workqueueDepth(),
+		MyResources:    make([]MyResourceState, 0, len(myresources.Items)),
+	}
+	children := map[string][]client.Object{}
+	for i := range myresources.Items {
+		myresource := &myresources.Items[i]
+
+		if _, ok := children[myresource.Namespace]; !ok {
+			children[myresource.Namespace], err = s.listChildren(ctx, myresource.Namespace)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		myresourceState := MyResourceState{
+			Namespace:  myresource.Namespace,
+			Name:       myresource.Name,
+			Generation: myresource.Generation,
+			Children:   childrenOf(myresource, children[myresource.Namespace]),
+		}
+		if reconcileState, ok := s.recorder.State(client.ObjectKeyFromObject(myresource)); ok {
+			myresourceState.Reconcile = &reconcileState
+		}
+		state.MyResources = append(state.MyResources, myresourceState)
+	}
+	return state, nil
+}
+
+// listChildren lists the objects in namespace that MyResources may control. It is only called for the
+// namespaces of MyResources.
+func (s *Server) listChildren(ctx context.Context, namespace string) ([]client.Object, error) {
+	var objs []client.Object
+
+	pods := &corev1.PodList{}
+	err := s.client.List(ctx, pods, client.InNamespace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for i := range pods.Items {
+		objs = append(objs, &pods.Items[i])
+	}
+
+	services := &corev1.ServiceList{}
+	err = s.client.List(ctx, services, client.InNamespace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Services: %w", err)
+	}
+	for i := range services.Items {
+		objs = append(objs, &services.Items[i])
+	}
+
+	configMaps := &corev1.ConfigMapList{}
+	err = s.client.List(ctx, configMaps, client.InNamespace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ConfigMaps: %w", err)
+	}
+	for i := range configMaps.Items {
+		objs = append(objs, &configMaps.Items[i])
+	}
+
+	policies := &networkingv1.NetworkPolicyList{}
+	err = s.client.List(ctx, policies, client.InNamespace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list NetworkPolicies: %w", err)
+	}
+	for i := range policies.Items {
+		objs = append(objs, &policies.Items[i])
+	}
+
+	budgets := &policyv1.PodDisruptionBudgetList{}
+	err = s.client.List(ctx, budgets, client.InNamespace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list PodDisruptionBudgets: %w", err)
+	}
+	for i := range budgets.Items {
+		objs = append(objs, &budgets.Items[i])
+	}
+
+	revisions := &appsv1.ControllerRevisionList{}
+	err = s.client.List(ctx, revisions, client.InNamespace(namespace))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ControllerRevisions: %w", err)
+	}
+	for i := range revisions.Items {
+		objs = append(objs, &revisions.Items[i])
+	}
+
+	return objs, nil
+}
+
+func childrenOf(myresource *v1alpha1.MyResource, objs []client.Object) []Child {
+	children := []Child{}
+	for _, obj := range objs {
+		if !metav1.IsControlledBy(obj, myresource) {
+			continue
+		}
+
+		child := Child{Name: obj.GetName(), Deleting: obj.GetDeletionTimestamp() != nil}
+		switch typed := obj.(type) {
+		case *corev1.Pod:
+			child.Kind = "Pod"
+			ready := reconcileutil.IsPodReady(typed)
+			child.Ready = &ready
+		case *corev1.Service:
+			child.Kind = "Service"
+		case *corev1.ConfigMap:
+			child.Kind = "ConfigMap"
+		case *networkingv1.NetworkPolicy:
+			child.Kind = "NetworkPolicy"
+		case *policyv1.PodDisruptionBudget:
+			child.Kind = "PodDisruptionBudget"
+		case *appsv1.ControllerRevision:
+			child.Kind = "ControllerRevision"
+		}
+		children = append(children, child)
+	}
+	return children
+}
+
+// workqueueDepth returns the depth of the workqueue of the MyResource controller from its metrics.
+func (s *Server) workqueueDepth() int64 {
+	families, err := s.gatherer.Gather()
+	if err != nil {
+		return 0
+	}
+	for _, family := range families {
+		if family.GetName() != prometheus.BuildFQName("", "workqueue", metrics.DepthKey) {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			if hasLabel(metric, "name", controllerName) {
+				return int64(metric.GetGauge().GetValue())
+			}
+		}
+	}
+	return 0
+}
+
+func hasLabel(metric *dto.Metric, name string, value string) bool {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == name && label.GetValue() == value {
+			return true
+		}
+	}
+	return false
+}
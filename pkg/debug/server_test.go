@@ -0,0 +1,124 @@
+package debug
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"net/http"
+	"net/http/httptest"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func TestMyResourcesState(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	echo := kittesting.NewMyResource("default", "echo").Build()
+	failing := kittesting.NewMyResource("default", "failing").Build()
+	cluster, err := kittesting.NewCluster(scheme, echo, failing,
+		kittesting.NewPod("default", "echo-pod").OwnedBy(echo).Ready().Build(),
+		kittesting.NewPod("default", "foreign").Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := NewRecorder()
+	reconciler := recorder.WrapReconciler(reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		switch req.Name {
+		case "failing":
+			return reconcile.Result{}, errors.New("failed")
+		case "deleted":
+			Forget(ctx)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{RequeueAfter: time.Minute}, nil
+	}))
+	for _, name := range []string{"echo", "failing", "failing", "unlisted", "deleted"} {
+		_, _ = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: name}})
+	}
+
+	registry := prometheus.NewRegistry()
+	depth := prometheus.NewGaugeVec(prometheus.GaugeOpts{Subsystem: "workqueue", Name: "depth"}, []string{"name"})
+	registry.MustRegister(depth)
+	depth.WithLabelValues("pod").Set(7)
+	depth.WithLabelValues(controllerName).Set(3)
+
+	server := NewServer(":0", cluster, recorder)
+	server.gatherer = registry
+	response := httptest.NewRecorder()
+	server.Handler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, MyResourcesPath, nil))
+	if response.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", response.Code, response.Body.String())
+	}
+
+	state := &State{}
+	err = json.Unmarshal(response.Body.Bytes(), state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.WorkqueueDepth != 3 {
+		t.Errorf("workqueue depth is %d, expected 3", state.WorkqueueDepth)
+	}
+	if len(state.MyResources) != 2 {
+		t.Fatalf("got %d MyResources, expected 2", len(state.MyResources))
+	}
+
+	for _, myresource := range state.MyResources {
+		switch myresource.Name {
+		case "echo":
+			if myresource.Reconcile == nil || myresource.Reconcile.Result != "requeue" || myresource.Reconcile.RequeueTime == nil {
+				t.Errorf("echo has reconcile state %+v", myresource.Reconcile)
+			}
+			if len(myresource.Children) != 1 || myresource.Children[0].Name != "echo-pod" ||
+				myresource.Children[0].Ready == nil || !*myresource.Children[0].Ready {
+				t.Errorf("echo has children %+v", myresource.Children)
+			}
+		case "failing":
+			if myresource.Reconcile == nil || myresource.Reconcile.Error != "failed" || myresource.Reconcile.ConsecutiveErrors != 2 {
+				t.Errorf("failing has reconcile state %+v", myresource.Reconcile)
+			}
+		}
+	}
+
+	if _, ok := recorder.State(types.NamespacedName{Namespace: "default", Name: "unlisted"}); !ok {
+		t.Errorf("serving the state dropped the state of a MyResource missing from the list")
+	}
+}
+
+func TestRecorderForgetsDeletedObjects(t *testing.T) {
+	key := types.NamespacedName{Namespace: "default", Name: "echo"}
+	deleted := false
+	recorder := NewRecorder()
+	reconciler := recorder.WrapReconciler(reconcile.Func(func(ctx context.Context, _ reconcile.Request) (reconcile.Result, error) {
+		if deleted {
+			Forget(ctx)
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, errors.New("failed")
+	}))
+
+	_, _ = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	if _, ok := recorder.State(key); !ok {
+		t.Fatalf("reconcile wasn't recorded")
+	}
+
+	deleted = true
+	_, _ = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	if state, ok := recorder.State(key); ok {
+		t.Errorf("state %+v of a deleted object was kept", state)
+	}
+
+	// Forget without a Recorder is a no-op
+	Forget(context.Background())
+}
@@ -0,0 +1,44 @@
+package echoserver
+
+import (
+	"github.com/go-logr/logr"
+	"net/http"
+	"time"
+)
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(data)
+	r.bytes += n
+	return n, err
+}
+
+// accessLog logs every request served by next.
+func accessLog(logger logr.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, req)
+		logger.Info("served request",
+			"remoteAddr", req.RemoteAddr,
+			"method", req.Method,
+			"uri", req.URL.RequestURI(),
+			"status", recorder.status,
+			"bytes", recorder.bytes,
+			"duration", time.Since(start).String(),
+			"userAgent", req.UserAgent())
+	})
+}
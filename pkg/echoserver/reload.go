@@ -0,0 +1,53 @@
+package echoserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"os"
+)
+
+// loadFiles reads MessageFile and RoutesFile and swaps the served content if it changed.
+func (s *Server) loadFiles() error {
+	current := s.currentContent()
+	message := current.message
+	rawRoutes := current.rawRoutes
+
+	if s.options.MessageFile != "" {
+		data, err := os.ReadFile(s.options.MessageFile)
+		if err != nil {
+			return fmt.Errorf("failed to read message file %q: %w", s.options.MessageFile, err)
+		}
+		message = string(data)
+	}
+	if s.options.RoutesFile != "" {
+		data, err := os.ReadFile(s.options.RoutesFile)
+		if err != nil {
+			return fmt.Errorf("failed to read routes file %q: %w", s.options.RoutesFile, err)
+		}
+		rawRoutes = data
+	}
+
+	if message == current.message && bytes.Equal(rawRoutes, current.rawRoutes) {
+		return nil
+	}
+
+	err := s.setContent(message, rawRoutes)
+	if err != nil {
+		return err
+	}
+	s.log.Info("loaded new content", "messageHash", MessageHash(message))
+	return nil
+}
+
+// watchFiles polls MessageFile and RoutesFile until ctx is done. Polling copes with the symlink swaps
+// the kubelet uses to update mounted ConfigMaps atomically.
+func (s *Server) watchFiles(ctx context.Context) {
+	wait.UntilWithContext(ctx, func(context.Context) {
+		err := s.loadFiles()
+		if err != nil {
+			s.log.Error(err, "keeping current content")
+		}
+	}, s.options.ReloadInterval)
+}
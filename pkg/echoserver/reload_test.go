@@ -0,0 +1,109 @@
+package echoserver
+
+import (
+	"encoding/json"
+	"github.com/go-logr/logr"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func get(t *testing.T, s *Server, path string) (int, string) {
+	t.Helper()
+	response := httptest.NewRecorder()
+	s.Handler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, path, nil))
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return response.Code, string(body)
+}
+
+func writeFile(t *testing.T, name string, data string) {
+	t.Helper()
+	err := os.WriteFile(name, []byte(data), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadFilesSwapsContent(t *testing.T) {
+	dir := t.TempDir()
+	messageFile := filepath.Join(dir, "message")
+	routesFile := filepath.Join(dir, "routes")
+	writeFile(t, messageFile, "hello")
+	writeFile(t, routesFile, `[{"path": "/teapot", "statusCode": 418}]`)
+
+	s, err := New(Options{MessageFile: messageFile, RoutesFile: routesFile, Logger: logr.Discard()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadFiles(); err != nil {
+		t.Fatal(err)
+	}
+	if _, body := get(t, s, "/"); body != "hello" {
+		t.Errorf("served %q, expected %q", body, "hello")
+	}
+	if code, _ := get(t, s, "/teapot"); code != http.StatusTeapot {
+		t.Errorf("route served status %d, expected %d", code, http.StatusTeapot)
+	}
+
+	writeFile(t, messageFile, "goodbye")
+	writeFile(t, routesFile, "")
+	if err := s.loadFiles(); err != nil {
+		t.Fatal(err)
+	}
+	if _, body := get(t, s, "/"); body != "goodbye" {
+		t.Errorf("served %q after the reload, expected %q", body, "goodbye")
+	}
+	if code, _ := get(t, s, "/teapot"); code != http.StatusOK {
+		t.Errorf("removed route still served status %d", code)
+	}
+
+	_, body := get(t, s, StatusPath)
+	status := Status{}
+	if err := json.Unmarshal([]byte(body), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status.MessageHash != MessageHash("goodbye") {
+		t.Errorf("status reports hash %q, expected the hash of the reloaded message", status.MessageHash)
+	}
+}
+
+func TestLoadFilesKeepsContentOnInvalidRoutes(t *testing.T) {
+	dir := t.TempDir()
+	messageFile := filepath.Join(dir, "message")
+	routesFile := filepath.Join(dir, "routes")
+	writeFile(t, messageFile, "hello")
+	writeFile(t, routesFile, "")
+
+	s, err := New(Options{MessageFile: messageFile, RoutesFile: routesFile, Logger: logr.Discard()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadFiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, messageFile, "goodbye")
+	writeFile(t, routesFile, `[{"path": "no-slash"}]`)
+	if err := s.loadFiles(); err == nil {
+		t.Error("invalid routes were loaded")
+	}
+	if s.Message() != "hello" {
+		t.Errorf("serves %q after a rejected reload, expected the previous message", s.Message())
+	}
+}
+
+func TestLoadFilesFailsForMissingFile(t *testing.T) {
+	s, err := New(Options{MessageFile: filepath.Join(t.TempDir(), "missing"), Logger: logr.Discard()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.loadFiles(); err == nil {
+		t.Error("a missing message file was accepted")
+	}
+}
@@ -0,0 +1,127 @@
+package echoserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/echotemplate"
+)
+
+const (
+	PathTypeExact  = "Exact"
+	PathTypePrefix = "Prefix"
+
+	defaultContentType = "text/plain"
+)
+
+// ReservedPaths are served by the echo server itself, routes can't override them.
+var ReservedPaths = []string{"/healthz", "/readyz", StatusPath, "/metrics"}
+
+// IsReservedPath reports whether a route for path would be shadowed by one of the ReservedPaths.
+func IsReservedPath(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	for _, reserved := range ReservedPaths {
+		if path == reserved {
+			return true
+		}
+	}
+	return false
+}
+
+// Route defines the response the echo server sends for requests matching Path.
+// Its JSON representation is the route table the controller passes to the echo pods.
+type Route struct {
+	Path string `json:"path"`
+	// PathType is either Exact or Prefix. Defaults to Prefix. Prefixes match whole path segments
+	// like the Prefix paths of Ingresses: /api matches /api and /api/v1, but not /apis.
+	PathType    string            `json:"pathType,omitempty"`
+	Body        string            `json:"body,omitempty"`
+	StatusCode  int               `json:"statusCode,omitempty"`
+	ContentType string            `json:"contentType,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+}
+
+// ParseRoutes decodes a JSON route table. Empty data yields no routes.
+func ParseRoutes(data []byte) ([]Route, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	var routes []Route
+	err := json.Unmarshal(data, &routes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode routes: %w", err)
+	}
+	return routes, nil
+}
+
+type route struct {
+	Route
+	template *template.Template
+}
+
+func (r *route) matches(path string) bool {
+	if r.PathType == PathTypeExact {
+		return path == r.Path
+	}
+	prefix := strings.TrimSuffix(r.Path, "/")
+	return path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// compileRoutes validates routes and parses their bodies as templates if useTemplates is set.
+func compileRoutes(routes []Route, useTemplates bool) ([]*route, error) {
+	compiled := make([]*route, 0, len(routes))
+	for _, r := range routes {
+		if r.Path == "" || !strings.HasPrefix(r.Path, "/") {
+			return nil, fmt.Errorf("route path %q must start with /", r.Path)
+		}
+		if IsReservedPath(r.Path) {
+			return nil, fmt.Errorf("route path %q is reserved for the echo server", r.Path)
+		}
+		if r.PathType != "" && r.PathType != PathTypeExact && r.PathType != PathTypePrefix {
+			return nil, fmt.Errorf("route %q has unknown path type %q", r.Path, r.PathType)
+		}
+		if r.StatusCode == 0 {
+			r.StatusCode = http.StatusOK
+		}
+		if r.StatusCode < 100 || r.StatusCode > 599 {
+			return nil, fmt.Errorf("route %q has invalid status code %d", r.Path, r.StatusCode)
+		}
+		if r.ContentType == "" {
+			r.ContentType = defaultContentType
+		}
+
+		c := &route{Route: r}
+		if useTemplates {
+			tmpl, err := echotemplate.Parse(r.Body)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template in route %q: %w", r.Path, err)
+			}
+			c.template = tmpl
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// matchRoute returns the route for path. Exact matches win over prefix matches,
+// and longer prefixes over shorter ones. It returns nil if no route matches.
+func matchRoute(routes []*route, path string) *route {
+	var best *route
+	for _, r := range routes {
+		if !r.matches(path) {
+			continue
+		}
+		if r.PathType == PathTypeExact {
+			return r
+		}
+		if best == nil || len(r.Path) > len(best.Path) {
+			best = r
+		}
+	}
+	return best
+}
@@ -0,0 +1,126 @@
+package echoserver
+
+import (
+	"github.com/go-logr/logr"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatchRoute(t *testing.T) {
+	routes, err := compileRoutes([]Route{
+		{Path: "/", Body: "root"},
+		{Path: "/api", Body: "api"},
+		{Path: "/api/v1/", Body: "v1"},
+		{Path: "/api/v1/status", PathType: PathTypeExact, Body: "status"},
+		{Path: "/exact", PathType: PathTypeExact, Body: "exact"},
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"/":                   "root",
+		"/other":              "root",
+		"/api":                "api",
+		"/api/":               "api",
+		"/apis":               "root",
+		"/api/v1":             "v1",
+		"/api/v1/users":       "v1",
+		"/api/v10":            "api",
+		"/api/v1/status":      "status",
+		"/api/v1/status/more": "v1",
+		"/exact":              "exact",
+		"/exact/more":         "root",
+	}
+	for path, expected := range tests {
+		r := matchRoute(routes, path)
+		if r == nil {
+			t.Errorf("no route matched %q, expected %q", path, expected)
+			continue
+		}
+		if r.Body != expected {
+			t.Errorf("route %q matched %q, expected %q", r.Body, path, expected)
+		}
+	}
+
+	if r := matchRoute(routes[1:], "/other"); r != nil {
+		t.Errorf("route %q matched a path outside of all routes", r.Path)
+	}
+}
+
+func TestCompileRoutesRejectsInvalidRoutes(t *testing.T) {
+	tests := map[string]Route{
+		"empty path":          {},
+		"relative path":       {Path: "api"},
+		"unknown path type":   {Path: "/api", PathType: "Regex"},
+		"invalid status code": {Path: "/api", StatusCode: 600},
+		"health check":        {Path: "/healthz"},
+		"readiness check":     {Path: "/readyz/", PathType: PathTypePrefix},
+		"status":              {Path: StatusPath, PathType: PathTypeExact},
+		"metrics":             {Path: "/metrics"},
+	}
+	for name, r := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := compileRoutes([]Route{r}, false); err == nil {
+				t.Errorf("route %+v was accepted", r)
+			}
+		})
+	}
+
+	if _, err := compileRoutes([]Route{{Path: "/api", Body: "{{ .Unknown }}"}}, true); err == nil {
+		t.Error("route with an invalid template was accepted")
+	}
+	if _, err := compileRoutes([]Route{{Path: "/api", Body: "{{ .Unknown }}"}}, false); err != nil {
+		t.Errorf("route body was parsed as template without templates: %v", err)
+	}
+}
+
+func TestIsReservedPath(t *testing.T) {
+	tests := map[string]bool{
+		"/healthz":   true,
+		"/readyz/":   true,
+		StatusPath:   true,
+		"/metrics":   true,
+		"/":          false,
+		"/health":    false,
+		"/metrics/x": false,
+	}
+	for path, expected := range tests {
+		if reserved := IsReservedPath(path); reserved != expected {
+			t.Errorf("IsReservedPath(%q) = %t, expected %t", path, reserved, expected)
+		}
+	}
+}
+
+func TestServeRoute(t *testing.T) {
+	s, err := New(Options{
+		Message: "default",
+		Routes: []Route{{
+			Path:        "/json",
+			Body:        `{"ok": true}`,
+			StatusCode:  http.StatusAccepted,
+			ContentType: "application/json",
+			Headers:     map[string]string{"X-Route": "json"},
+		}},
+		Logger: logr.Discard(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := httptest.NewRecorder()
+	s.Handler().ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/json/sub", nil))
+	if response.Code != http.StatusAccepted || response.Body.String() != `{"ok": true}` {
+		t.Errorf("route served status %d and body %q", response.Code, response.Body.String())
+	}
+	if contentType := response.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("route served content type %q", contentType)
+	}
+	if header := response.Header().Get("X-Route"); header != "json" {
+		t.Errorf("route served header X-Route %q", header)
+	}
+	if code, body := get(t, s, "/"); code != http.StatusOK || body != "default" {
+		t.Errorf("unmatched path served status %d and body %q", code, body)
+	}
+}
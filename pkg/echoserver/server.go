@@ -0,0 +1,274 @@
+package echoserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/echotemplate"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+const (
+	DefaultAddress         = ":8080"
+	DefaultShutdownTimeout = 30 * time.Second
+	DefaultDrainDelay      = 5 * time.Second
+	DefaultReloadInterval  = 2 * time.Second
+)
+
+// Options configures the echo server.
+type Options struct {
+	// Address is the address the server listens on.
+	Address string
+	// Message is the body returned for every request.
+	Message string
+	// MessageFile, if set, is read for the message instead of Message and watched for changes.
+	MessageFile string
+	// Routes are served instead of Message for the requests they match.
+	Routes []Route
+	// RoutesFile, if set, is read for a JSON route table instead of Routes and watched for changes.
+	RoutesFile string
+	// ReloadInterval is how often MessageFile and RoutesFile are checked for changes.
+	ReloadInterval time.Duration
+	// Template renders the message and the route bodies as Go text/template for every request.
+	Template bool
+	// TemplateHeaders lists the request headers that are available to templates.
+	TemplateHeaders []string
+	// Pod identifies the pod the server runs in, templates may refer to it.
+	Pod echotemplate.Pod
+	// TraceParent is the W3C trace context of the reconcile that created the pod, the spans of requests link to it.
+	TraceParent string
+	// DrainDelay is how long the server keeps serving with a failing readiness probe
+	// after it was asked to stop, so that endpoints can be removed before connections are closed.
+	DrainDelay time.Duration
+	// ShutdownTimeout bounds how long in-flight requests may take to finish on shutdown.
+	ShutdownTimeout time.Duration
+	// Logger receives the access log and the lifecycle events of the server.
+	// It defaults to the controller-runtime logger named echoserver.
+	Logger logr.Logger
+}
+
+// Server serves a message and reports its health and request metrics.
+type Server struct {
+	options  Options
+	log      logr.Logger
+	content  atomic.Value
+	draining int32
+	registry *prometheus.Registry
+	tracer   trace.Tracer
+	handler  http.Handler
+}
+
+// content is the message and route table served at a time, together with their parsed templates.
+type content struct {
+	message   string
+	template  *template.Template
+	rawRoutes []byte
+	routes    []*route
+}
+
+func New(options Options) (*Server, error) {
+	if options.Address == "" {
+		options.Address = DefaultAddress
+	}
+	if options.ShutdownTimeout <= 0 {
+		options.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if options.ReloadInterval <= 0 {
+		options.ReloadInterval = DefaultReloadInterval
+	}
+	if options.Logger == nil {
+		options.Logger = log.Log.WithName("echoserver")
+	}
+
+	s := &Server{
+		options:  options,
+		log:      options.Logger,
+		registry: prometheus.NewRegistry(),
+		tracer:   tracing.Tracer(),
+	}
+	rawRoutes, err := json.Marshal(options.Routes)
+	if err != nil {
+		return nil, err
+	}
+	err = s.setContent(options.Message, rawRoutes)
+	if err != nil {
+		return nil, err
+	}
+	s.handler = s.newHandler()
+	return s, nil
+}
+
+// Message returns the message currently served.
+func (s *Server) Message() string {
+	return s.currentContent().message
+}
+
+func (s *Server) currentContent() *content {
+	return s.content.Load().(*content)
+}
+
+// setContent atomically replaces the served message and route table. Invalid content is rejected.
+func (s *Server) setContent(message string, rawRoutes []byte) error {
+	next := &content{message: message, rawRoutes: rawRoutes}
+	if s.options.Template {
+		tmpl, err := echotemplate.Parse(message)
+		if err != nil {
+			return fmt.Errorf("invalid message template: %w", err)
+		}
+		next.template = tmpl
+	}
+
+	routes, err := ParseRoutes(rawRoutes)
+	if err != nil {
+		return err
+	}
+	next.routes, err = compileRoutes(routes, s.options.Template)
+	if err != nil {
+		return err
+	}
+
+	s.content.Store(next)
+	return nil
+}
+
+// Handler returns the HTTP handler of the server.
+func (s *Server) Handler() http.Handler {
+	return s.handler
+}
+
+// Run serves requests until ctx is done and then drains and shuts the server down.
+func (s *Server) Run(ctx context.Context) error {
+	if s.options.MessageFile != "" || s.options.RoutesFile != "" {
+		err := s.loadFiles()
+		if err != nil {
+			return err
+		}
+		go s.watchFiles(ctx)
+	}
+
+	httpServer := &http.Server{
+		Addr:    s.options.Address,
+		Handler: s.handler,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.log.Info("echo server listening", "address", s.options.Address)
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("echo server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	s.log.Info("draining echo server", "drainDelay", s.options.DrainDelay.String())
+	atomic.StoreInt32(&s.draining, 1)
+	time.Sleep(s.options.DrainDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.options.ShutdownTimeout)
+	defer cancel()
+	err := httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("failed to shut down echo server: %w", err)
+	}
+
+	if err := <-serveErr; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	s.log.Info("echo server stopped")
+	return nil
+}
+
+func (s *Server) newHandler() http.Handler {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "echoserver_http_requests_total",
+		Help: "Number of HTTP requests served, partitioned by status code and method.",
+	}, []string{"code", "method"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "echoserver_http_request_duration_seconds",
+		Help:    "Latency of HTTP requests, partitioned by status code and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"code", "method"})
+	inFlight := prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "echoserver_http_requests_in_flight",
+		Help: "Number of HTTP requests currently being served.",
+	})
+	s.registry.MustRegister(requests, duration, inFlight,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	echo := promhttp.InstrumentHandlerInFlight(inFlight,
+		promhttp.InstrumentHandlerDuration(duration,
+			promhttp.InstrumentHandlerCounter(requests, http.HandlerFunc(s.serveMessage))))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.serveHealthz)
+	mux.HandleFunc("/readyz", s.serveReadyz)
+	mux.HandleFunc(StatusPath, s.serveStatus)
+	mux.Handle("/metrics", promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{}))
+	mux.Handle("/", accessLog(s.log, s.traced(echo)))
+	return mux
+}
+
+func (s *Server) serveMessage(w http.ResponseWriter, req *http.Request) {
+	current := s.currentContent()
+	statusCode := http.StatusOK
+	contentType := defaultContentType
+	body := current.message
+	tmpl := current.template
+
+	if r := matchRoute(current.routes, req.URL.Path); r != nil {
+		for key, value := range r.Headers {
+			w.Header().Set(key, value)
+		}
+		statusCode = r.StatusCode
+		contentType = r.ContentType
+		body = r.Body
+		tmpl = r.template
+	}
+
+	rendered := []byte(body)
+	if tmpl != nil {
+		buffer := &bytes.Buffer{}
+		err := tmpl.Execute(buffer, echotemplate.NewData(req, s.options.TemplateHeaders, s.options.Pod))
+		if err != nil {
+			s.log.Error(err, "failed to render message template")
+			http.Error(w, "failed to render message", http.StatusInternalServerError)
+			return
+		}
+		rendered = buffer.Bytes()
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(statusCode)
+	_, _ = w.Write(rendered)
+}
+
+func (s *Server) serveHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+func (s *Server) serveReadyz(w http.ResponseWriter, _ *http.Request) {
+	if atomic.LoadInt32(&s.draining) == 1 {
+		http.Error(w, "draining", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
@@ -0,0 +1,51 @@
+package echoserver
+
+import (
+	"github.com/go-logr/logr"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestServeMessage(t *testing.T) {
+	s, err := New(Options{Message: "hello", Logger: logr.Discard()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/", "/any/path"} {
+		if code, body := get(t, s, path); code != http.StatusOK || body != "hello" {
+			t.Errorf("%s served status %d and body %q", path, code, body)
+		}
+	}
+	if code, _ := get(t, s, "/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz served status %d", code)
+	}
+	if code, _ := get(t, s, "/readyz"); code != http.StatusOK {
+		t.Errorf("/readyz served status %d", code)
+	}
+
+	_, metrics := get(t, s, "/metrics")
+	if !strings.Contains(metrics, `echoserver_http_requests_total{code="200",method="get"} 2`) {
+		t.Errorf("/metrics doesn't count the served requests:\n%s", metrics)
+	}
+}
+
+func TestDrainingServerIsNotReady(t *testing.T) {
+	s, err := New(Options{Message: "hello", Logger: logr.Discard()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(&s.draining, 1)
+
+	if code, _ := get(t, s, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz served status %d while draining", code)
+	}
+	if code, _ := get(t, s, "/healthz"); code != http.StatusOK {
+		t.Errorf("/healthz served status %d while draining", code)
+	}
+	if code, body := get(t, s, "/"); code != http.StatusOK || body != "hello" {
+		t.Errorf("draining server served status %d and body %q", code, body)
+	}
+}
@@ -0,0 +1,27 @@
+package echoserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+// StatusPath is the path at which the echo server reports the content it currently serves.
+const StatusPath = "/statusz"
+
+// Status is the response body served at StatusPath.
+type Status struct {
+	MessageHash string `json:"messageHash"`
+}
+
+// MessageHash returns the hash by which the echo server identifies message.
+func MessageHash(message string) string {
+	sum := sha256.Sum256([]byte(message))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Server) serveStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(Status{MessageHash: MessageHash(s.Message())})
+}
@@ -0,0 +1,38 @@
+package echoserver
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+)
+
+// traced runs every request served by next in a span. The span is a child of the trace context of the request,
+// if it has one, and links to the reconcile that created the pod of the server.
+func (s *Server) traced(next http.Handler) http.Handler {
+	var links []trace.Link
+	creator := trace.SpanContextFromContext(tracing.ContextWithTraceParent(context.Background(), s.options.TraceParent))
+	if creator.IsValid() {
+		links = append(links, trace.Link{SpanContext: creator})
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := propagation.TraceContext{}.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+		ctx, span := s.tracer.Start(ctx, "HTTP "+req.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithLinks(links...),
+			trace.WithAttributes(semconv.HTTPServerAttributesFromHTTPRequest("echoserver", "", req)...))
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, req.WithContext(ctx))
+		span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(recorder.status)...)
+		if code, message := semconv.SpanStatusFromHTTPStatusCode(recorder.status); code == codes.Error {
+			span.SetStatus(code, message)
+		}
+	})
+}
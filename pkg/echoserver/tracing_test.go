@@ -0,0 +1,75 @@
+package echoserver
+
+import (
+	"github.com/go-logr/logr"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/tracing"
+)
+
+const (
+	creatorTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	creatorSpanID  = "00f067aa0ba902b7"
+	requestTraceID = "0af7651916cd43dd8448eb211c80319c"
+	requestSpanID  = "b7ad6b7169203331"
+)
+
+func TestRequestSpansLinkToCreator(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	s, err := New(Options{
+		Message:     "hello",
+		TraceParent: "00-" + creatorTraceID + "-" + creatorSpanID + "-01",
+		Logger:      logr.Discard(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.tracer = provider.Tracer(tracing.TracerName)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-"+requestTraceID+"-"+requestSpanID+"-01")
+	s.Handler().ServeHTTP(httptest.NewRecorder(), req)
+	get(t, s, "/healthz")
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, expected 1 for the echo request", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "HTTP GET" {
+		t.Errorf("recorded span %q", span.Name())
+	}
+	if span.Parent().TraceID().String() != requestTraceID || span.Parent().SpanID().String() != requestSpanID {
+		t.Errorf("span has parent %s/%s, expected the trace context of the request", span.Parent().TraceID(), span.Parent().SpanID())
+	}
+	links := span.Links()
+	if len(links) != 1 || links[0].SpanContext.TraceID().String() != creatorTraceID || links[0].SpanContext.SpanID().String() != creatorSpanID {
+		t.Errorf("span has links %+v, expected a link to the reconcile that created the pod", links)
+	}
+}
+
+func TestRequestSpansWithoutCreator(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	s, err := New(Options{Message: "hello", TraceParent: "invalid", Logger: logr.Discard()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.tracer = provider.Tracer(tracing.TracerName)
+	get(t, s, "/")
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, expected 1", len(spans))
+	}
+	if spans[0].Parent().IsValid() || len(spans[0].Links()) > 0 {
+		t.Errorf("span without trace context has parent %v and links %+v", spans[0].Parent(), spans[0].Links())
+	}
+}
@@ -0,0 +1,124 @@
+// Package echotemplate renders messages of MyResources that are Go text/templates.
+// The controller uses it to validate templates, the echo server to render them per request.
+package echotemplate
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Data is passed to a message template when it is rendered.
+type Data struct {
+	Request Request
+	Pod     Pod
+}
+
+// Request holds the parts of the HTTP request that templates may use.
+type Request struct {
+	Method string
+	Path   string
+	// Headers holds the selected headers of the request, keyed by their canonical name.
+	Headers map[string]string
+	// Query holds the first value of every query parameter.
+	Query map[string]string
+}
+
+// Pod identifies the echo pod rendering the template.
+type Pod struct {
+	Name      string
+	Namespace string
+	Node      string
+}
+
+// Funcs returns the functions available in message templates.
+func Funcs() template.FuncMap {
+	return template.FuncMap{
+		"now": time.Now,
+		"formatTime": func(layout string, t time.Time) string {
+			return t.Format(layout)
+		},
+		"utc": func(t time.Time) time.Time {
+			return t.UTC()
+		},
+		"unix": func(t time.Time) int64 {
+			return t.Unix()
+		},
+		"header": func(name string, request Request) string {
+			return request.Headers[http.CanonicalHeaderKey(name)]
+		},
+		"query": func(name string, request Request) string {
+			return request.Query[name]
+		},
+		"default": func(fallback string, value string) string {
+			if value == "" {
+				return fallback
+			}
+			return value
+		},
+	}
+}
+
+// Parse parses text as message template and renders it once with sample data,
+// so that references to unknown fields are reported ahead of time.
+func Parse(text string) (*template.Template, error) {
+	tmpl, err := template.New("message").Funcs(Funcs()).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tmpl.Execute(io.Discard, sampleData())
+	if err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// Validate returns an error describing why text is not a valid message template.
+func Validate(text string) error {
+	_, err := Parse(text)
+	if err != nil {
+		return fmt.Errorf("invalid message template: %w", err)
+	}
+	return nil
+}
+
+// NewData collects the template data of req. Only the headers listed in headers are exposed.
+func NewData(req *http.Request, headers []string, pod Pod) Data {
+	data := Data{
+		Request: Request{
+			Method:  req.Method,
+			Path:    req.URL.Path,
+			Headers: map[string]string{},
+			Query:   map[string]string{},
+		},
+		Pod: pod,
+	}
+
+	for _, header := range headers {
+		header = strings.TrimSpace(header)
+		if value := req.Header.Get(header); value != "" {
+			data.Request.Headers[http.CanonicalHeaderKey(header)] = value
+		}
+	}
+	for key, values := range req.URL.Query() {
+		if len(values) > 0 {
+			data.Request.Query[key] = values[0]
+		}
+	}
+	return data
+}
+
+func sampleData() Data {
+	return Data{
+		Request: Request{
+			Method:  http.MethodGet,
+			Path:    "/",
+			Headers: map[string]string{},
+			Query:   map[string]string{},
+		},
+	}
+}
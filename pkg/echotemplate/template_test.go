@@ -0,0 +1,84 @@
+package echotemplate
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		headers  []string
+		expected string
+	}{
+		{
+			name:     "plain text",
+			template: "hello",
+			expected: "hello",
+		},
+		{
+			name:     "request",
+			template: "{{ .Request.Method }} {{ .Request.Path }}",
+			expected: "GET /greet",
+		},
+		{
+			name:     "selected header",
+			template: `{{ header "x-user" .Request }}`,
+			headers:  []string{" X-User "},
+			expected: "alice",
+		},
+		{
+			name:     "header not selected",
+			template: `{{ header "X-User" .Request | default "anonymous" }}`,
+			expected: "anonymous",
+		},
+		{
+			name:     "query",
+			template: `{{ query "lang" .Request }}`,
+			expected: "en",
+		},
+		{
+			name:     "pod",
+			template: "{{ .Pod.Name }}/{{ .Pod.Namespace }}@{{ .Pod.Node }}",
+			expected: "echo/default@node",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tmpl, err := Parse(test.template)
+			if err != nil {
+				t.Fatalf("failed to parse template: %v", err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/greet?lang=en&lang=de", nil)
+			req.Header.Set("X-User", "alice")
+			buffer := &bytes.Buffer{}
+			err = tmpl.Execute(buffer, NewData(req, test.headers, Pod{Name: "echo", Namespace: "default", Node: "node"}))
+			if err != nil {
+				t.Fatalf("failed to render template: %v", err)
+			}
+			if buffer.String() != test.expected {
+				t.Errorf("rendered %q, expected %q", buffer.String(), test.expected)
+			}
+		})
+	}
+}
+
+func TestValidateRejectsInvalidTemplates(t *testing.T) {
+	tests := map[string]string{
+		"syntax error":     "{{ .Request.Path ",
+		"unknown function": "{{ shout .Request.Path }}",
+		"unknown field":    "{{ .Request.Body }}",
+		"failing function": `{{ formatTime "2006" .Request.Path }}`,
+	}
+	for name, text := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := Validate(text); err == nil {
+				t.Errorf("template %q was accepted", text)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	samplecontrollerv1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/generated/clientset/versioned/typed/samplecontroller/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *FakeMyResources) Rollback(ctx context.Context, name string, toRevision int64, opts v1.UpdateOptions) (*v1alpha1.MyResource, error) {
+	myResource, err := c.Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	samplecontrollerv1alpha1.SetRollbackTo(myResource, toRevision)
+	return c.Update(ctx, myResource, opts)
+}
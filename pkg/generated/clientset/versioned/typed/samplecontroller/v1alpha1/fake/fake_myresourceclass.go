@@ -0,0 +1,106 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeMyResourceClasses implements MyResourceClassInterface
+type FakeMyResourceClasses struct {
+	Fake *FakeSamplecontrollerV1alpha1
+}
+
+var myresourceclassesResource = schema.GroupVersionResource{Group: "samplecontroller.reshnm.de", Version: "v1alpha1", Resource: "myresourceclasses"}
+
+var myresourceclassesKind = schema.GroupVersionKind{Group: "samplecontroller.reshnm.de", Version: "v1alpha1", Kind: "MyResourceClass"}
+
+// Get takes name of the myResourceClass, and returns the corresponding myResourceClass object, and an error if there is any.
+func (c *FakeMyResourceClasses) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.MyResourceClass, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(myresourceclassesResource, name), &v1alpha1.MyResourceClass{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceClass), err
+}
+
+// List takes label and field selectors, and returns the list of MyResourceClasses that match those selectors.
+func (c *FakeMyResourceClasses) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.MyResourceClassList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(myresourceclassesResource, myresourceclassesKind, opts), &v1alpha1.MyResourceClassList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.MyResourceClassList{ListMeta: obj.(*v1alpha1.MyResourceClassList).ListMeta}
+	for _, item := range obj.(*v1alpha1.MyResourceClassList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested myResourceClasses.
+func (c *FakeMyResourceClasses) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(myresourceclassesResource, opts))
+}
+
+// Create takes the representation of a myResourceClass and creates it.  Returns the server's representation of the myResourceClass, and an error, if there is any.
+func (c *FakeMyResourceClasses) Create(ctx context.Context, myResourceClass *v1alpha1.MyResourceClass, opts v1.CreateOptions) (result *v1alpha1.MyResourceClass, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(myresourceclassesResource, myResourceClass), &v1alpha1.MyResourceClass{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceClass), err
+}
+
+// Update takes the representation of a myResourceClass and updates it. Returns the server's representation of the myResourceClass, and an error, if there is any.
+func (c *FakeMyResourceClasses) Update(ctx context.Context, myResourceClass *v1alpha1.MyResourceClass, opts v1.UpdateOptions) (result *v1alpha1.MyResourceClass, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(myresourceclassesResource, myResourceClass), &v1alpha1.MyResourceClass{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceClass), err
+}
+
+// Delete takes name of the myResourceClass and deletes it. Returns an error if one occurs.
+func (c *FakeMyResourceClasses) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(myresourceclassesResource, name), &v1alpha1.MyResourceClass{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeMyResourceClasses) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(myresourceclassesResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.MyResourceClassList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched myResourceClass.
+func (c *FakeMyResourceClasses) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.MyResourceClass, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(myresourceclassesResource, name, pt, data, subresources...), &v1alpha1.MyResourceClass{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceClass), err
+}
@@ -0,0 +1,126 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeMyResourceQuotas implements MyResourceQuotaInterface
+type FakeMyResourceQuotas struct {
+	Fake *FakeSamplecontrollerV1alpha1
+	ns   string
+}
+
+var myresourcequotasResource = schema.GroupVersionResource{Group: "samplecontroller.reshnm.de", Version: "v1alpha1", Resource: "myresourcequotas"}
+
+var myresourcequotasKind = schema.GroupVersionKind{Group: "samplecontroller.reshnm.de", Version: "v1alpha1", Kind: "MyResourceQuota"}
+
+// Get takes name of the myResourceQuota, and returns the corresponding myResourceQuota object, and an error if there is any.
+func (c *FakeMyResourceQuotas) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.MyResourceQuota, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(myresourcequotasResource, c.ns, name), &v1alpha1.MyResourceQuota{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceQuota), err
+}
+
+// List takes label and field selectors, and returns the list of MyResourceQuotas that match those selectors.
+func (c *FakeMyResourceQuotas) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.MyResourceQuotaList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(myresourcequotasResource, myresourcequotasKind, c.ns, opts), &v1alpha1.MyResourceQuotaList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.MyResourceQuotaList{ListMeta: obj.(*v1alpha1.MyResourceQuotaList).ListMeta}
+	for _, item := range obj.(*v1alpha1.MyResourceQuotaList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested myResourceQuotas.
+func (c *FakeMyResourceQuotas) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(myresourcequotasResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a myResourceQuota and creates it.  Returns the server's representation of the myResourceQuota, and an error, if there is any.
+func (c *FakeMyResourceQuotas) Create(ctx context.Context, myResourceQuota *v1alpha1.MyResourceQuota, opts v1.CreateOptions) (result *v1alpha1.MyResourceQuota, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(myresourcequotasResource, c.ns, myResourceQuota), &v1alpha1.MyResourceQuota{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceQuota), err
+}
+
+// Update takes the representation of a myResourceQuota and updates it. Returns the server's representation of the myResourceQuota, and an error, if there is any.
+func (c *FakeMyResourceQuotas) Update(ctx context.Context, myResourceQuota *v1alpha1.MyResourceQuota, opts v1.UpdateOptions) (result *v1alpha1.MyResourceQuota, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(myresourcequotasResource, c.ns, myResourceQuota), &v1alpha1.MyResourceQuota{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceQuota), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeMyResourceQuotas) UpdateStatus(ctx context.Context, myResourceQuota *v1alpha1.MyResourceQuota, opts v1.UpdateOptions) (*v1alpha1.MyResourceQuota, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(myresourcequotasResource, "status", c.ns, myResourceQuota), &v1alpha1.MyResourceQuota{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceQuota), err
+}
+
+// Delete takes name of the myResourceQuota and deletes it. Returns an error if one occurs.
+func (c *FakeMyResourceQuotas) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(myresourcequotasResource, c.ns, name), &v1alpha1.MyResourceQuota{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeMyResourceQuotas) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(myresourcequotasResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.MyResourceQuotaList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched myResourceQuota.
+func (c *FakeMyResourceQuotas) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.MyResourceQuota, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(myresourcequotasResource, c.ns, name, pt, data, subresources...), &v1alpha1.MyResourceQuota{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceQuota), err
+}
@@ -0,0 +1,117 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeMyResourceSets implements MyResourceSetInterface
+type FakeMyResourceSets struct {
+	Fake *FakeSamplecontrollerV1alpha1
+}
+
+var myresourcesetsResource = schema.GroupVersionResource{Group: "samplecontroller.reshnm.de", Version: "v1alpha1", Resource: "myresourcesets"}
+
+var myresourcesetsKind = schema.GroupVersionKind{Group: "samplecontroller.reshnm.de", Version: "v1alpha1", Kind: "MyResourceSet"}
+
+// Get takes name of the myResourceSet, and returns the corresponding myResourceSet object, and an error if there is any.
+func (c *FakeMyResourceSets) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.MyResourceSet, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(myresourcesetsResource, name), &v1alpha1.MyResourceSet{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceSet), err
+}
+
+// List takes label and field selectors, and returns the list of MyResourceSets that match those selectors.
+func (c *FakeMyResourceSets) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.MyResourceSetList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(myresourcesetsResource, myresourcesetsKind, opts), &v1alpha1.MyResourceSetList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.MyResourceSetList{ListMeta: obj.(*v1alpha1.MyResourceSetList).ListMeta}
+	for _, item := range obj.(*v1alpha1.MyResourceSetList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested myResourceSets.
+func (c *FakeMyResourceSets) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(myresourcesetsResource, opts))
+}
+
+// Create takes the representation of a myResourceSet and creates it.  Returns the server's representation of the myResourceSet, and an error, if there is any.
+func (c *FakeMyResourceSets) Create(ctx context.Context, myResourceSet *v1alpha1.MyResourceSet, opts v1.CreateOptions) (result *v1alpha1.MyResourceSet, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(myresourcesetsResource, myResourceSet), &v1alpha1.MyResourceSet{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceSet), err
+}
+
+// Update takes the representation of a myResourceSet and updates it. Returns the server's representation of the myResourceSet, and an error, if there is any.
+func (c *FakeMyResourceSets) Update(ctx context.Context, myResourceSet *v1alpha1.MyResourceSet, opts v1.UpdateOptions) (result *v1alpha1.MyResourceSet, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(myresourcesetsResource, myResourceSet), &v1alpha1.MyResourceSet{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceSet), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeMyResourceSets) UpdateStatus(ctx context.Context, myResourceSet *v1alpha1.MyResourceSet, opts v1.UpdateOptions) (*v1alpha1.MyResourceSet, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateSubresourceAction(myresourcesetsResource, "status", myResourceSet), &v1alpha1.MyResourceSet{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceSet), err
+}
+
+// Delete takes name of the myResourceSet and deletes it. Returns an error if one occurs.
+func (c *FakeMyResourceSets) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(myresourcesetsResource, name), &v1alpha1.MyResourceSet{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeMyResourceSets) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(myresourcesetsResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.MyResourceSetList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched myResourceSet.
+func (c *FakeMyResourceSets) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.MyResourceSet, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(myresourcesetsResource, name, pt, data, subresources...), &v1alpha1.MyResourceSet{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MyResourceSet), err
+}
@@ -16,6 +16,18 @@ func (c *FakeSamplecontrollerV1alpha1) MyResources(namespace string) v1alpha1.My
 	return &FakeMyResources{c, namespace}
 }
 
+func (c *FakeSamplecontrollerV1alpha1) MyResourceClasses() v1alpha1.MyResourceClassInterface {
+	return &FakeMyResourceClasses{c}
+}
+
+func (c *FakeSamplecontrollerV1alpha1) MyResourceQuotas(namespace string) v1alpha1.MyResourceQuotaInterface {
+	return &FakeMyResourceQuotas{c, namespace}
+}
+
+func (c *FakeSamplecontrollerV1alpha1) MyResourceSets() v1alpha1.MyResourceSetInterface {
+	return &FakeMyResourceSets{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeSamplecontrollerV1alpha1) RESTClient() rest.Interface {
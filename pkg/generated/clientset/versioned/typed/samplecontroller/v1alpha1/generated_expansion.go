@@ -2,4 +2,8 @@
 
 package v1alpha1
 
-type MyResourceExpansion interface{}
+type MyResourceClassExpansion interface{}
+
+type MyResourceQuotaExpansion interface{}
+
+type MyResourceSetExpansion interface{}
@@ -0,0 +1,34 @@
+package v1alpha1
+
+import (
+	"context"
+	"strconv"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The MyResourceExpansion interface allows manually adding extra methods to the MyResourceInterface.
+type MyResourceExpansion interface {
+	Rollback(ctx context.Context, name string, toRevision int64, opts v1.UpdateOptions) (*v1alpha1.MyResource, error)
+}
+
+// Rollback asks the controller to roll the spec of the named MyResource back to the recorded revision toRevision.
+// A toRevision of 0 rolls back to the revision before the current one.
+func (c *myResources) Rollback(ctx context.Context, name string, toRevision int64, opts v1.UpdateOptions) (*v1alpha1.MyResource, error) {
+	myResource, err := c.Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	SetRollbackTo(myResource, toRevision)
+	return c.Update(ctx, myResource, opts)
+}
+
+// SetRollbackTo sets the annotation which makes the controller roll myResource back to toRevision.
+func SetRollbackTo(myResource *v1alpha1.MyResource, toRevision int64) {
+	if myResource.Annotations == nil {
+		myResource.Annotations = map[string]string{}
+	}
+	myResource.Annotations[v1alpha1.RollbackToAnnotation] = strconv.FormatInt(toRevision, 10)
+}
@@ -0,0 +1,152 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	scheme "github.com/reshnm/k8s-sample-controller-crd/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// MyResourceClassesGetter has a method to return a MyResourceClassInterface.
+// A group's client should implement this interface.
+type MyResourceClassesGetter interface {
+	MyResourceClasses() MyResourceClassInterface
+}
+
+// MyResourceClassInterface has methods to work with MyResourceClass resources.
+type MyResourceClassInterface interface {
+	Create(ctx context.Context, myResourceClass *v1alpha1.MyResourceClass, opts v1.CreateOptions) (*v1alpha1.MyResourceClass, error)
+	Update(ctx context.Context, myResourceClass *v1alpha1.MyResourceClass, opts v1.UpdateOptions) (*v1alpha1.MyResourceClass, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.MyResourceClass, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.MyResourceClassList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.MyResourceClass, err error)
+	MyResourceClassExpansion
+}
+
+// myResourceClasses implements MyResourceClassInterface
+type myResourceClasses struct {
+	client rest.Interface
+}
+
+// newMyResourceClasses returns a MyResourceClasses
+func newMyResourceClasses(c *SamplecontrollerV1alpha1Client) *myResourceClasses {
+	return &myResourceClasses{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the myResourceClass, and returns the corresponding myResourceClass object, and an error if there is any.
+func (c *myResourceClasses) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.MyResourceClass, err error) {
+	result = &v1alpha1.MyResourceClass{}
+	err = c.client.Get().
+		Resource("myresourceclasses").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of MyResourceClasses that match those selectors.
+func (c *myResourceClasses) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.MyResourceClassList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.MyResourceClassList{}
+	err = c.client.Get().
+		Resource("myresourceclasses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested myResourceClasses.
+func (c *myResourceClasses) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("myresourceclasses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a myResourceClass and creates it.  Returns the server's representation of the myResourceClass, and an error, if there is any.
+func (c *myResourceClasses) Create(ctx context.Context, myResourceClass *v1alpha1.MyResourceClass, opts v1.CreateOptions) (result *v1alpha1.MyResourceClass, err error) {
+	result = &v1alpha1.MyResourceClass{}
+	err = c.client.Post().
+		Resource("myresourceclasses").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(myResourceClass).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a myResourceClass and updates it. Returns the server's representation of the myResourceClass, and an error, if there is any.
+func (c *myResourceClasses) Update(ctx context.Context, myResourceClass *v1alpha1.MyResourceClass, opts v1.UpdateOptions) (result *v1alpha1.MyResourceClass, err error) {
+	result = &v1alpha1.MyResourceClass{}
+	err = c.client.Put().
+		Resource("myresourceclasses").
+		Name(myResourceClass.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(myResourceClass).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the myResourceClass and deletes it. Returns an error if one occurs.
+func (c *myResourceClasses) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("myresourceclasses").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *myResourceClasses) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("myresourceclasses").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched myResourceClass.
+func (c *myResourceClasses) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.MyResourceClass, err error) {
+	result = &v1alpha1.MyResourceClass{}
+	err = c.client.Patch(pt).
+		Resource("myresourceclasses").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
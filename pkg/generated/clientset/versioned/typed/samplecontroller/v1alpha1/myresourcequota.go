@@ -0,0 +1,179 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	scheme "github.com/reshnm/k8s-sample-controller-crd/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// MyResourceQuotasGetter has a method to return a MyResourceQuotaInterface.
+// A group's client should implement this interface.
+type MyResourceQuotasGetter interface {
+	MyResourceQuotas(namespace string) MyResourceQuotaInterface
+}
+
+// MyResourceQuotaInterface has methods to work with MyResourceQuota resources.
+type MyResourceQuotaInterface interface {
+	Create(ctx context.Context, myResourceQuota *v1alpha1.MyResourceQuota, opts v1.CreateOptions) (*v1alpha1.MyResourceQuota, error)
+	Update(ctx context.Context, myResourceQuota *v1alpha1.MyResourceQuota, opts v1.UpdateOptions) (*v1alpha1.MyResourceQuota, error)
+	UpdateStatus(ctx context.Context, myResourceQuota *v1alpha1.MyResourceQuota, opts v1.UpdateOptions) (*v1alpha1.MyResourceQuota, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.MyResourceQuota, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.MyResourceQuotaList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.MyResourceQuota, err error)
+	MyResourceQuotaExpansion
+}
+
+// myResourceQuotas implements MyResourceQuotaInterface
+type myResourceQuotas struct {
+	client rest.Interface
+	ns     string
+}
+
+// newMyResourceQuotas returns a MyResourceQuotas
+func newMyResourceQuotas(c *SamplecontrollerV1alpha1Client, namespace string) *myResourceQuotas {
+	return &myResourceQuotas{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the myResourceQuota, and returns the corresponding myResourceQuota object, and an error if there is any.
+func (c *myResourceQuotas) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.MyResourceQuota, err error) {
+	result = &v1alpha1.MyResourceQuota{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("myresourcequotas").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of MyResourceQuotas that match those selectors.
+func (c *myResourceQuotas) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.MyResourceQuotaList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.MyResourceQuotaList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("myresourcequotas").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested myResourceQuotas.
+func (c *myResourceQuotas) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("myresourcequotas").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a myResourceQuota and creates it.  Returns the server's representation of the myResourceQuota, and an error, if there is any.
+func (c *myResourceQuotas) Create(ctx context.Context, myResourceQuota *v1alpha1.MyResourceQuota, opts v1.CreateOptions) (result *v1alpha1.MyResourceQuota, err error) {
+	result = &v1alpha1.MyResourceQuota{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("myresourcequotas").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(myResourceQuota).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a myResourceQuota and updates it. Returns the server's representation of the myResourceQuota, and an error, if there is any.
+func (c *myResourceQuotas) Update(ctx context.Context, myResourceQuota *v1alpha1.MyResourceQuota, opts v1.UpdateOptions) (result *v1alpha1.MyResourceQuota, err error) {
+	result = &v1alpha1.MyResourceQuota{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("myresourcequotas").
+		Name(myResourceQuota.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(myResourceQuota).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *myResourceQuotas) UpdateStatus(ctx context.Context, myResourceQuota *v1alpha1.MyResourceQuota, opts v1.UpdateOptions) (result *v1alpha1.MyResourceQuota, err error) {
+	result = &v1alpha1.MyResourceQuota{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("myresourcequotas").
+		Name(myResourceQuota.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(myResourceQuota).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the myResourceQuota and deletes it. Returns an error if one occurs.
+func (c *myResourceQuotas) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("myresourcequotas").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *myResourceQuotas) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("myresourcequotas").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched myResourceQuota.
+func (c *myResourceQuotas) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.MyResourceQuota, err error) {
+	result = &v1alpha1.MyResourceQuota{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("myresourcequotas").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -0,0 +1,168 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	scheme "github.com/reshnm/k8s-sample-controller-crd/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// MyResourceSetsGetter has a method to return a MyResourceSetInterface.
+// A group's client should implement this interface.
+type MyResourceSetsGetter interface {
+	MyResourceSets() MyResourceSetInterface
+}
+
+// MyResourceSetInterface has methods to work with MyResourceSet resources.
+type MyResourceSetInterface interface {
+	Create(ctx context.Context, myResourceSet *v1alpha1.MyResourceSet, opts v1.CreateOptions) (*v1alpha1.MyResourceSet, error)
+	Update(ctx context.Context, myResourceSet *v1alpha1.MyResourceSet, opts v1.UpdateOptions) (*v1alpha1.MyResourceSet, error)
+	UpdateStatus(ctx context.Context, myResourceSet *v1alpha1.MyResourceSet, opts v1.UpdateOptions) (*v1alpha1.MyResourceSet, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.MyResourceSet, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.MyResourceSetList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.MyResourceSet, err error)
+	MyResourceSetExpansion
+}
+
+// myResourceSets implements MyResourceSetInterface
+type myResourceSets struct {
+	client rest.Interface
+}
+
+// newMyResourceSets returns a MyResourceSets
+func newMyResourceSets(c *SamplecontrollerV1alpha1Client) *myResourceSets {
+	return &myResourceSets{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the myResourceSet, and returns the corresponding myResourceSet object, and an error if there is any.
+func (c *myResourceSets) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.MyResourceSet, err error) {
+	result = &v1alpha1.MyResourceSet{}
+	err = c.client.Get().
+		Resource("myresourcesets").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of MyResourceSets that match those selectors.
+func (c *myResourceSets) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.MyResourceSetList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.MyResourceSetList{}
+	err = c.client.Get().
+		Resource("myresourcesets").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested myResourceSets.
+func (c *myResourceSets) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("myresourcesets").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a myResourceSet and creates it.  Returns the server's representation of the myResourceSet, and an error, if there is any.
+func (c *myResourceSets) Create(ctx context.Context, myResourceSet *v1alpha1.MyResourceSet, opts v1.CreateOptions) (result *v1alpha1.MyResourceSet, err error) {
+	result = &v1alpha1.MyResourceSet{}
+	err = c.client.Post().
+		Resource("myresourcesets").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(myResourceSet).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a myResourceSet and updates it. Returns the server's representation of the myResourceSet, and an error, if there is any.
+func (c *myResourceSets) Update(ctx context.Context, myResourceSet *v1alpha1.MyResourceSet, opts v1.UpdateOptions) (result *v1alpha1.MyResourceSet, err error) {
+	result = &v1alpha1.MyResourceSet{}
+	err = c.client.Put().
+		Resource("myresourcesets").
+		Name(myResourceSet.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(myResourceSet).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *myResourceSets) UpdateStatus(ctx context.Context, myResourceSet *v1alpha1.MyResourceSet, opts v1.UpdateOptions) (result *v1alpha1.MyResourceSet, err error) {
+	result = &v1alpha1.MyResourceSet{}
+	err = c.client.Put().
+		Resource("myresourcesets").
+		Name(myResourceSet.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(myResourceSet).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the myResourceSet and deletes it. Returns an error if one occurs.
+func (c *myResourceSets) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("myresourcesets").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *myResourceSets) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("myresourcesets").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched myResourceSet.
+func (c *myResourceSets) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.MyResourceSet, err error) {
+	result = &v1alpha1.MyResourceSet{}
+	err = c.client.Patch(pt).
+		Resource("myresourcesets").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
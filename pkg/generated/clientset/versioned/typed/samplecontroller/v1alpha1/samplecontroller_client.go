@@ -11,6 +11,9 @@ import (
 type SamplecontrollerV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	MyResourcesGetter
+	MyResourceClassesGetter
+	MyResourceQuotasGetter
+	MyResourceSetsGetter
 }
 
 // SamplecontrollerV1alpha1Client is used to interact with features provided by the samplecontroller.reshnm.de group.
@@ -22,6 +25,18 @@ func (c *SamplecontrollerV1alpha1Client) MyResources(namespace string) MyResourc
 	return newMyResources(c, namespace)
 }
 
+func (c *SamplecontrollerV1alpha1Client) MyResourceClasses() MyResourceClassInterface {
+	return newMyResourceClasses(c)
+}
+
+func (c *SamplecontrollerV1alpha1Client) MyResourceQuotas(namespace string) MyResourceQuotaInterface {
+	return newMyResourceQuotas(c, namespace)
+}
+
+func (c *SamplecontrollerV1alpha1Client) MyResourceSets() MyResourceSetInterface {
+	return newMyResourceSets(c)
+}
+
 // NewForConfig creates a new SamplecontrollerV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*SamplecontrollerV1alpha1Client, error) {
 	config := *c
@@ -39,6 +39,12 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=samplecontroller.reshnm.de, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("myresources"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Samplecontroller().V1alpha1().MyResources().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("myresourceclasses"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Samplecontroller().V1alpha1().MyResourceClasses().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("myresourcequotas"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Samplecontroller().V1alpha1().MyResourceQuotas().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("myresourcesets"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Samplecontroller().V1alpha1().MyResourceSets().Informer()}, nil
 
 	}
 
@@ -10,6 +10,12 @@ import (
 type Interface interface {
 	// MyResources returns a MyResourceInformer.
 	MyResources() MyResourceInformer
+	// MyResourceClasses returns a MyResourceClassInformer.
+	MyResourceClasses() MyResourceClassInformer
+	// MyResourceQuotas returns a MyResourceQuotaInformer.
+	MyResourceQuotas() MyResourceQuotaInformer
+	// MyResourceSets returns a MyResourceSetInformer.
+	MyResourceSets() MyResourceSetInformer
 }
 
 type version struct {
@@ -27,3 +33,18 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) MyResources() MyResourceInformer {
 	return &myResourceInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// MyResourceClasses returns a MyResourceClassInformer.
+func (v *version) MyResourceClasses() MyResourceClassInformer {
+	return &myResourceClassInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// MyResourceQuotas returns a MyResourceQuotaInformer.
+func (v *version) MyResourceQuotas() MyResourceQuotaInformer {
+	return &myResourceQuotaInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// MyResourceSets returns a MyResourceSetInformer.
+func (v *version) MyResourceSets() MyResourceSetInformer {
+	return &myResourceSetInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
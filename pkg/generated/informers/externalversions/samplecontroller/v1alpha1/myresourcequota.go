@@ -0,0 +1,74 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	samplecontrollerv1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	versioned "github.com/reshnm/k8s-sample-controller-crd/pkg/generated/clientset/versioned"
+	internalinterfaces "github.com/reshnm/k8s-sample-controller-crd/pkg/generated/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/generated/listers/samplecontroller/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// MyResourceQuotaInformer provides access to a shared informer and lister for
+// MyResourceQuotas.
+type MyResourceQuotaInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.MyResourceQuotaLister
+}
+
+type myResourceQuotaInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewMyResourceQuotaInformer constructs a new informer for MyResourceQuota type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewMyResourceQuotaInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredMyResourceQuotaInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredMyResourceQuotaInformer constructs a new informer for MyResourceQuota type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredMyResourceQuotaInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.SamplecontrollerV1alpha1().MyResourceQuotas(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.SamplecontrollerV1alpha1().MyResourceQuotas(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&samplecontrollerv1alpha1.MyResourceQuota{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *myResourceQuotaInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredMyResourceQuotaInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *myResourceQuotaInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&samplecontrollerv1alpha1.MyResourceQuota{}, f.defaultInformer)
+}
+
+func (f *myResourceQuotaInformer) Lister() v1alpha1.MyResourceQuotaLister {
+	return v1alpha1.NewMyResourceQuotaLister(f.Informer().GetIndexer())
+}
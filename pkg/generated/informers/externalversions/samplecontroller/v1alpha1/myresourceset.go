@@ -0,0 +1,73 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	samplecontrollerv1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	versioned "github.com/reshnm/k8s-sample-controller-crd/pkg/generated/clientset/versioned"
+	internalinterfaces "github.com/reshnm/k8s-sample-controller-crd/pkg/generated/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/generated/listers/samplecontroller/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// MyResourceSetInformer provides access to a shared informer and lister for
+// MyResourceSets.
+type MyResourceSetInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.MyResourceSetLister
+}
+
+type myResourceSetInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewMyResourceSetInformer constructs a new informer for MyResourceSet type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewMyResourceSetInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredMyResourceSetInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredMyResourceSetInformer constructs a new informer for MyResourceSet type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredMyResourceSetInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.SamplecontrollerV1alpha1().MyResourceSets().List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.SamplecontrollerV1alpha1().MyResourceSets().Watch(context.TODO(), options)
+			},
+		},
+		&samplecontrollerv1alpha1.MyResourceSet{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *myResourceSetInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredMyResourceSetInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *myResourceSetInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&samplecontrollerv1alpha1.MyResourceSet{}, f.defaultInformer)
+}
+
+func (f *myResourceSetInformer) Lister() v1alpha1.MyResourceSetLister {
+	return v1alpha1.NewMyResourceSetLister(f.Informer().GetIndexer())
+}
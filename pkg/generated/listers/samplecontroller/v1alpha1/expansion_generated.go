@@ -9,3 +9,19 @@ type MyResourceListerExpansion interface{}
 // MyResourceNamespaceListerExpansion allows custom methods to be added to
 // MyResourceNamespaceLister.
 type MyResourceNamespaceListerExpansion interface{}
+
+// MyResourceClassListerExpansion allows custom methods to be added to
+// MyResourceClassLister.
+type MyResourceClassListerExpansion interface{}
+
+// MyResourceQuotaListerExpansion allows custom methods to be added to
+// MyResourceQuotaLister.
+type MyResourceQuotaListerExpansion interface{}
+
+// MyResourceQuotaNamespaceListerExpansion allows custom methods to be added to
+// MyResourceQuotaNamespaceLister.
+type MyResourceQuotaNamespaceListerExpansion interface{}
+
+// MyResourceSetListerExpansion allows custom methods to be added to
+// MyResourceSetLister.
+type MyResourceSetListerExpansion interface{}
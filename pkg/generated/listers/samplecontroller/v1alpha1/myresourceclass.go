@@ -0,0 +1,52 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// MyResourceClassLister helps list MyResourceClasses.
+// All objects returned here must be treated as read-only.
+type MyResourceClassLister interface {
+	// List lists all MyResourceClasses in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.MyResourceClass, err error)
+	// Get retrieves the MyResourceClass from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.MyResourceClass, error)
+	MyResourceClassListerExpansion
+}
+
+// myResourceClassLister implements the MyResourceClassLister interface.
+type myResourceClassLister struct {
+	indexer cache.Indexer
+}
+
+// NewMyResourceClassLister returns a new MyResourceClassLister.
+func NewMyResourceClassLister(indexer cache.Indexer) MyResourceClassLister {
+	return &myResourceClassLister{indexer: indexer}
+}
+
+// List lists all MyResourceClasses in the indexer.
+func (s *myResourceClassLister) List(selector labels.Selector) (ret []*v1alpha1.MyResourceClass, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.MyResourceClass))
+	})
+	return ret, err
+}
+
+// Get retrieves the MyResourceClass from the index for a given name.
+func (s *myResourceClassLister) Get(name string) (*v1alpha1.MyResourceClass, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("myresourceclass"), name)
+	}
+	return obj.(*v1alpha1.MyResourceClass), nil
+}
@@ -0,0 +1,83 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// MyResourceQuotaLister helps list MyResourceQuotas.
+// All objects returned here must be treated as read-only.
+type MyResourceQuotaLister interface {
+	// List lists all MyResourceQuotas in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.MyResourceQuota, err error)
+	// MyResourceQuotas returns an object that can list and get MyResourceQuotas.
+	MyResourceQuotas(namespace string) MyResourceQuotaNamespaceLister
+	MyResourceQuotaListerExpansion
+}
+
+// myResourceQuotaLister implements the MyResourceQuotaLister interface.
+type myResourceQuotaLister struct {
+	indexer cache.Indexer
+}
+
+// NewMyResourceQuotaLister returns a new MyResourceQuotaLister.
+func NewMyResourceQuotaLister(indexer cache.Indexer) MyResourceQuotaLister {
+	return &myResourceQuotaLister{indexer: indexer}
+}
+
+// List lists all MyResourceQuotas in the indexer.
+func (s *myResourceQuotaLister) List(selector labels.Selector) (ret []*v1alpha1.MyResourceQuota, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.MyResourceQuota))
+	})
+	return ret, err
+}
+
+// MyResourceQuotas returns an object that can list and get MyResourceQuotas.
+func (s *myResourceQuotaLister) MyResourceQuotas(namespace string) MyResourceQuotaNamespaceLister {
+	return myResourceQuotaNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// MyResourceQuotaNamespaceLister helps list and get MyResourceQuotas.
+// All objects returned here must be treated as read-only.
+type MyResourceQuotaNamespaceLister interface {
+	// List lists all MyResourceQuotas in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.MyResourceQuota, err error)
+	// Get retrieves the MyResourceQuota from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.MyResourceQuota, error)
+	MyResourceQuotaNamespaceListerExpansion
+}
+
+// myResourceQuotaNamespaceLister implements the MyResourceQuotaNamespaceLister
+// interface.
+type myResourceQuotaNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all MyResourceQuotas in the indexer for a given namespace.
+func (s myResourceQuotaNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.MyResourceQuota, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.MyResourceQuota))
+	})
+	return ret, err
+}
+
+// Get retrieves the MyResourceQuota from the indexer for a given namespace and name.
+func (s myResourceQuotaNamespaceLister) Get(name string) (*v1alpha1.MyResourceQuota, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("myresourcequota"), name)
+	}
+	return obj.(*v1alpha1.MyResourceQuota), nil
+}
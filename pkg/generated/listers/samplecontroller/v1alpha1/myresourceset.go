@@ -0,0 +1,52 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// MyResourceSetLister helps list MyResourceSets.
+// All objects returned here must be treated as read-only.
+type MyResourceSetLister interface {
+	// List lists all MyResourceSets in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.MyResourceSet, err error)
+	// Get retrieves the MyResourceSet from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.MyResourceSet, error)
+	MyResourceSetListerExpansion
+}
+
+// myResourceSetLister implements the MyResourceSetLister interface.
+type myResourceSetLister struct {
+	indexer cache.Indexer
+}
+
+// NewMyResourceSetLister returns a new MyResourceSetLister.
+func NewMyResourceSetLister(indexer cache.Indexer) MyResourceSetLister {
+	return &myResourceSetLister{indexer: indexer}
+}
+
+// List lists all MyResourceSets in the indexer.
+func (s *myResourceSetLister) List(selector labels.Selector) (ret []*v1alpha1.MyResourceSet, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.MyResourceSet))
+	})
+	return ret, err
+}
+
+// Get retrieves the MyResourceSet from the index for a given name.
+func (s *myResourceSetLister) Get(name string) (*v1alpha1.MyResourceSet, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("myresourceset"), name)
+	}
+	return obj.(*v1alpha1.MyResourceSet), nil
+}
@@ -0,0 +1,111 @@
+package imagepolicy
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+const (
+	// defaultRegistry is the registry of images whose name doesn't start with a registry host.
+	defaultRegistry = "docker.io"
+	// legacyDefaultRegistry is another name of defaultRegistry.
+	legacyDefaultRegistry = "index.docker.io"
+	// officialRepository holds the single-segment images of defaultRegistry, e.g. docker.io/library/nginx.
+	officialRepository = "library"
+	latestTag          = "latest"
+)
+
+// Policy restricts the images of the echo pods. The zero Policy allows every image.
+type Policy struct {
+	// AllowedRegistries are the registries, optionally followed by a repository prefix like ghcr.io/example,
+	// that images may be pulled from. All registries are allowed if it is empty.
+	AllowedRegistries []string
+	// RequireDigest requires images to be pinned by digest.
+	RequireDigest bool
+	// ForbidLatestTag forbids the latest tag, which images without tag and digest implicitly refer to.
+	ForbidLatestTag bool
+}
+
+func (p *Policy) AddFlags(flagSet *flag.FlagSet) {
+	flagSet.Func("image-policy-allowed-registries",
+		"comma separated registries, optionally with repository prefix, that images of echo pods may be pulled from, all if empty",
+		func(value string) error {
+			p.AllowedRegistries = nil
+			for _, registry := range strings.Split(value, ",") {
+				registry = strings.TrimSuffix(strings.TrimSpace(registry), "/")
+				if registry != "" {
+					p.AllowedRegistries = append(p.AllowedRegistries, registry)
+				}
+			}
+			return nil
+		})
+	flagSet.BoolVar(&p.RequireDigest, "image-policy-require-digest", p.RequireDigest,
+		"require images of echo pods to be pinned by digest")
+	flagSet.BoolVar(&p.ForbidLatestTag, "image-policy-forbid-latest", p.ForbidLatestTag,
+		"forbid the latest tag for images of echo pods, including images without tag")
+}
+
+// Evaluate returns the reasons why image violates the policy, nothing if it complies.
+func (p Policy) Evaluate(image string) []string {
+	ref := parse(image)
+
+	var violations []string
+	if len(p.AllowedRegistries) > 0 && !p.allowsRepository(ref.repository) {
+		violations = append(violations, fmt.Sprintf("image %q is not from an allowed registry", image))
+	}
+	if p.RequireDigest && ref.digest == "" {
+		violations = append(violations, fmt.Sprintf("image %q is not pinned by digest", image))
+	}
+	if p.ForbidLatestTag && (ref.tag == latestTag || (ref.tag == "" && ref.digest == "")) {
+		violations = append(violations, fmt.Sprintf("image %q uses the latest tag", image))
+	}
+	return violations
+}
+
+func (p Policy) allowsRepository(repository string) bool {
+	for _, allowed := range p.AllowedRegistries {
+		if allowed == legacyDefaultRegistry || strings.HasPrefix(allowed, legacyDefaultRegistry+"/") {
+			allowed = defaultRegistry + strings.TrimPrefix(allowed, legacyDefaultRegistry)
+		}
+		if repository == allowed || strings.HasPrefix(repository, allowed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// reference is an image reference split into its parts.
+type reference struct {
+	// repository is the fully qualified repository including the registry host, e.g. docker.io/reshnm/echoserver.
+	repository string
+	tag        string
+	digest     string
+}
+
+// parse splits image into repository, tag and digest. Names without registry host refer to Docker Hub,
+// like the container runtimes resolve them: index.docker.io is docker.io, and single-segment names
+// on Docker Hub are in its library repository.
+func parse(image string) reference {
+	ref := reference{}
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, ref.digest = name[:i], name[i+1:]
+	}
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.tag = name[:i], name[i+1:]
+	}
+
+	host, path := defaultRegistry, name
+	if i := strings.Index(name, "/"); i >= 0 && (strings.ContainsAny(name[:i], ".:") || name[:i] == "localhost") {
+		host, path = name[:i], name[i+1:]
+	}
+	if host == legacyDefaultRegistry {
+		host = defaultRegistry
+	}
+	if host == defaultRegistry && !strings.Contains(path, "/") {
+		path = officialRepository + "/" + path
+	}
+	ref.repository = host + "/" + path
+	return ref
+}
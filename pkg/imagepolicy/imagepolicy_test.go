@@ -0,0 +1,105 @@
+package imagepolicy
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+const (
+	testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected reference
+	}{
+		{"echoserver", reference{repository: "docker.io/library/echoserver"}},
+		{"docker.io/echoserver:v1", reference{repository: "docker.io/library/echoserver", tag: "v1"}},
+		{"index.docker.io/echoserver", reference{repository: "docker.io/library/echoserver"}},
+		{"index.docker.io/reshnm/echoserver", reference{repository: "docker.io/reshnm/echoserver"}},
+		{"reshnm/echoserver:latest", reference{repository: "docker.io/reshnm/echoserver", tag: "latest"}},
+		{"ghcr.io/example/echoserver:v1", reference{repository: "ghcr.io/example/echoserver", tag: "v1"}},
+		{"localhost:5000/echoserver", reference{repository: "localhost:5000/echoserver"}},
+		{"localhost/echoserver:v1", reference{repository: "localhost/echoserver", tag: "v1"}},
+		{"registry.example.com:5000/echoserver:v1@" + testDigest,
+			reference{repository: "registry.example.com:5000/echoserver", tag: "v1", digest: testDigest}},
+	}
+
+	for _, test := range tests {
+		ref := parse(test.image)
+		if ref != test.expected {
+			t.Errorf("parse(%q) = %+v, expected %+v", test.image, ref, test.expected)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	policy := Policy{
+		AllowedRegistries: []string{"ghcr.io/example", "registry.example.com"},
+		RequireDigest:     true,
+		ForbidLatestTag:   true,
+	}
+
+	tests := []struct {
+		image      string
+		violations int
+	}{
+		{"ghcr.io/example/echoserver@" + testDigest, 0},
+		{"registry.example.com/team/echoserver:v1@" + testDigest, 0},
+		{"ghcr.io/example/echoserver:v1", 1},
+		{"ghcr.io/example/echoserver:latest@" + testDigest, 1},
+		{"ghcr.io/examples/echoserver@" + testDigest, 1},
+		{"reshnm/echoserver:latest", 3},
+		{"reshnm/echoserver", 3},
+	}
+
+	for _, test := range tests {
+		violations := policy.Evaluate(test.image)
+		if len(violations) != test.violations {
+			t.Errorf("Evaluate(%q) = %v, expected %d violations", test.image, violations, test.violations)
+		}
+	}
+
+	if violations := (Policy{}).Evaluate("reshnm/echoserver:latest"); len(violations) > 0 {
+		t.Errorf("the zero policy reports %v", violations)
+	}
+}
+
+func TestEvaluateNormalizesDockerHub(t *testing.T) {
+	tests := []struct {
+		allowed string
+		image   string
+		allow   bool
+	}{
+		{"docker.io/library", "nginx", true},
+		{"docker.io/library", "index.docker.io/nginx", true},
+		{"docker.io/library", "reshnm/echoserver", false},
+		{"index.docker.io/reshnm", "reshnm/echoserver", true},
+		{"docker.io/nginx", "nginx", false},
+	}
+
+	for _, test := range tests {
+		violations := Policy{AllowedRegistries: []string{test.allowed}}.Evaluate(test.image)
+		if allowed := len(violations) == 0; allowed != test.allow {
+			t.Errorf("registry %q allows image %q: %t, expected %t", test.allowed, test.image, allowed, test.allow)
+		}
+	}
+}
+
+func TestAddFlags(t *testing.T) {
+	policy := Policy{}
+	flagSet := flag.NewFlagSet("test", flag.ContinueOnError)
+	policy.AddFlags(flagSet)
+
+	err := flagSet.Parse([]string{"--image-policy-allowed-registries", "ghcr.io/example/, docker.io,", "--image-policy-forbid-latest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Policy{AllowedRegistries: []string{"ghcr.io/example", "docker.io"}, ForbidLatestTag: true}
+	if !reflect.DeepEqual(policy, expected) {
+		t.Errorf("parsed %+v, expected %+v", policy, expected)
+	}
+}
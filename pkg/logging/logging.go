@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/go-logr/logr"
+	"go.uber.org/zap/zapcore"
+	"io"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/klog/v2"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// FormatText writes human readable log lines.
+	FormatText = "text"
+	// FormatJSON writes one JSON object per log line.
+	FormatJSON = "json"
+)
+
+const (
+	// KeyReconcileID identifies all log lines of a single reconcile.
+	KeyReconcileID = "reconcileID"
+	// KeyGeneration is the generation of the reconciled object.
+	KeyGeneration = "generation"
+	// KeyMyResource is the namespace/name of a MyResource.
+	KeyMyResource = "myresource"
+	// KeyMyResourceSet is the name of a MyResourceSet.
+	KeyMyResourceSet = "myresourceset"
+	// KeyMyResourceQuota is the namespace/name of a MyResourceQuota.
+	KeyMyResourceQuota = "myresourcequota"
+	// KeyPod is the namespace/name of a pod.
+	KeyPod = "pod"
+)
+
+// Options configures the format and verbosity of the logs.
+type Options struct {
+	// Format is FormatText or FormatJSON.
+	Format string
+	// Verbosity enables the log lines of up to this V level.
+	Verbosity int
+}
+
+// DefaultOptions returns the Options used when no flags are given.
+func DefaultOptions() Options {
+	return Options{
+		Format: FormatText,
+	}
+}
+
+func (o *Options) AddFlags(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&o.Format, "log-format", o.Format,
+		fmt.Sprintf("format of the log lines, %q or %q", FormatText, FormatJSON))
+	flagSet.IntVar(&o.Verbosity, "v", o.Verbosity, "number for the log level verbosity")
+}
+
+// New returns a logger writing to out according to options.
+func New(out io.Writer, options Options) (logr.Logger, error) {
+	zapOptions := []zap.Opts{
+		zap.WriteTo(out),
+		zap.Level(zapcore.Level(-options.Verbosity)),
+	}
+	switch options.Format {
+	case FormatText, "":
+		zapOptions = append(zapOptions, zap.ConsoleEncoder())
+	case FormatJSON:
+		zapOptions = append(zapOptions, zap.JSONEncoder())
+	default:
+		return nil, fmt.Errorf("unknown log format %q", options.Format)
+	}
+	return zap.New(zapOptions...), nil
+}
+
+// Setup makes the logger configured by options the logger of controller-runtime and of klog,
+// which is used by client-go.
+func Setup(options Options) error {
+	logger, err := New(os.Stderr, options)
+	if err != nil {
+		return err
+	}
+
+	log.SetLogger(logger)
+	klog.SetLogger(logger)
+	return nil
+}
+
+// WrapReconciler returns a reconciler that adds the reconciled object as key and a unique reconcile ID
+// to the logger of every reconcile of reconciler.
+func WrapReconciler(key string, reconciler reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		logger := log.FromContext(ctx).WithValues(key, Ref(req.Namespace, req.Name), KeyReconcileID, string(uuid.NewUUID()))
+		return reconciler.Reconcile(log.IntoContext(ctx, logger), req)
+	})
+}
+
+// WithGeneration adds the generation of obj to the logger of ctx.
+func WithGeneration(ctx context.Context, obj client.Object) (context.Context, logr.Logger) {
+	logger := log.FromContext(ctx).WithValues(KeyGeneration, obj.GetGeneration())
+	return log.IntoContext(ctx, logger), logger
+}
+
+// Ref returns namespace/name, or name for cluster scoped objects.
+func Ref(namespace string, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// RefOf returns the Ref of obj.
+func RefOf(obj client.Object) string {
+	return Ref(obj.GetNamespace(), obj.GetName())
+}
@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+)
+
+func TestJSONReconcileLog(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger, err := New(out, Options{Format: FormatJSON, Verbosity: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reconciler := WrapReconciler(KeyMyResource, reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		log.FromContext(ctx).V(1).Info("reconciling MyResource")
+		return reconcile.Result{}, nil
+	}))
+	ctx := log.IntoContext(context.Background(), logger)
+	for i := 0; i < 2; i++ {
+		_, _ = reconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "echo"}})
+	}
+
+	decoder := json.NewDecoder(out)
+	reconcileIDs := map[interface{}]bool{}
+	for decoder.More() {
+		line := map[string]interface{}{}
+		err := decoder.Decode(&line)
+		if err != nil {
+			t.Fatalf("log line isn't JSON: %v", err)
+		}
+		if line["msg"] != "reconciling MyResource" || line[KeyMyResource] != "default/echo" {
+			t.Errorf("unexpected log line %v", line)
+		}
+		reconcileIDs[line[KeyReconcileID]] = true
+	}
+	if len(reconcileIDs) != 2 {
+		t.Errorf("logged reconcile IDs %v, expected one per reconcile", reconcileIDs)
+	}
+}
+
+func TestVerbosity(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger, err := New(out, Options{Format: FormatText})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.V(1).Info("debug")
+	if out.Len() != 0 {
+		t.Errorf("V(1) line was logged at verbosity 0: %q", out.String())
+	}
+	logger.Info("info")
+	if !bytes.Contains(out.Bytes(), []byte("info")) {
+		t.Errorf("info line wasn't logged")
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	_, err := New(&bytes.Buffer{}, Options{Format: "xml"})
+	if err == nil {
+		t.Errorf("unknown format was accepted")
+	}
+}
@@ -0,0 +1,138 @@
+package spoke
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/connrotation"
+	"net"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// LabelSpoke marks the Secrets that register spoke clusters when set to "true".
+	LabelSpoke = "samplecontroller.reshnm.de/spoke"
+	// KubeconfigKey is the key of the kubeconfig in a spoke Secret.
+	KubeconfigKey = "kubeconfig"
+)
+
+// Clients returns clients for spoke clusters by name.
+type Clients interface {
+	Client(ctx context.Context, name string) (client.Client, error)
+}
+
+// StaticClients serves a fixed set of spoke clusters, e.g. local test API servers.
+type StaticClients map[string]client.Client
+
+func (s StaticClients) Client(_ context.Context, name string) (client.Client, error) {
+	spokeClient, ok := s[name]
+	if !ok {
+		return nil, fmt.Errorf("spoke cluster %q is not registered", name)
+	}
+	return spokeClient, nil
+}
+
+// SecretClients creates clients for the spoke clusters registered by kubeconfig Secrets in a namespace
+// of the hub cluster. The name of a Secret is the name of its cluster. Clients are cached by cluster until
+// their Secret changes or is deleted, the connections of a replaced client are closed.
+type SecretClients struct {
+	hub       client.Client
+	namespace string
+
+	mutex   sync.Mutex
+	clients map[string]cachedClient
+}
+
+type cachedClient struct {
+	resourceVersion string
+	client          client.Client
+	// dialer tracks the connections of client, each client gets its own transport
+	dialer *connrotation.Dialer
+}
+
+func NewSecretClients(hub client.Client, namespace string) *SecretClients {
+	return &SecretClients{
+		hub:       hub,
+		namespace: namespace,
+		clients:   map[string]cachedClient{},
+	}
+}
+
+// Namespace returns the namespace of the spoke Secrets.
+func (s *SecretClients) Namespace() string {
+	return s.namespace
+}
+
+func (s *SecretClients) Client(ctx context.Context, name string) (client.Client, error) {
+	secret := &corev1.Secret{}
+	err := s.hub.Get(ctx, types.NamespacedName{Name: name, Namespace: s.namespace}, secret)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			s.Forget(name)
+			return nil, fmt.Errorf("spoke cluster %q is not registered", name)
+		}
+		return nil, fmt.Errorf("failed to get Secret of spoke cluster %q: %w", name, err)
+	}
+	if !IsSpokeSecret(secret) {
+		s.Forget(name)
+		return nil, fmt.Errorf("Secret %s/%s is not labeled %s=true", s.namespace, name, LabelSpoke)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	cached, ok := s.clients[name]
+	if ok && cached.resourceVersion == secret.ResourceVersion {
+		return cached.client, nil
+	}
+	if ok {
+		s.forget(name)
+	}
+
+	config, err := clientcmd.RESTConfigFromKubeConfig(secret.Data[KubeconfigKey])
+	if err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig of spoke cluster %q: %w", name, err)
+	}
+	// client-go shares transports with equal TLS configs forever, a custom dialer gets a transport of its own
+	dialer := connrotation.NewDialer((&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext)
+	config.Dial = dialer.DialContext
+	spokeClient, err := client.New(config, client.Options{Scheme: s.hub.Scheme()})
+	if err != nil {
+		dialer.CloseAll()
+		return nil, fmt.Errorf("failed to create client for spoke cluster %q: %w", name, err)
+	}
+
+	log.FromContext(ctx).Info("connected to spoke cluster", "cluster", name, "host", config.Host)
+	s.clients[name] = cachedClient{resourceVersion: secret.ResourceVersion, client: spokeClient, dialer: dialer}
+	return spokeClient, nil
+}
+
+// Forget drops the cached client of the spoke cluster called name and closes its connections,
+// e.g. because its Secret was deleted.
+func (s *SecretClients) Forget(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.forget(name)
+}
+
+func (s *SecretClients) forget(name string) {
+	cached, ok := s.clients[name]
+	if !ok {
+		return
+	}
+	cached.dialer.CloseAll()
+	delete(s.clients, name)
+}
+
+// IsSpokeSecret reports whether secret registers a spoke cluster.
+func IsSpokeSecret(secret *corev1.Secret) bool {
+	isSpoke, _ := strconv.ParseBool(secret.Labels[LabelSpoke])
+	return isSpoke
+}
@@ -0,0 +1,152 @@
+package spoke
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+const testNamespace = "spokes"
+
+// newSpokeServer returns an API server that serves just enough discovery to create a client for it.
+func newSpokeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	serve := func(path string, body interface{}) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(body)
+		})
+	}
+	serve("/api", &metav1.APIVersions{Versions: []string{"v1"}})
+	serve("/apis", &metav1.APIGroupList{})
+	serve("/api/v1", &metav1.APIResourceList{GroupVersion: "v1"})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newSpokeSecret returns a Secret that registers the spoke cluster called name served by server.
+func newSpokeSecret(name string, server *httptest.Server) *corev1.Secret {
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+clusters:
+- name: %[1]s
+  cluster:
+    server: %[2]s
+contexts:
+- name: %[1]s
+  context:
+    cluster: %[1]s
+current-context: %[1]s
+`, name, server.URL)
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: testNamespace,
+			Name:      name,
+			Labels:    map[string]string{LabelSpoke: "true"},
+		},
+		Data: map[string][]byte{KubeconfigKey: []byte(kubeconfig)},
+	}
+}
+
+func newTestHub(t *testing.T, objs ...*corev1.Secret) *kittesting.Cluster {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	hub, err := kittesting.NewCluster(scheme)
+	if err != nil {
+		t.Fatalf("failed to create hub cluster: %v", err)
+	}
+	for _, obj := range objs {
+		if err := hub.Create(context.Background(), obj); err != nil {
+			t.Fatalf("failed to create Secret %q: %v", obj.Name, err)
+		}
+	}
+	return hub
+}
+
+func TestSecretClientsCachesClientUntilSecretChanges(t *testing.T) {
+	ctx := context.Background()
+	secret := newSpokeSecret("a", newSpokeServer(t))
+	hub := newTestHub(t, secret)
+	clients := NewSecretClients(hub, testNamespace)
+
+	first, err := clients.Client(ctx, "a")
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	cached, err := clients.Client(ctx, "a")
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	if cached != first {
+		t.Errorf("client wasn't cached while the Secret didn't change")
+	}
+
+	secret.Data[KubeconfigKey] = newSpokeSecret("a", newSpokeServer(t)).Data[KubeconfigKey]
+	if err := hub.Update(ctx, secret); err != nil {
+		t.Fatalf("failed to update Secret: %v", err)
+	}
+	replaced, err := clients.Client(ctx, "a")
+	if err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+	if replaced == first {
+		t.Errorf("client wasn't replaced after the Secret changed")
+	}
+	if len(clients.clients) != 1 {
+		t.Errorf("%d clients are cached, expected just the replacement", len(clients.clients))
+	}
+}
+
+func TestSecretClientsDropsClientOfDeletedSecret(t *testing.T) {
+	ctx := context.Background()
+	secret := newSpokeSecret("a", newSpokeServer(t))
+	hub := newTestHub(t, secret)
+	clients := NewSecretClients(hub, testNamespace)
+	if _, err := clients.Client(ctx, "a"); err != nil {
+		t.Fatalf("failed to get client: %v", err)
+	}
+
+	if err := hub.Delete(ctx, secret); err != nil {
+		t.Fatalf("failed to delete Secret: %v", err)
+	}
+	if _, err := clients.Client(ctx, "a"); err == nil {
+		t.Errorf("got a client for a spoke cluster whose Secret was deleted")
+	}
+	if len(clients.clients) != 0 {
+		t.Errorf("client of the deleted Secret is still cached")
+	}
+}
+
+func TestSecretClientsForget(t *testing.T) {
+	ctx := context.Background()
+	hub := newTestHub(t, newSpokeSecret("a", newSpokeServer(t)), newSpokeSecret("b", newSpokeServer(t)))
+	clients := NewSecretClients(hub, testNamespace)
+	for _, name := range []string{"a", "b"} {
+		if _, err := clients.Client(ctx, name); err != nil {
+			t.Fatalf("failed to get client of spoke cluster %q: %v", name, err)
+		}
+	}
+
+	clients.Forget("a")
+	clients.Forget("unknown")
+
+	if _, ok := clients.clients["a"]; ok {
+		t.Errorf("forgotten client is still cached")
+	}
+	if _, ok := clients.clients["b"]; !ok {
+		t.Errorf("client of another spoke cluster was dropped")
+	}
+}
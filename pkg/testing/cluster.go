@@ -0,0 +1,540 @@
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	jsonpatch "github.com/evanphx/json-patch"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sync"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+)
+
+// defaultStatusSubresources are the kinds whose status can only be written through the status subresource
+// unless a Cluster is told otherwise.
+var defaultStatusSubresources = []schema.GroupKind{
+	v1alpha1.SchemeGroupVersion.WithKind("MyResource").GroupKind(),
+	v1alpha1.SchemeGroupVersion.WithKind("MyResourceSet").GroupKind(),
+	corev1.SchemeGroupVersion.WithKind("Pod").GroupKind(),
+	corev1.SchemeGroupVersion.WithKind("Service").GroupKind(),
+	corev1.SchemeGroupVersion.WithKind("Namespace").GroupKind(),
+	appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind(),
+}
+
+// Cluster is a client.Client for an in-memory cluster that behaves like an API server where
+// controller-runtime's fake client doesn't:
+//
+//   - metadata.uid, metadata.creationTimestamp and metadata.generation are set on create and
+//     metadata.generateName is honored,
+//   - metadata.generation is bumped whenever anything but metadata and status changes,
+//   - the status of kinds with a status subresource is only written by Status().Update and Status().Patch,
+//     which in turn don't change anything else,
+//   - updates with an outdated resourceVersion and deletes with failing preconditions return conflicts,
+//   - writes with client.DryRunAll fail like the others but leave the stored objects alone,
+//   - objects with finalizers are only marked as deleted, and
+//   - dependents are garbage collected once all their owners are deleted, unless they are orphaned.
+type Cluster struct {
+	client            client.Client
+	scheme            *runtime.Scheme
+	statusSubresource map[schema.GroupKind]bool
+
+	mutex sync.Mutex
+	kinds map[schema.GroupVersionKind]bool
+}
+
+var _ client.Client = &Cluster{}
+
+// NewCluster returns a Cluster holding objs. The objects are stored as they are, including their status,
+// only missing UIDs are filled in.
+func NewCluster(scheme *runtime.Scheme, objs ...client.Object) (*Cluster, error) {
+	c := &Cluster{
+		scheme:            scheme,
+		statusSubresource: map[schema.GroupKind]bool{},
+		kinds:             map[schema.GroupVersionKind]bool{},
+	}
+	for _, groupKind := range defaultStatusSubresources {
+		c.statusSubresource[groupKind] = true
+	}
+
+	initObjs := make([]client.Object, 0, len(objs))
+	for _, obj := range objs {
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return nil, err
+		}
+		c.kinds[gvk] = true
+
+		obj = obj.DeepCopyObject().(client.Object)
+		if obj.GetUID() == "" {
+			obj.SetUID(uuid.NewUUID())
+		}
+		if obj.GetGeneration() == 0 {
+			obj.SetGeneration(1)
+		}
+		initObjs = append(initObjs, obj)
+	}
+
+	c.client = fake.NewClientBuilder().WithScheme(scheme).WithObjects(initObjs...).Build()
+	return c, nil
+}
+
+// SetStatusSubresource defines whether the status of the given kind is a subresource.
+func (c *Cluster) SetStatusSubresource(groupKind schema.GroupKind, enabled bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.statusSubresource[groupKind] = enabled
+}
+
+func (c *Cluster) Scheme() *runtime.Scheme {
+	return c.scheme
+}
+
+func (c *Cluster) RESTMapper() meta.RESTMapper {
+	return c.client.RESTMapper()
+}
+
+func (c *Cluster) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return c.client.Get(ctx, key, obj)
+}
+
+func (c *Cluster) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return c.client.List(ctx, list, opts...)
+}
+
+func (c *Cluster) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	c.kinds[gvk] = true
+
+	if obj.GetName() == "" && obj.GetGenerateName() != "" {
+		obj.SetName(obj.GetGenerateName() + rand.String(5))
+	}
+	if c.statusSubresource[gvk.GroupKind()] {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return err
+		}
+		delete(content, "status")
+		err = c.setContent(obj, gvk, content)
+		if err != nil {
+			return err
+		}
+	}
+	obj.SetUID(uuid.NewUUID())
+	obj.SetCreationTimestamp(metav1.Now())
+	obj.SetGeneration(1)
+	obj.SetDeletionTimestamp(nil)
+
+	createOptions := &client.CreateOptions{}
+	createOptions.ApplyOptions(opts)
+	if isDryRun(createOptions.DryRun) {
+		_, err := c.get(ctx, gvk, client.ObjectKeyFromObject(obj))
+		if err == nil {
+			return apierrors.NewAlreadyExists(resourceOf(gvk), obj.GetName())
+		}
+		return client.IgnoreNotFound(err)
+	}
+	return c.client.Create(ctx, obj, opts...)
+}
+
+func (c *Cluster) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.update(ctx, obj, false, opts...)
+}
+
+func (c *Cluster) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.patch(ctx, obj, patch, false, opts...)
+}
+
+func (c *Cluster) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	deleteOptions := &client.DeleteOptions{}
+	deleteOptions.ApplyOptions(opts)
+	return c.delete(ctx, obj, deleteOptions)
+}
+
+func (c *Cluster) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	deleteAllOfOptions := &client.DeleteAllOfOptions{}
+	deleteAllOfOptions.ApplyOptions(opts)
+
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	items, err := c.list(ctx, gvk, &deleteAllOfOptions.ListOptions)
+	if err != nil {
+		return err
+	}
+	if isDryRun(deleteAllOfOptions.DryRun) {
+		return nil
+	}
+	for _, item := range items {
+		err = c.delete(ctx, item, &client.DeleteOptions{PropagationPolicy: deleteAllOfOptions.PropagationPolicy})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) Status() client.StatusWriter {
+	return &statusWriter{cluster: c}
+}
+
+type statusWriter struct {
+	cluster *Cluster
+}
+
+func (w *statusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w.cluster.mutex.Lock()
+	defer w.cluster.mutex.Unlock()
+	return w.cluster.update(ctx, obj, true, opts...)
+}
+
+func (w *statusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w.cluster.mutex.Lock()
+	defer w.cluster.mutex.Unlock()
+	return w.cluster.patch(ctx, obj, patch, true, opts...)
+}
+
+// update writes obj like the API server does for an update of the object or of its status subresource.
+func (c *Cluster) update(ctx context.Context, obj client.Object, status bool, opts ...client.UpdateOption) error {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	hasStatus := c.statusSubresource[gvk.GroupKind()]
+	if status && !hasStatus {
+		return apierrors.NewNotFound(resourceOf(gvk), obj.GetName()+"/status")
+	}
+
+	stored, err := c.get(ctx, gvk, client.ObjectKeyFromObject(obj))
+	if err != nil {
+		return err
+	}
+	if obj.GetResourceVersion() != "" && obj.GetResourceVersion() != stored.GetResourceVersion() {
+		return conflict(gvk, obj.GetName())
+	}
+	updateOptions := &client.UpdateOptions{}
+	updateOptions.ApplyOptions(opts)
+	if isDryRun(updateOptions.DryRun) {
+		return nil
+	}
+
+	storedContent, err := runtime.DefaultUnstructuredConverter.ToUnstructured(stored)
+	if err != nil {
+		return err
+	}
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return err
+	}
+
+	var next map[string]interface{}
+	if status {
+		// only the status is taken from obj
+		next = runtime.DeepCopyJSON(storedContent)
+		setOrDelete(next, "status", content["status"])
+	} else {
+		next = content
+		if hasStatus {
+			setOrDelete(next, "status", storedContent["status"])
+		}
+		next["metadata"] = keepServerFields(content["metadata"], storedContent["metadata"])
+		if specChanged(storedContent, next) {
+			metadata := next["metadata"].(map[string]interface{})
+			metadata["generation"] = stored.GetGeneration() + 1
+		}
+	}
+
+	err = c.setContent(obj, gvk, next)
+	if err != nil {
+		return err
+	}
+	obj.SetResourceVersion(stored.GetResourceVersion())
+	err = c.client.Update(ctx, obj, opts...)
+	if err != nil {
+		return err
+	}
+
+	// the fake client deletes objects once their last finalizer is removed
+	if obj.GetDeletionTimestamp() != nil && len(obj.GetFinalizers()) == 0 {
+		return c.collectDependents(ctx, obj.GetUID(), metav1.DeletePropagationBackground)
+	}
+	return nil
+}
+
+// patch applies patch to the stored object and updates it, or its status, with the result.
+func (c *Cluster) patch(ctx context.Context, obj client.Object, patch client.Patch, status bool, opts ...client.PatchOption) error {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	stored, err := c.get(ctx, gvk, client.ObjectKeyFromObject(obj))
+	if err != nil {
+		return err
+	}
+
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	original, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	patched, err := applyPatch(original, patch.Type(), data, stored)
+	if err != nil {
+		return apierrors.NewBadRequest(fmt.Sprintf("failed to apply patch: %v", err))
+	}
+
+	result, err := c.scheme.New(gvk)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(patched, result)
+	if err != nil {
+		return apierrors.NewBadRequest(fmt.Sprintf("failed to decode patched object: %v", err))
+	}
+
+	var updateOptions []client.UpdateOption
+	patchOptions := &client.PatchOptions{}
+	patchOptions.ApplyOptions(opts)
+	if isDryRun(patchOptions.DryRun) {
+		updateOptions = append(updateOptions, client.DryRunAll)
+	}
+	err = c.update(ctx, result.(client.Object), status, updateOptions...)
+	if err != nil {
+		return err
+	}
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(result).Elem())
+	return nil
+}
+
+// delete deletes obj, or marks it as deleted if it has finalizers, and collects its dependents.
+func (c *Cluster) delete(ctx context.Context, obj client.Object, options *client.DeleteOptions) error {
+	gvk, err := apiutil.GVKForObject(obj, c.scheme)
+	if err != nil {
+		return err
+	}
+	stored, err := c.get(ctx, gvk, client.ObjectKeyFromObject(obj))
+	if err != nil {
+		return err
+	}
+
+	if preconditions := options.Preconditions; preconditions != nil {
+		if preconditions.UID != nil && *preconditions.UID != stored.GetUID() {
+			return conflict(gvk, obj.GetName())
+		}
+		if preconditions.ResourceVersion != nil && *preconditions.ResourceVersion != stored.GetResourceVersion() {
+			return conflict(gvk, obj.GetName())
+		}
+	}
+	if isDryRun(options.DryRun) {
+		return nil
+	}
+
+	if len(stored.GetFinalizers()) > 0 {
+		if stored.GetDeletionTimestamp() == nil {
+			now := metav1.Now()
+			stored.SetDeletionTimestamp(&now)
+			return c.client.Update(ctx, stored)
+		}
+		return nil
+	}
+
+	err = c.client.Delete(ctx, stored)
+	if err != nil {
+		return err
+	}
+
+	propagation := metav1.DeletePropagationBackground
+	if options.PropagationPolicy != nil {
+		propagation = *options.PropagationPolicy
+	}
+	return c.collectDependents(ctx, stored.GetUID(), propagation)
+}
+
+// collectDependents runs the garbage collector for the deleted owner with the given UID. Dependents that
+// have no other owner are deleted, otherwise the reference to the owner is removed. Orphaned dependents
+// only lose the reference.
+func (c *Cluster) collectDependents(ctx context.Context, owner types.UID, propagation metav1.DeletionPropagation) error {
+	for gvk := range c.kinds {
+		items, err := c.list(ctx, gvk, &client.ListOptions{})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			ownerRefs := item.GetOwnerReferences()
+			remaining := make([]metav1.OwnerReference, 0, len(ownerRefs))
+			for _, ownerRef := range ownerRefs {
+				if ownerRef.UID != owner {
+					remaining = append(remaining, ownerRef)
+				}
+			}
+			if len(remaining) == len(ownerRefs) {
+				continue
+			}
+
+			if len(remaining) == 0 && propagation != metav1.DeletePropagationOrphan {
+				err = c.delete(ctx, item, &client.DeleteOptions{})
+			} else {
+				item.SetOwnerReferences(remaining)
+				err = c.client.Update(ctx, item)
+			}
+			if err != nil && !apierrors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Cluster) get(ctx context.Context, gvk schema.GroupVersionKind, key client.ObjectKey) (client.Object, error) {
+	obj, err := c.scheme.New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	err = c.client.Get(ctx, key, obj.(client.Object))
+	if err != nil {
+		return nil, err
+	}
+	return obj.(client.Object), nil
+}
+
+func (c *Cluster) list(ctx context.Context, gvk schema.GroupVersionKind, options *client.ListOptions) ([]client.Object, error) {
+	obj, err := c.scheme.New(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err != nil {
+		return nil, err
+	}
+	list := obj.(client.ObjectList)
+	err = c.client.List(ctx, list, options)
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]client.Object, 0, len(objs))
+	for _, item := range objs {
+		items = append(items, item.(client.Object))
+	}
+	return items, nil
+}
+
+// setContent replaces obj by the object described by content.
+func (c *Cluster) setContent(obj client.Object, gvk schema.GroupVersionKind, content map[string]interface{}) error {
+	next, err := c.scheme.New(gvk)
+	if err != nil {
+		return err
+	}
+	err = runtime.DefaultUnstructuredConverter.FromUnstructured(content, next)
+	if err != nil {
+		return err
+	}
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(next).Elem())
+	return nil
+}
+
+// keepServerFields returns metadata with the fields the API server manages taken from stored.
+func keepServerFields(metadata interface{}, stored interface{}) map[string]interface{} {
+	result, _ := metadata.(map[string]interface{})
+	if result == nil {
+		result = map[string]interface{}{}
+	}
+	storedMetadata, _ := stored.(map[string]interface{})
+	for _, field := range []string{"uid", "creationTimestamp", "generation", "deletionTimestamp"} {
+		setOrDelete(result, field, storedMetadata[field])
+	}
+	return result
+}
+
+// specChanged reports whether anything but metadata and status differs between stored and next.
+func specChanged(stored map[string]interface{}, next map[string]interface{}) bool {
+	strip := func(content map[string]interface{}) map[string]interface{} {
+		stripped := map[string]interface{}{}
+		for key, value := range content {
+			if key != "metadata" && key != "status" && key != "apiVersion" && key != "kind" {
+				stripped[key] = value
+			}
+		}
+		return stripped
+	}
+	return !reflect.DeepEqual(strip(stored), strip(next))
+}
+
+func setOrDelete(content map[string]interface{}, key string, value interface{}) {
+	if value == nil {
+		delete(content, key)
+		return
+	}
+	content[key] = value
+}
+
+func applyPatch(original []byte, patchType types.PatchType, data []byte, dataStruct runtime.Object) ([]byte, error) {
+	switch patchType {
+	case types.JSONPatchType:
+		patch, err := jsonpatch.DecodePatch(data)
+		if err != nil {
+			return nil, err
+		}
+		return patch.Apply(original)
+	case types.MergePatchType:
+		return jsonpatch.MergePatch(original, data)
+	case types.StrategicMergePatchType:
+		return strategicpatch.StrategicMergePatch(original, data, dataStruct)
+	}
+	return nil, fmt.Errorf("patch type %q is not supported", patchType)
+}
+
+// isDryRun reports whether the dry-run option of a write is client.DryRunAll.
+func isDryRun(dryRun []string) bool {
+	for _, value := range dryRun {
+		if value == metav1.DryRunAll {
+			return true
+		}
+	}
+	return false
+}
+
+func resourceOf(gvk schema.GroupVersionKind) schema.GroupResource {
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+	return gvr.GroupResource()
+}
+
+func conflict(gvk schema.GroupVersionKind, name string) error {
+	return apierrors.NewConflict(resourceOf(gvk), name,
+		errors.New("the object has been modified; please apply your changes to the latest version and try again"))
+}
@@ -0,0 +1,209 @@
+package testing
+
+import (
+	"context"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+)
+
+func newCluster(t *testing.T, objs ...client.Object) *Cluster {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+
+	cluster, err := NewCluster(scheme, objs...)
+	if err != nil {
+		t.Fatalf("failed to create cluster: %v", err)
+	}
+	return cluster
+}
+
+func TestUpdateBumpsGeneration(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, NewMyResource("default", "echo").WithMessage("hello").Build())
+
+	mr := &v1alpha1.MyResource{}
+	must(t, cluster.Get(ctx, client.ObjectKey{Namespace: "default", Name: "echo"}, mr))
+	generation := mr.Generation
+
+	mr.Labels = map[string]string{"changed": "metadata"}
+	must(t, cluster.Update(ctx, mr))
+	if mr.Generation != generation {
+		t.Errorf("metadata change bumped generation from %d to %d", generation, mr.Generation)
+	}
+
+	mr.Spec.Message = "goodbye"
+	must(t, cluster.Update(ctx, mr))
+	if mr.Generation != generation+1 {
+		t.Errorf("spec change set generation %d, expected %d", mr.Generation, generation+1)
+	}
+}
+
+func TestStatusSubresource(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t)
+
+	mr := NewMyResource("default", "echo").WithMessage("hello").
+		WithStatus(func(status *v1alpha1.MyResourceStatus) { status.PodName = "ignored" }).Build()
+	must(t, cluster.Create(ctx, mr))
+	if mr.Status.PodName != "" {
+		t.Errorf("create kept status.podName %q", mr.Status.PodName)
+	}
+
+	mr.Status.PodName = "echo-pod"
+	mr.Spec.Message = "ignored"
+	must(t, cluster.Status().Update(ctx, mr))
+
+	stored := &v1alpha1.MyResource{}
+	must(t, cluster.Get(ctx, client.ObjectKeyFromObject(mr), stored))
+	if stored.Status.PodName != "echo-pod" || stored.Spec.Message != "hello" {
+		t.Errorf("status update stored podName %q and message %q", stored.Status.PodName, stored.Spec.Message)
+	}
+
+	stored.Status.PodName = "ignored"
+	must(t, cluster.Update(ctx, stored))
+	if stored.Status.PodName != "echo-pod" {
+		t.Errorf("update changed status.podName to %q", stored.Status.PodName)
+	}
+}
+
+func TestUpdateConflicts(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, NewMyResource("default", "echo").Build())
+
+	first := &v1alpha1.MyResource{}
+	must(t, cluster.Get(ctx, client.ObjectKey{Namespace: "default", Name: "echo"}, first))
+	second := first.DeepCopy()
+
+	first.Spec.Message = "first"
+	must(t, cluster.Update(ctx, first))
+
+	second.Spec.Message = "second"
+	if err := cluster.Update(ctx, second); !apierrors.IsConflict(err) {
+		t.Errorf("update with outdated resourceVersion returned %v, expected a conflict", err)
+	}
+	if err := cluster.Status().Update(ctx, second); !apierrors.IsConflict(err) {
+		t.Errorf("status update with outdated resourceVersion returned %v, expected a conflict", err)
+	}
+}
+
+func TestGarbageCollection(t *testing.T) {
+	ctx := context.Background()
+	mr := NewMyResource("default", "echo").Build()
+	other := NewMyResource("default", "other").Build()
+	owned := NewPod("default", "owned").OwnedBy(mr).Build()
+	shared := NewPod("default", "shared").OwnedBy(mr).Build()
+	shared.OwnerReferences = append(shared.OwnerReferences, metav1.OwnerReference{
+		APIVersion: v1alpha1.SchemeGroupVersion.String(),
+		Kind:       "MyResource",
+		Name:       other.Name,
+		UID:        other.UID,
+	})
+	cluster := newCluster(t, mr, other, owned, shared)
+
+	must(t, cluster.Delete(ctx, mr))
+
+	err := cluster.Get(ctx, client.ObjectKeyFromObject(owned), &corev1.Pod{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("dependent without other owners wasn't deleted: %v", err)
+	}
+	pod := &corev1.Pod{}
+	must(t, cluster.Get(ctx, client.ObjectKeyFromObject(shared), pod))
+	if len(pod.OwnerReferences) != 1 || pod.OwnerReferences[0].UID != other.UID {
+		t.Errorf("dependent with another owner has owner references %+v", pod.OwnerReferences)
+	}
+}
+
+func TestDeleteWithFinalizer(t *testing.T) {
+	ctx := context.Background()
+	mr := NewMyResource("default", "echo").WithFinalizer("test/finalizer").Build()
+	pod := NewPod("default", "owned").OwnedBy(mr).Build()
+	cluster := newCluster(t, mr, pod)
+
+	must(t, cluster.Delete(ctx, mr))
+	stored := &v1alpha1.MyResource{}
+	must(t, cluster.Get(ctx, client.ObjectKeyFromObject(mr), stored))
+	if stored.DeletionTimestamp == nil {
+		t.Fatalf("MyResource with finalizer isn't marked as deleted")
+	}
+	must(t, cluster.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{}))
+
+	stored.Finalizers = nil
+	must(t, cluster.Update(ctx, stored))
+	if err := cluster.Get(ctx, client.ObjectKeyFromObject(mr), &v1alpha1.MyResource{}); !apierrors.IsNotFound(err) {
+		t.Errorf("MyResource wasn't deleted after its finalizer was removed: %v", err)
+	}
+	if err := cluster.Get(ctx, client.ObjectKeyFromObject(pod), &corev1.Pod{}); !apierrors.IsNotFound(err) {
+		t.Errorf("dependent wasn't deleted with its owner: %v", err)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	ctx := context.Background()
+	cluster := newCluster(t, NewMyResource("default", "echo").WithMessage("hello").Build())
+
+	mr := &v1alpha1.MyResource{}
+	must(t, cluster.Get(ctx, client.ObjectKey{Namespace: "default", Name: "echo"}, mr))
+	generation := mr.Generation
+
+	patched := mr.DeepCopy()
+	patched.Spec.Message = "goodbye"
+	patched.Status.PodName = "ignored"
+	must(t, cluster.Patch(ctx, patched, client.MergeFrom(mr)))
+	if patched.Spec.Message != "goodbye" || patched.Status.PodName != "" || patched.Generation != generation+1 {
+		t.Errorf("patch resulted in message %q, podName %q and generation %d",
+			patched.Spec.Message, patched.Status.PodName, patched.Generation)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	mr := NewMyResource("default", "echo").WithMessage("hello").Build()
+	cluster := newCluster(t, mr, NewPod("default", "existing").Build())
+
+	must(t, cluster.Create(ctx, NewPod("default", "new").Build(), client.DryRunAll))
+	if err := cluster.Get(ctx, client.ObjectKey{Namespace: "default", Name: "new"}, &corev1.Pod{}); !apierrors.IsNotFound(err) {
+		t.Errorf("pod was created in dry-run: %v", err)
+	}
+	if err := cluster.Create(ctx, NewPod("default", "existing").Build(), client.DryRunAll); !apierrors.IsAlreadyExists(err) {
+		t.Errorf("dry-run create of an existing pod returned %v", err)
+	}
+
+	stored := &v1alpha1.MyResource{}
+	must(t, cluster.Get(ctx, client.ObjectKeyFromObject(mr), stored))
+	changed := stored.DeepCopy()
+	changed.Spec.Message = "goodbye"
+	must(t, cluster.Update(ctx, changed, client.DryRunAll))
+	must(t, cluster.Patch(ctx, changed, client.MergeFrom(stored), client.DryRunAll))
+	must(t, cluster.Delete(ctx, stored, client.DryRunAll))
+	must(t, cluster.DeleteAllOf(ctx, &corev1.Pod{}, client.InNamespace("default"), client.DryRunAll))
+
+	after := &v1alpha1.MyResource{}
+	must(t, cluster.Get(ctx, client.ObjectKeyFromObject(mr), after))
+	if after.Spec.Message != "hello" || after.DeletionTimestamp != nil || after.ResourceVersion != stored.ResourceVersion {
+		t.Errorf("dry-run writes changed the MyResource to %+v", after)
+	}
+	must(t, cluster.Get(ctx, client.ObjectKey{Namespace: "default", Name: "existing"}, &corev1.Pod{}))
+
+	stale := stored.DeepCopy()
+	stale.ResourceVersion = "outdated"
+	if err := cluster.Update(ctx, stale, client.DryRunAll); !apierrors.IsConflict(err) {
+		t.Errorf("dry-run update with an outdated resourceVersion returned %v", err)
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,3 @@
+// Package testing helps to unit test reconcilers of the samplecontroller API. Cluster is a client.Client
+// with the API server semantics reconcilers depend on, the builders create MyResource and Pod fixtures.
+package testing
@@ -0,0 +1,223 @@
+package testing
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+)
+
+// MyResourceBuilder builds MyResource fixtures.
+type MyResourceBuilder struct {
+	myresource *v1alpha1.MyResource
+}
+
+// NewMyResource returns a builder for a MyResource with a UID and an empty message.
+func NewMyResource(namespace string, name string) *MyResourceBuilder {
+	return &MyResourceBuilder{
+		myresource: &v1alpha1.MyResource{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: v1alpha1.SchemeGroupVersion.String(),
+				Kind:       "MyResource",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				UID:       uuid.NewUUID(),
+			},
+		},
+	}
+}
+
+func (b *MyResourceBuilder) WithUID(uid types.UID) *MyResourceBuilder {
+	b.myresource.UID = uid
+	return b
+}
+
+func (b *MyResourceBuilder) WithGeneration(generation int64) *MyResourceBuilder {
+	b.myresource.Generation = generation
+	return b
+}
+
+func (b *MyResourceBuilder) WithLabel(key string, value string) *MyResourceBuilder {
+	if b.myresource.Labels == nil {
+		b.myresource.Labels = map[string]string{}
+	}
+	b.myresource.Labels[key] = value
+	return b
+}
+
+func (b *MyResourceBuilder) WithAnnotation(key string, value string) *MyResourceBuilder {
+	if b.myresource.Annotations == nil {
+		b.myresource.Annotations = map[string]string{}
+	}
+	b.myresource.Annotations[key] = value
+	return b
+}
+
+func (b *MyResourceBuilder) WithFinalizer(finalizer string) *MyResourceBuilder {
+	b.myresource.Finalizers = append(b.myresource.Finalizers, finalizer)
+	return b
+}
+
+func (b *MyResourceBuilder) WithMessage(message string) *MyResourceBuilder {
+	b.myresource.Spec.Message = message
+	return b
+}
+
+func (b *MyResourceBuilder) WithMessageFormat(format v1alpha1.MessageFormat) *MyResourceBuilder {
+	b.myresource.Spec.MessageFormat = format
+	return b
+}
+
+func (b *MyResourceBuilder) WithReplicas(replicas int32) *MyResourceBuilder {
+	b.myresource.Spec.Replicas = &replicas
+	return b
+}
+
+func (b *MyResourceBuilder) WithStrategy(strategy v1alpha1.Strategy) *MyResourceBuilder {
+	b.myresource.Spec.Strategy = strategy
+	return b
+}
+
+func (b *MyResourceBuilder) WithReloadStrategy(strategy v1alpha1.ReloadStrategy) *MyResourceBuilder {
+	b.myresource.Spec.ReloadStrategy = strategy
+	return b
+}
+
+func (b *MyResourceBuilder) WithClassName(className string) *MyResourceBuilder {
+	b.myresource.Spec.ClassName = className
+	return b
+}
+
+func (b *MyResourceBuilder) WithRoute(route v1alpha1.Route) *MyResourceBuilder {
+	b.myresource.Spec.Routes = append(b.myresource.Spec.Routes, route)
+	return b
+}
+
+func (b *MyResourceBuilder) WithSchedule(entry v1alpha1.ScheduleEntry) *MyResourceBuilder {
+	b.myresource.Spec.Schedule = append(b.myresource.Spec.Schedule, entry)
+	return b
+}
+
+func (b *MyResourceBuilder) Suspended() *MyResourceBuilder {
+	b.myresource.Spec.Suspend = true
+	return b
+}
+
+// WithStatus applies mutate to the status of the MyResource.
+func (b *MyResourceBuilder) WithStatus(mutate func(status *v1alpha1.MyResourceStatus)) *MyResourceBuilder {
+	mutate(&b.myresource.Status)
+	return b
+}
+
+// Build returns a copy of the MyResource, the builder can be used for further fixtures.
+func (b *MyResourceBuilder) Build() *v1alpha1.MyResource {
+	return b.myresource.DeepCopy()
+}
+
+// PodBuilder builds Pod fixtures.
+type PodBuilder struct {
+	pod *corev1.Pod
+}
+
+// NewPod returns a builder for a pending pod with a UID and a single container called echoserver.
+func NewPod(namespace string, name string) *PodBuilder {
+	return &PodBuilder{
+		pod: &corev1.Pod{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: corev1.SchemeGroupVersion.String(),
+				Kind:       "Pod",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				UID:       uuid.NewUUID(),
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: "echoserver"},
+				},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+			},
+		},
+	}
+}
+
+// OwnedBy makes myresource the controller of the pod.
+func (b *PodBuilder) OwnedBy(myresource *v1alpha1.MyResource) *PodBuilder {
+	b.pod.OwnerReferences = append(b.pod.OwnerReferences,
+		*metav1.NewControllerRef(myresource, v1alpha1.SchemeGroupVersion.WithKind("MyResource")))
+	return b
+}
+
+func (b *PodBuilder) WithLabel(key string, value string) *PodBuilder {
+	if b.pod.Labels == nil {
+		b.pod.Labels = map[string]string{}
+	}
+	b.pod.Labels[key] = value
+	return b
+}
+
+func (b *PodBuilder) WithImage(image string) *PodBuilder {
+	b.pod.Spec.Containers[0].Image = image
+	return b
+}
+
+func (b *PodBuilder) WithEnv(name string, value string) *PodBuilder {
+	b.pod.Spec.Containers[0].Env = append(b.pod.Spec.Containers[0].Env, corev1.EnvVar{Name: name, Value: value})
+	return b
+}
+
+func (b *PodBuilder) WithPort(name string, port int32) *PodBuilder {
+	b.pod.Spec.Containers[0].Ports = append(b.pod.Spec.Containers[0].Ports, corev1.ContainerPort{Name: name, ContainerPort: port})
+	return b
+}
+
+func (b *PodBuilder) WithPodIP(ip string) *PodBuilder {
+	b.pod.Status.PodIP = ip
+	return b
+}
+
+// Ready makes the pod running and ready.
+func (b *PodBuilder) Ready() *PodBuilder {
+	b.pod.Status.Phase = corev1.PodRunning
+	b.setCondition(corev1.PodReady, corev1.ConditionTrue)
+	return b
+}
+
+// NotReady makes the pod running but not ready.
+func (b *PodBuilder) NotReady() *PodBuilder {
+	b.pod.Status.Phase = corev1.PodRunning
+	b.setCondition(corev1.PodReady, corev1.ConditionFalse)
+	return b
+}
+
+// Terminating marks the pod as deleted. A finalizer keeps it from being removed by a Cluster.
+func (b *PodBuilder) Terminating() *PodBuilder {
+	now := metav1.Now()
+	b.pod.DeletionTimestamp = &now
+	if len(b.pod.Finalizers) == 0 {
+		b.pod.Finalizers = []string{"testing/terminating"}
+	}
+	return b
+}
+
+func (b *PodBuilder) setCondition(conditionType corev1.PodConditionType, status corev1.ConditionStatus) {
+	for i := range b.pod.Status.Conditions {
+		if b.pod.Status.Conditions[i].Type == conditionType {
+			b.pod.Status.Conditions[i].Status = status
+			return
+		}
+	}
+	b.pod.Status.Conditions = append(b.pod.Status.Conditions, corev1.PodCondition{Type: conditionType, Status: status})
+}
+
+// Build returns a copy of the pod, the builder can be used for further fixtures.
+func (b *PodBuilder) Build() *corev1.Pod {
+	return b.pod.DeepCopy()
+}
@@ -0,0 +1,99 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// WrapClient returns a client that runs every call of c in a child span of the span of its context.
+func WrapClient(c client.Client) client.Client {
+	return &tracingClient{Client: c, tracer: Tracer()}
+}
+
+type tracingClient struct {
+	client.Client
+	tracer trace.Tracer
+}
+
+func (c *tracingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	ctx, span := c.start(ctx, "Get", obj, key)
+	return End(span, c.Client.Get(ctx, key, obj))
+}
+
+func (c *tracingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	ctx, span := c.start(ctx, "List", list, client.ObjectKey{})
+	return End(span, c.Client.List(ctx, list, opts...))
+}
+
+func (c *tracingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	ctx, span := c.start(ctx, "Create", obj, client.ObjectKeyFromObject(obj))
+	return End(span, c.Client.Create(ctx, obj, opts...))
+}
+
+func (c *tracingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	ctx, span := c.start(ctx, "Update", obj, client.ObjectKeyFromObject(obj))
+	return End(span, c.Client.Update(ctx, obj, opts...))
+}
+
+func (c *tracingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	ctx, span := c.start(ctx, "Patch", obj, client.ObjectKeyFromObject(obj))
+	return End(span, c.Client.Patch(ctx, obj, patch, opts...))
+}
+
+func (c *tracingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	ctx, span := c.start(ctx, "Delete", obj, client.ObjectKeyFromObject(obj))
+	return End(span, c.Client.Delete(ctx, obj, opts...))
+}
+
+func (c *tracingClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	ctx, span := c.start(ctx, "DeleteAllOf", obj, client.ObjectKey{})
+	return End(span, c.Client.DeleteAllOf(ctx, obj, opts...))
+}
+
+func (c *tracingClient) Status() client.StatusWriter {
+	return &tracingStatusWriter{client: c, StatusWriter: c.Client.Status()}
+}
+
+// start starts the span of the call verb on obj, e.g. "Get Pod".
+func (c *tracingClient) start(ctx context.Context, verb string, obj runtime.Object, key client.ObjectKey) (context.Context, trace.Span) {
+	kind := "Unknown"
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme())
+	if err == nil {
+		kind = gvk.Kind
+	}
+
+	return c.tracer.Start(ctx, verb+" "+kind, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attributeKind.String(kind),
+		attributeNamespace.String(key.Namespace),
+		attributeName.String(key.Name),
+	))
+}
+
+type tracingStatusWriter struct {
+	client.StatusWriter
+	client *tracingClient
+}
+
+func (w *tracingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	ctx, span := w.client.start(ctx, "UpdateStatus", obj, client.ObjectKeyFromObject(obj))
+	return End(span, w.StatusWriter.Update(ctx, obj, opts...))
+}
+
+func (w *tracingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	ctx, span := w.client.start(ctx, "PatchStatus", obj, client.ObjectKeyFromObject(obj))
+	return End(span, w.StatusWriter.Patch(ctx, obj, patch, opts...))
+}
+
+// End records err on span and ends it, it returns err.
+func End(span trace.Span, err error) error {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+	return err
+}
@@ -0,0 +1,218 @@
+package tracing
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+const (
+	// TracerName is the name of the tracer of the controller.
+	TracerName = "github.com/reshnm/k8s-sample-controller-crd"
+
+	// AnnotationTraceParent carries the W3C trace context of the reconcile that created a pod,
+	// so that spans of the echo server can link back to it.
+	AnnotationTraceParent = "samplecontroller.reshnm.de/traceparent"
+
+	defaultServiceName = "sample-controller"
+)
+
+const (
+	// ExporterNone disables tracing.
+	ExporterNone = "none"
+	// ExporterOTLP sends spans to an OTLP collector over gRPC.
+	ExporterOTLP = "otlp"
+	// ExporterFile writes spans as JSON to a local file for offline debugging.
+	ExporterFile = "file"
+)
+
+const (
+	attributeNamespace  = attribute.Key("k8s.namespace")
+	attributeName       = attribute.Key("k8s.name")
+	attributeKind       = attribute.Key("k8s.kind")
+	attributeGeneration = attribute.Key("k8s.generation")
+	attributeOutcome    = attribute.Key("reconcile.outcome")
+)
+
+// Options configures where spans are exported to.
+type Options struct {
+	// Exporter is one of ExporterNone, ExporterOTLP and ExporterFile.
+	Exporter string
+	// OTLPEndpoint is the host:port of the OTLP collector. If it is empty, the OTEL_EXPORTER_OTLP_ENDPOINT
+	// environment variable or localhost:4317 is used.
+	OTLPEndpoint string
+	// OTLPInsecure disables TLS towards the OTLP collector.
+	OTLPInsecure bool
+	// File is the path of the file the spans are written to by the file exporter.
+	File string
+	// ServiceName is reported as service.name of all spans.
+	ServiceName string
+}
+
+// DefaultOptions returns the Options used when no flags are given, tracing is disabled.
+func DefaultOptions() Options {
+	return Options{
+		Exporter:    ExporterNone,
+		File:        "traces.json",
+		ServiceName: defaultServiceName,
+	}
+}
+
+func (o *Options) AddFlags(flagSet *flag.FlagSet) {
+	flagSet.StringVar(&o.Exporter, "tracing-exporter", o.Exporter,
+		fmt.Sprintf("where spans of reconciles are exported to, one of %q, %q and %q", ExporterNone, ExporterOTLP, ExporterFile))
+	flagSet.StringVar(&o.OTLPEndpoint, "tracing-otlp-endpoint", o.OTLPEndpoint,
+		"host:port of the OTLP collector, defaults to OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317")
+	flagSet.BoolVar(&o.OTLPInsecure, "tracing-otlp-insecure", o.OTLPInsecure,
+		"connect to the OTLP collector without TLS")
+	flagSet.StringVar(&o.File, "tracing-file", o.File,
+		"file the spans are written to as JSON by the file exporter")
+}
+
+// Setup installs the global tracer provider and the W3C trace context propagator according to options.
+// The returned function flushes the pending spans and must be called before the process exits.
+func Setup(ctx context.Context, options Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var exporter sdktrace.SpanExporter
+	var closeFile func() error
+	switch options.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var grpcOptions []otlptracegrpc.Option
+		if options.OTLPEndpoint != "" {
+			grpcOptions = append(grpcOptions, otlptracegrpc.WithEndpoint(options.OTLPEndpoint))
+		}
+		if options.OTLPInsecure {
+			grpcOptions = append(grpcOptions, otlptracegrpc.WithInsecure())
+		}
+		otlpExporter, err := otlptracegrpc.New(ctx, grpcOptions...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+		}
+		exporter = otlpExporter
+	case ExporterFile:
+		file, err := os.Create(options.File)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create trace file: %w", err)
+		}
+		fileExporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("failed to create file exporter: %w", err)
+		}
+		exporter = fileExporter
+		closeFile = file.Close
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", options.Exporter)
+	}
+
+	serviceName := options.ServiceName
+	if serviceName == "" {
+		serviceName = defaultServiceName
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	log.FromContext(ctx).Info("exporting traces", "exporter", options.Exporter)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeFile != nil {
+			if closeErr := closeFile(); err == nil {
+				err = closeErr
+			}
+		}
+		return err
+	}, nil
+}
+
+// Tracer returns the tracer of the controller. Spans are dropped unless Setup installed an exporter.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// WrapReconciler returns a reconciler that runs every reconcile of reconciler in a span named after kind.
+func WrapReconciler(kind string, reconciler reconcile.Reconciler) reconcile.Reconciler {
+	return wrapReconciler(Tracer(), kind, reconciler)
+}
+
+func wrapReconciler(tracer trace.Tracer, kind string, reconciler reconcile.Reconciler) reconcile.Reconciler {
+	return reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		ctx, span := tracer.Start(ctx, "Reconcile "+kind, trace.WithAttributes(
+			attributeKind.String(kind),
+			attributeNamespace.String(req.Namespace),
+			attributeName.String(req.Name),
+		))
+		defer span.End()
+
+		result, err := reconciler.Reconcile(ctx, req)
+		span.SetAttributes(attributeOutcome.String(reconcileutil.Outcome(result, err)))
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return result, err
+	})
+}
+
+// RecordObject adds the generation of obj to the span of ctx.
+func RecordObject(ctx context.Context, obj client.Object) {
+	trace.SpanFromContext(ctx).SetAttributes(attributeGeneration.Int64(obj.GetGeneration()))
+}
+
+// InjectTraceContext stamps the trace context of ctx onto obj, it does nothing if ctx isn't traced.
+func InjectTraceContext(ctx context.Context, obj client.Object) {
+	carrier := propagation.HeaderCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	traceParent := carrier.Get("traceparent")
+	if traceParent == "" {
+		return
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[AnnotationTraceParent] = traceParent
+	obj.SetAnnotations(annotations)
+}
+
+// ExtractTraceContext returns ctx with the trace context stamped onto obj as remote parent.
+func ExtractTraceContext(ctx context.Context, obj client.Object) context.Context {
+	traceParent, ok := obj.GetAnnotations()[AnnotationTraceParent]
+	if !ok {
+		return ctx
+	}
+	return ContextWithTraceParent(ctx, traceParent)
+}
+
+// ContextWithTraceParent returns ctx with the W3C traceparent as remote parent. An empty or invalid traceparent
+// leaves ctx unchanged.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	carrier := propagation.HeaderCarrier{}
+	carrier.Set("traceparent", traceParent)
+	return propagation.TraceContext{}.Extract(ctx, carrier)
+}
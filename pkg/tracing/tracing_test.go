@@ -0,0 +1,95 @@
+package tracing
+
+import (
+	"context"
+	goerrors "errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"testing"
+
+	kittesting "github.com/reshnm/k8s-sample-controller-crd/pkg/testing"
+)
+
+func TestReconcileSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer(TracerName)
+
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	cluster, err := kittesting.NewCluster(scheme, kittesting.NewPod("default", "echo").Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &tracingClient{Client: cluster, tracer: tracer}
+
+	reconciler := wrapReconciler(tracer, "Pod", reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+		pod := &corev1.Pod{}
+		err := c.Get(ctx, req.NamespacedName, pod)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		RecordObject(ctx, pod)
+		return reconcile.Result{}, goerrors.New("failed")
+	}))
+	_, _ = reconciler.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "echo"}})
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, expected 2", len(spans))
+	}
+	get, reconcileSpan := spans[0], spans[1]
+	if get.Name() != "Get Pod" || reconcileSpan.Name() != "Reconcile Pod" {
+		t.Fatalf("recorded spans %q and %q", get.Name(), reconcileSpan.Name())
+	}
+	if get.Parent().SpanID() != reconcileSpan.SpanContext().SpanID() {
+		t.Errorf("span of Get isn't a child of the reconcile span")
+	}
+	attributes := attribute.NewSet(reconcileSpan.Attributes()...)
+	for key, expected := range map[attribute.Key]attribute.Value{
+		attributeNamespace:  attribute.StringValue("default"),
+		attributeName:       attribute.StringValue("echo"),
+		attributeGeneration: attribute.Int64Value(1),
+		attributeOutcome:    attribute.StringValue("error"),
+	} {
+		value, ok := attributes.Value(key)
+		if !ok || value != expected {
+			t.Errorf("attribute %s is %v, expected %v", key, value.Emit(), expected.Emit())
+		}
+	}
+	if reconcileSpan.Status().Code != codes.Error {
+		t.Errorf("reconcile span has status %v, expected an error", reconcileSpan.Status().Code)
+	}
+}
+
+func TestTraceContextAnnotation(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer(TracerName).Start(context.Background(), "test")
+	defer span.End()
+
+	pod := kittesting.NewPod("default", "echo").Build()
+	InjectTraceContext(ctx, pod)
+	if pod.Annotations[AnnotationTraceParent] == "" {
+		t.Fatalf("trace context wasn't stamped onto the pod")
+	}
+
+	linked := trace.SpanContextFromContext(ExtractTraceContext(context.Background(), pod))
+	if linked.TraceID() != span.SpanContext().TraceID() || linked.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("extracted span context %v, expected %v", linked, span.SpanContext())
+	}
+
+	untraced := kittesting.NewPod("default", "untraced").Build()
+	InjectTraceContext(context.Background(), untraced)
+	if _, ok := untraced.Annotations[AnnotationTraceParent]; ok {
+		t.Errorf("untraced context stamped a trace context")
+	}
+}
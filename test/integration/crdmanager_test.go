@@ -0,0 +1,220 @@
+package integration
+
+import (
+	"context"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/crdmanager"
+)
+
+var embeddedCRDs = []string{
+	"myresources.samplecontroller.reshnm.de",
+	"myresourceclasses.samplecontroller.reshnm.de",
+	"myresourcesets.samplecontroller.reshnm.de",
+	"myresourcequotas.samplecontroller.reshnm.de",
+}
+
+// ensureCRDs registers the embedded CRDs, the tests of the controllers depend on them.
+func ensureCRDs(t *testing.T) {
+	t.Helper()
+	crdManager, err := crdmanager.CreateCrdManager(newManager(t), crdmanager.Options{})
+	if err != nil {
+		t.Fatalf("failed to create CRD manager: %v", err)
+	}
+	err = crdManager.EnsureCRDs(context.Background())
+	if err != nil {
+		t.Fatalf("failed to ensure CRDs: %v", err)
+	}
+}
+
+func getCRD(t *testing.T, name string) *apiextensionsv1.CustomResourceDefinition {
+	t.Helper()
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	err := kubeClient.Get(context.Background(), client.ObjectKey{Name: name}, crd)
+	if err != nil {
+		t.Fatalf("failed to get CRD %q: %v", name, err)
+	}
+	return crd
+}
+
+func isEstablished(crd *apiextensionsv1.CustomResourceDefinition) bool {
+	for _, condition := range crd.Status.Conditions {
+		if condition.Type == apiextensionsv1.Established {
+			return condition.Status == apiextensionsv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func TestEnsureCRDsCreatesEstablishedCRDs(t *testing.T) {
+	requireAPIServer(t)
+	ensureCRDs(t)
+
+	for _, name := range embeddedCRDs {
+		// EnsureCRDs returns only after the CRDs are established, there is nothing to wait for
+		crd := getCRD(t, name)
+		if !isEstablished(crd) {
+			t.Errorf("CRD %q is not established: %+v", name, crd.Status.Conditions)
+		}
+	}
+}
+
+func TestEnsureCRDsRestoresChangedCRD(t *testing.T) {
+	requireAPIServer(t)
+	ensureCRDs(t)
+
+	name := "myresources.samplecontroller.reshnm.de"
+	crd := getCRD(t, name)
+	properties := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties
+	if _, ok := properties["message"]; !ok {
+		t.Fatalf("CRD %q has no spec.message", name)
+	}
+	delete(properties, "message")
+	err := kubeClient.Update(context.Background(), crd)
+	if err != nil {
+		t.Fatalf("failed to update CRD %q: %v", name, err)
+	}
+
+	ensureCRDs(t)
+
+	crd = getCRD(t, name)
+	if _, ok := crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["message"]; !ok {
+		t.Errorf("EnsureCRDs didn't restore spec.message of CRD %q", name)
+	}
+	if !isEstablished(crd) {
+		t.Errorf("CRD %q is not established after the update", name)
+	}
+}
+
+func TestEnsureCRDsIsIdempotent(t *testing.T) {
+	requireAPIServer(t)
+	ensureCRDs(t)
+
+	generations := map[string]int64{}
+	for _, name := range embeddedCRDs {
+		generations[name] = getCRD(t, name).Generation
+	}
+
+	ensureCRDs(t)
+
+	for _, name := range embeddedCRDs {
+		if generation := getCRD(t, name).Generation; generation != generations[name] {
+			t.Errorf("CRD %q changed from generation %d to %d although the manifest didn't change",
+				name, generations[name], generation)
+		}
+	}
+}
+
+func TestRunnableEnsuresCRDsBeforeSetup(t *testing.T) {
+	requireAPIServer(t)
+	mgr := newManager(t)
+	crdManager, err := crdmanager.CreateCrdManager(mgr, crdmanager.Options{})
+	if err != nil {
+		t.Fatalf("failed to create CRD manager: %v", err)
+	}
+
+	established := make(chan bool, 1)
+	err = mgr.Add(crdManager.Runnable(func() error {
+		// setup runs in a goroutine of the manager, getCRD can't fail the test from there
+		for _, name := range embeddedCRDs {
+			crd := &apiextensionsv1.CustomResourceDefinition{}
+			err := kubeClient.Get(context.Background(), client.ObjectKey{Name: name}, crd)
+			if err != nil || !isEstablished(crd) {
+				established <- false
+				return nil
+			}
+		}
+		established <- true
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("failed to add CRD manager: %v", err)
+	}
+	stop := startManager(t, mgr)
+	defer stop()
+
+	select {
+	case ok := <-established:
+		if !ok {
+			t.Error("setup was called before the CRDs were established")
+		}
+	case <-time.After(eventuallyTimeout):
+		t.Error("setup wasn't called")
+	}
+}
+
+// dryRunCRDManager returns a CRDManager for the CRDs in crdFS that only validates them.
+func dryRunCRDManager(t *testing.T, crdFS fstest.MapFS) *crdmanager.CRDManager {
+	t.Helper()
+	crdManager, err := crdmanager.New(config, crdFS, "crds", crdmanager.Options{DryRun: true, Timeout: 3 * time.Second})
+	if err != nil {
+		t.Fatalf("failed to create CRD manager: %v", err)
+	}
+	return crdManager
+}
+
+func TestEnsureCRDsInDryRunLeavesChangedCRD(t *testing.T) {
+	requireAPIServer(t)
+	ensureCRDs(t)
+	// the controller tests need the complete schema
+	t.Cleanup(func() { ensureCRDs(t) })
+
+	name := "myresources.samplecontroller.reshnm.de"
+	crd := getCRD(t, name)
+	delete(crd.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties, "message")
+	err := kubeClient.Update(context.Background(), crd)
+	if err != nil {
+		t.Fatalf("failed to update CRD %q: %v", name, err)
+	}
+
+	crdManager, err := crdmanager.CreateCrdManager(newManager(t), crdmanager.Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("failed to create CRD manager: %v", err)
+	}
+	err = crdManager.EnsureCRDs(context.Background())
+	if err != nil {
+		t.Fatalf("failed to ensure CRDs in dry-run mode: %v", err)
+	}
+
+	if _, ok := getCRD(t, name).Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"].Properties["message"]; ok {
+		t.Errorf("EnsureCRDs restored spec.message of CRD %q in dry-run mode", name)
+	}
+}
+
+func TestEnsureCRDsInDryRunWaitsForRegistration(t *testing.T) {
+	requireAPIServer(t)
+	name := "dryruns.integration.reshnm.de"
+	crdFS := fstest.MapFS{"crds/dryrun.yaml": &fstest.MapFile{Data: []byte(`
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: ` + name + `
+spec:
+  group: integration.reshnm.de
+  scope: Namespaced
+  names:
+    kind: DryRun
+    plural: dryruns
+  versions:
+    - name: v1
+      served: true
+      storage: true
+      schema:
+        openAPIV3Schema:
+          type: object
+`)}}
+
+	err := dryRunCRDManager(t, crdFS).EnsureCRDs(context.Background())
+	if err == nil {
+		t.Error("EnsureCRDs in dry-run mode succeeded although the CRD isn't registered")
+	}
+	err = kubeClient.Get(context.Background(), client.ObjectKey{Name: name}, &apiextensionsv1.CustomResourceDefinition{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("EnsureCRDs registered CRD %q in dry-run mode: %v", name, err)
+	}
+}
@@ -0,0 +1,4 @@
+// Package integration contains tests that run the controllers and the CRDManager against a real API server
+// started by envtest. "make test" installs the envtest binaries and points KUBEBUILDER_ASSETS to them, each
+// test is skipped if it isn't set.
+package integration
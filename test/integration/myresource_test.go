@@ -0,0 +1,564 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"testing"
+	"time"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/myresource"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/myresourcequota"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/reconcileutil"
+)
+
+const (
+	testImage = "example.com/echoserver:test"
+)
+
+// startMyResourceController runs the MyResource controller until the test ends or the returned function is called.
+func startMyResourceController(t *testing.T) func() {
+	t.Helper()
+	return startMyResourceControllerWith(t, func(*myresource.Options) {})
+}
+
+// startMyResourceControllerWith is startMyResourceController with the options changed by configure.
+func startMyResourceControllerWith(t *testing.T, configure func(options *myresource.Options)) func() {
+	t.Helper()
+	ensureCRDs(t)
+
+	mgr := newManager(t)
+	options := myresource.DefaultOptions()
+	options.EchoServerImage = testImage
+	configure(&options)
+	err := myresource.AddControllerToManager(mgr, options)
+	if err != nil {
+		t.Fatalf("failed to add MyResource controller: %v", err)
+	}
+
+	stopped := false
+	stop := startManager(t, mgr)
+	t.Cleanup(func() {
+		if !stopped {
+			stop()
+		}
+	})
+	return func() {
+		stopped = true
+		stop()
+	}
+}
+
+func createMyResource(t *testing.T, namespace string, message string) *v1alpha1.MyResource {
+	t.Helper()
+	mr := &v1alpha1.MyResource{
+		ObjectMeta: metav1.ObjectMeta{Name: "echo", Namespace: namespace},
+		Spec:       v1alpha1.MyResourceSpec{Message: message},
+	}
+	err := kubeClient.Create(context.Background(), mr)
+	if err != nil {
+		t.Fatalf("failed to create MyResource: %v", err)
+	}
+	return mr
+}
+
+func getMyResource(t *testing.T, key types.NamespacedName) *v1alpha1.MyResource {
+	t.Helper()
+	mr := &v1alpha1.MyResource{}
+	err := kubeClient.Get(context.Background(), key, mr)
+	if err != nil {
+		t.Fatalf("failed to get MyResource %s: %v", key, err)
+	}
+	return mr
+}
+
+// waitForPod waits until the status of the MyResource names a pod serving message and returns the pod.
+func waitForPod(t *testing.T, key types.NamespacedName, message string) *corev1.Pod {
+	t.Helper()
+	pod := &corev1.Pod{}
+	eventually(t, func() error {
+		mr := &v1alpha1.MyResource{}
+		err := kubeClient.Get(context.Background(), key, mr)
+		if err != nil {
+			return err
+		}
+		if mr.Status.PodName == "" {
+			return fmt.Errorf("status.podName is not set")
+		}
+		err = kubeClient.Get(context.Background(), types.NamespacedName{Name: mr.Status.PodName, Namespace: key.Namespace}, pod)
+		if err != nil {
+			return err
+		}
+		if value := envValue(pod, "ECHO_MESSAGE"); value != message {
+			return fmt.Errorf("pod %q serves message %q", pod.Name, value)
+		}
+		return nil
+	})
+	return pod
+}
+
+func envValue(pod *corev1.Pod, name string) string {
+	for _, envVar := range pod.Spec.Containers[0].Env {
+		if envVar.Name == name {
+			return envVar.Value
+		}
+	}
+	return ""
+}
+
+func TestMyResourceCreatesPod(t *testing.T) {
+	requireAPIServer(t)
+	startMyResourceController(t)
+	namespace := createNamespace(t)
+	mr := createMyResource(t, namespace, "hello")
+	key := client.ObjectKeyFromObject(mr)
+
+	pod := waitForPod(t, key, "hello")
+
+	container := pod.Spec.Containers[0]
+	if container.Image != testImage {
+		t.Errorf("pod has image %q, expected %q", container.Image, testImage)
+	}
+	if port := envValue(pod, "PORT"); port != "8080" {
+		t.Errorf("pod has PORT %q, expected 8080", port)
+	}
+	if len(container.Args) != 1 || container.Args[0] != "echoserver" {
+		t.Errorf("pod has args %v, expected [echoserver]", container.Args)
+	}
+
+	ownerRef := metav1.GetControllerOf(pod)
+	if ownerRef == nil || ownerRef.Kind != "MyResource" || ownerRef.Name != mr.Name || ownerRef.UID != mr.UID {
+		t.Errorf("pod is controlled by %+v, expected MyResource %q with UID %s", ownerRef, mr.Name, mr.UID)
+	}
+}
+
+func TestMyResourcePopulatesStatus(t *testing.T) {
+	requireAPIServer(t)
+	startMyResourceController(t)
+	namespace := createNamespace(t)
+	mr := createMyResource(t, namespace, "hello")
+	key := client.ObjectKeyFromObject(mr)
+
+	waitForPod(t, key, "hello")
+	eventually(t, func() error {
+		mr := getMyResource(t, key)
+		switch {
+		case mr.Status.ServiceName == "":
+			return fmt.Errorf("status.serviceName is not set")
+		case mr.Status.CurrentRevision == "":
+			return fmt.Errorf("status.currentRevision is not set")
+		case mr.Status.Rollout == nil || mr.Status.Rollout.ActiveRevision == "":
+			return fmt.Errorf("status.rollout is %+v", mr.Status.Rollout)
+		case mr.Status.LastError != "":
+			return fmt.Errorf("status.lastError is %q", mr.Status.LastError)
+		}
+		return nil
+	})
+
+	mr = getMyResource(t, key)
+	// envtest runs no kubelet, the pod never becomes ready
+	if mr.Status.Rollout.Step != v1alpha1.RolloutStepProgressing {
+		t.Errorf("rollout step is %q, expected %q", mr.Status.Rollout.Step, v1alpha1.RolloutStepProgressing)
+	}
+
+	service := &corev1.Service{}
+	err := kubeClient.Get(context.Background(), types.NamespacedName{Name: mr.Status.ServiceName, Namespace: namespace}, service)
+	if err != nil {
+		t.Fatalf("failed to get Service %q: %v", mr.Status.ServiceName, err)
+	}
+	if !metav1.IsControlledBy(service, mr) {
+		t.Errorf("Service %q is not controlled by the MyResource", service.Name)
+	}
+
+	revision := &appsv1.ControllerRevision{}
+	err = kubeClient.Get(context.Background(), types.NamespacedName{Name: mr.Status.CurrentRevision, Namespace: namespace}, revision)
+	if err != nil {
+		t.Fatalf("failed to get ControllerRevision %q: %v", mr.Status.CurrentRevision, err)
+	}
+}
+
+func TestMyResourceMessageChangeReplacesPod(t *testing.T) {
+	requireAPIServer(t)
+	startMyResourceController(t)
+	namespace := createNamespace(t)
+	mr := createMyResource(t, namespace, "hello")
+	key := client.ObjectKeyFromObject(mr)
+	waitForPod(t, key, "hello")
+
+	mr = getMyResource(t, key)
+	mr.Spec.Message = "goodbye"
+	err := kubeClient.Update(context.Background(), mr)
+	if err != nil {
+		t.Fatalf("failed to update MyResource: %v", err)
+	}
+
+	waitForPod(t, key, "goodbye")
+}
+
+func TestMyResourceSurvivesControllerRestart(t *testing.T) {
+	requireAPIServer(t)
+	stop := startMyResourceController(t)
+	namespace := createNamespace(t)
+	mr := createMyResource(t, namespace, "hello")
+	key := client.ObjectKeyFromObject(mr)
+	pod := waitForPod(t, key, "hello")
+	eventually(t, func() error {
+		if getMyResource(t, key).Status.CurrentRevision == "" {
+			return fmt.Errorf("status.currentRevision is not set")
+		}
+		return nil
+	})
+	stop()
+
+	before := getMyResource(t, key)
+	startMyResourceController(t)
+
+	// the restarted controller reconciles the MyResource again, which must not change anything
+	consistently(t, 3*time.Second, func() error {
+		current := &corev1.Pod{}
+		err := kubeClient.Get(context.Background(), client.ObjectKeyFromObject(pod), current)
+		if err != nil {
+			return err
+		}
+		if current.UID != pod.UID {
+			return fmt.Errorf("pod %q was recreated", pod.Name)
+		}
+
+		mr := getMyResource(t, key)
+		if mr.ResourceVersion != before.ResourceVersion {
+			return fmt.Errorf("MyResource was updated: %+v", mr.Status)
+		}
+		return nil
+	})
+
+	revisions := &appsv1.ControllerRevisionList{}
+	err := kubeClient.List(context.Background(), revisions, client.InNamespace(namespace))
+	if err != nil {
+		t.Fatalf("failed to list ControllerRevisions: %v", err)
+	}
+	if len(revisions.Items) != 1 {
+		t.Errorf("found %d ControllerRevisions, expected 1", len(revisions.Items))
+	}
+}
+
+func TestMyResourceNetworkPolicy(t *testing.T) {
+	requireAPIServer(t)
+	startMyResourceController(t)
+	namespace := createNamespace(t)
+	mr := &v1alpha1.MyResource{
+		ObjectMeta: metav1.ObjectMeta{Name: "echo", Namespace: namespace},
+		Spec: v1alpha1.MyResourceSpec{
+			Message: "hello",
+			NetworkPolicy: &v1alpha1.NetworkPolicy{
+				From: []v1alpha1.NetworkPolicyPeer{
+					{
+						Namespaces:  []string{"monitoring"},
+						PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "prometheus"}},
+					},
+				},
+			},
+		},
+	}
+	err := kubeClient.Create(context.Background(), mr)
+	if err != nil {
+		t.Fatalf("failed to create MyResource: %v", err)
+	}
+	key := client.ObjectKeyFromObject(mr)
+	waitForPod(t, key, "hello")
+
+	policy := &networkingv1.NetworkPolicy{}
+	policyKey := types.NamespacedName{Name: "echo-netpol", Namespace: namespace}
+	eventually(t, func() error {
+		return kubeClient.Get(context.Background(), policyKey, policy)
+	})
+	if !metav1.IsControlledBy(policy, mr) {
+		t.Errorf("NetworkPolicy is not controlled by the MyResource")
+	}
+	if len(policy.Spec.Ingress) != 1 || len(policy.Spec.Ingress[0].From) != 1 || len(policy.Spec.Ingress[0].Ports) != 1 {
+		t.Fatalf("NetworkPolicy has ingress %+v, expected a single rule with one peer and port", policy.Spec.Ingress)
+	}
+	if port := policy.Spec.Ingress[0].Ports[0].Port; port == nil || port.String() != "http" {
+		t.Errorf("NetworkPolicy admits port %v, expected http", port)
+	}
+	peer := policy.Spec.Ingress[0].From[0]
+	if peer.NamespaceSelector == nil || len(peer.NamespaceSelector.MatchExpressions) != 1 ||
+		peer.NamespaceSelector.MatchExpressions[0].Key != corev1.LabelMetadataName {
+		t.Errorf("NetworkPolicy selects namespaces by %+v", peer.NamespaceSelector)
+	}
+
+	mr = getMyResource(t, key)
+	mr.Spec.NetworkPolicy = nil
+	err = kubeClient.Update(context.Background(), mr)
+	if err != nil {
+		t.Fatalf("failed to update MyResource: %v", err)
+	}
+	// ingress is denied by default
+	eventually(t, func() error {
+		err := kubeClient.Get(context.Background(), policyKey, policy)
+		if err != nil {
+			return err
+		}
+		if len(policy.Spec.Ingress) > 0 {
+			return fmt.Errorf("NetworkPolicy still admits %+v", policy.Spec.Ingress)
+		}
+		return nil
+	})
+}
+
+func TestMyResourceReplicasGetPodDisruptionBudget(t *testing.T) {
+	requireAPIServer(t)
+	startMyResourceController(t)
+	namespace := createNamespace(t)
+	replicas := int32(2)
+	mr := &v1alpha1.MyResource{
+		ObjectMeta: metav1.ObjectMeta{Name: "echo", Namespace: namespace},
+		Spec:       v1alpha1.MyResourceSpec{Message: "hello", Replicas: &replicas},
+	}
+	err := kubeClient.Create(context.Background(), mr)
+	if err != nil {
+		t.Fatalf("failed to create MyResource: %v", err)
+	}
+	key := client.ObjectKeyFromObject(mr)
+	pod := waitForPod(t, key, "hello")
+
+	if len(pod.Spec.TopologySpreadConstraints) != 2 {
+		t.Errorf("pod has topology spread constraints %+v, expected zone and hostname", pod.Spec.TopologySpreadConstraints)
+	}
+
+	budget := &policyv1.PodDisruptionBudget{}
+	budgetKey := types.NamespacedName{Name: "echo-pdb", Namespace: namespace}
+	eventually(t, func() error {
+		return kubeClient.Get(context.Background(), budgetKey, budget)
+	})
+	if !metav1.IsControlledBy(budget, mr) {
+		t.Errorf("PodDisruptionBudget is not controlled by the MyResource")
+	}
+	if budget.Spec.MaxUnavailable == nil || budget.Spec.MaxUnavailable.IntValue() != 1 || budget.Spec.MinAvailable != nil {
+		t.Errorf("PodDisruptionBudget has minAvailable %v and maxUnavailable %v, expected maxUnavailable 1",
+			budget.Spec.MinAvailable, budget.Spec.MaxUnavailable)
+	}
+
+	mr = getMyResource(t, key)
+	replicas = 1
+	mr.Spec.Replicas = &replicas
+	err = kubeClient.Update(context.Background(), mr)
+	if err != nil {
+		t.Fatalf("failed to update MyResource: %v", err)
+	}
+	eventually(t, func() error {
+		err := kubeClient.Get(context.Background(), budgetKey, &policyv1.PodDisruptionBudget{})
+		if err == nil {
+			return fmt.Errorf("PodDisruptionBudget still exists")
+		}
+		return client.IgnoreNotFound(err)
+	})
+}
+
+func TestMyResourcePodSecurity(t *testing.T) {
+	requireAPIServer(t)
+	startMyResourceController(t)
+	namespace := createNamespace(t)
+	mr := createMyResource(t, namespace, "hello")
+	key := client.ObjectKeyFromObject(mr)
+	pod := waitForPod(t, key, "hello")
+
+	podContext := pod.Spec.SecurityContext
+	if podContext == nil || podContext.RunAsNonRoot == nil || !*podContext.RunAsNonRoot ||
+		podContext.SeccompProfile == nil || podContext.SeccompProfile.Type != corev1.SeccompProfileTypeRuntimeDefault {
+		t.Errorf("pod has security context %+v, expected runAsNonRoot and the RuntimeDefault seccomp profile", podContext)
+	}
+	containerContext := pod.Spec.Containers[0].SecurityContext
+	if containerContext == nil || containerContext.AllowPrivilegeEscalation == nil || *containerContext.AllowPrivilegeEscalation ||
+		containerContext.ReadOnlyRootFilesystem == nil || !*containerContext.ReadOnlyRootFilesystem {
+		t.Errorf("container has security context %+v, expected no privilege escalation and a read-only root filesystem", containerContext)
+	}
+
+	enforcePodSecurity(t, namespace, "restricted")
+
+	mr = getMyResource(t, key)
+	runAsNonRoot := false
+	mr.Spec.SecurityContext = &v1alpha1.SecurityContext{RunAsNonRoot: &runAsNonRoot}
+	err := kubeClient.Update(context.Background(), mr)
+	if err != nil {
+		t.Fatalf("failed to update MyResource: %v", err)
+	}
+	eventually(t, func() error {
+		mr := getMyResource(t, key)
+		if !meta.IsStatusConditionTrue(mr.Status.Conditions, v1alpha1.ConditionPodSecurityViolation) {
+			return fmt.Errorf("condition %s is not true: %+v", v1alpha1.ConditionPodSecurityViolation, mr.Status.Conditions)
+		}
+		return nil
+	})
+
+	enforcePodSecurity(t, namespace, "baseline")
+	eventually(t, func() error {
+		mr := getMyResource(t, key)
+		if meta.FindStatusCondition(mr.Status.Conditions, v1alpha1.ConditionPodSecurityViolation) != nil {
+			return fmt.Errorf("condition %s is still set", v1alpha1.ConditionPodSecurityViolation)
+		}
+		return nil
+	})
+}
+
+// enforcePodSecurity labels namespace with the enforced Pod Security level.
+func enforcePodSecurity(t *testing.T, namespace string, level string) {
+	t.Helper()
+	ns := &corev1.Namespace{}
+	err := kubeClient.Get(context.Background(), types.NamespacedName{Name: namespace}, ns)
+	if err != nil {
+		t.Fatalf("failed to get namespace: %v", err)
+	}
+	if ns.Labels == nil {
+		ns.Labels = map[string]string{}
+	}
+	ns.Labels["pod-security.kubernetes.io/enforce"] = level
+	err = kubeClient.Update(context.Background(), ns)
+	if err != nil {
+		t.Fatalf("failed to update namespace: %v", err)
+	}
+}
+
+func TestMyResourceImagePolicyViolation(t *testing.T) {
+	requireAPIServer(t)
+	startMyResourceControllerWith(t, func(options *myresource.Options) {
+		options.ImagePolicy.AllowedRegistries = []string{"example.com"}
+	})
+	namespace := createNamespace(t)
+
+	// classes are cluster-scoped, the generated name keeps the class apart from other tests
+	class := &v1alpha1.MyResourceClass{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: "untrusted-"},
+		Spec:       v1alpha1.MyResourceClassSpec{Image: "docker.io/untrusted/echoserver:v1"},
+	}
+	err := kubeClient.Create(context.Background(), class)
+	if err != nil {
+		t.Fatalf("failed to create MyResourceClass: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = kubeClient.Delete(context.Background(), class)
+	})
+
+	mr := &v1alpha1.MyResource{
+		ObjectMeta: metav1.ObjectMeta{Name: "echo", Namespace: namespace},
+		Spec:       v1alpha1.MyResourceSpec{Message: "hello", ClassName: class.Name},
+	}
+	err = kubeClient.Create(context.Background(), mr)
+	if err != nil {
+		t.Fatalf("failed to create MyResource: %v", err)
+	}
+	key := client.ObjectKeyFromObject(mr)
+
+	eventually(t, func() error {
+		mr := getMyResource(t, key)
+		if !meta.IsStatusConditionTrue(mr.Status.Conditions, v1alpha1.ConditionPolicyViolation) {
+			return fmt.Errorf("condition %s is not true: %+v", v1alpha1.ConditionPolicyViolation, mr.Status.Conditions)
+		}
+		return nil
+	})
+
+	pods := &corev1.PodList{}
+	err = kubeClient.List(context.Background(), pods, client.InNamespace(namespace))
+	if err != nil {
+		t.Fatalf("failed to list pods: %v", err)
+	}
+	if len(pods.Items) > 0 {
+		t.Errorf("found %d pods of a MyResource violating the image policy", len(pods.Items))
+	}
+}
+
+func TestMyResourceQuotaExceeded(t *testing.T) {
+	requireAPIServer(t)
+	startMyResourceController(t)
+	mgr := newManager(t)
+	err := myresourcequota.AddControllerToManager(mgr, reconcileutil.DefaultOptions())
+	if err != nil {
+		t.Fatalf("failed to add MyResourceQuota controller: %v", err)
+	}
+	stop := startManager(t, mgr)
+	t.Cleanup(stop)
+
+	namespace := createNamespace(t)
+	allowed := int32(1)
+	quota := &v1alpha1.MyResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "quota", Namespace: namespace},
+		Spec:       v1alpha1.MyResourceQuotaSpec{Hard: v1alpha1.QuotaResources{MyResources: &allowed}},
+	}
+	err = kubeClient.Create(context.Background(), quota)
+	if err != nil {
+		t.Fatalf("failed to create MyResourceQuota: %v", err)
+	}
+
+	first := createMyResource(t, namespace, "hello")
+	second := &v1alpha1.MyResource{
+		ObjectMeta: metav1.ObjectMeta{Name: "second", Namespace: namespace},
+		Spec:       v1alpha1.MyResourceSpec{Message: "hello"},
+	}
+	err = kubeClient.Create(context.Background(), second)
+	if err != nil {
+		t.Fatalf("failed to create MyResource: %v", err)
+	}
+
+	eventually(t, func() error {
+		mr := getMyResource(t, client.ObjectKeyFromObject(second))
+		if !meta.IsStatusConditionTrue(mr.Status.Conditions, v1alpha1.ConditionQuotaExceeded) {
+			return fmt.Errorf("condition %s is not true: %+v", v1alpha1.ConditionQuotaExceeded, mr.Status.Conditions)
+		}
+		return nil
+	})
+	if mr := getMyResource(t, client.ObjectKeyFromObject(first)); meta.IsStatusConditionTrue(mr.Status.Conditions, v1alpha1.ConditionQuotaExceeded) {
+		t.Errorf("the first MyResource exceeds the quota: %+v", mr.Status.Conditions)
+	}
+
+	eventually(t, func() error {
+		quota := &v1alpha1.MyResourceQuota{}
+		err := kubeClient.Get(context.Background(), types.NamespacedName{Name: "quota", Namespace: namespace}, quota)
+		if err != nil {
+			return err
+		}
+		used := quota.Status.Used.MyResources
+		if used == nil || *used != 1 || quota.Status.RejectedMyResources != 1 {
+			return fmt.Errorf("unexpected quota status %+v", quota.Status)
+		}
+		return nil
+	})
+}
+
+func TestMyResourceDryRunPlansChanges(t *testing.T) {
+	requireAPIServer(t)
+	startMyResourceControllerWith(t, func(options *myresource.Options) {
+		options.DryRun = true
+	})
+	namespace := createNamespace(t)
+	mr := createMyResource(t, namespace, "hello")
+	key := client.ObjectKeyFromObject(mr)
+
+	eventually(t, func() error {
+		mr := getMyResource(t, key)
+		for _, change := range mr.Status.PlannedChanges {
+			if change.Action == "Create" && change.Kind == "Pod" {
+				return nil
+			}
+		}
+		return fmt.Errorf("no pod creation is planned: %+v", mr.Status.PlannedChanges)
+	})
+
+	pods := &corev1.PodList{}
+	err := kubeClient.List(context.Background(), pods, client.InNamespace(namespace))
+	if err != nil {
+		t.Fatalf("failed to list pods: %v", err)
+	}
+	if len(pods.Items) > 0 {
+		t.Errorf("found %d pods created in dry-run mode", len(pods.Items))
+	}
+	if mr := getMyResource(t, key); mr.Status.PodName != "" {
+		t.Errorf("status.podName %q was written in dry-run mode", mr.Status.PodName)
+	}
+}
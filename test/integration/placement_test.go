@@ -0,0 +1,177 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"testing"
+
+	v1alpha1 "github.com/reshnm/k8s-sample-controller-crd/pkg/apis/samplecontroller/v1alpha1"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/myresource"
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/spoke"
+)
+
+// labelHubUID marks the children in spoke clusters with the UID of their MyResource in the hub cluster.
+const labelHubUID = "samplecontroller.reshnm.de/hub-uid"
+
+// startSpokeCluster starts a second test API server that serves as spoke cluster until the test ends.
+func startSpokeCluster(t *testing.T) (*rest.Config, client.Client) {
+	t.Helper()
+	spokeEnv := &envtest.Environment{}
+	spokeConfig, err := spokeEnv.Start()
+	if err != nil {
+		t.Fatalf("failed to start spoke cluster: %v", err)
+	}
+	t.Cleanup(func() {
+		err := spokeEnv.Stop()
+		if err != nil {
+			t.Errorf("failed to stop spoke cluster: %v", err)
+		}
+	})
+
+	spokeClient, err := client.New(spokeConfig, client.Options{Scheme: scheme})
+	if err != nil {
+		t.Fatalf("failed to create client for spoke cluster: %v", err)
+	}
+	return spokeConfig, spokeClient
+}
+
+// registerSpokeCluster creates the Secret that registers the spoke cluster called name in namespace.
+func registerSpokeCluster(t *testing.T, namespace string, name string, spokeConfig *rest.Config) {
+	t.Helper()
+	kubeconfig := clientcmdapi.NewConfig()
+	kubeconfig.Clusters[name] = &clientcmdapi.Cluster{
+		Server:                   spokeConfig.Host,
+		CertificateAuthorityData: spokeConfig.CAData,
+	}
+	kubeconfig.AuthInfos[name] = &clientcmdapi.AuthInfo{
+		ClientCertificateData: spokeConfig.CertData,
+		ClientKeyData:         spokeConfig.KeyData,
+		Token:                 spokeConfig.BearerToken,
+	}
+	kubeconfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+	kubeconfig.CurrentContext = name
+	data, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		t.Fatalf("failed to write kubeconfig of spoke cluster %q: %v", name, err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{spoke.LabelSpoke: "true"},
+		},
+		Data: map[string][]byte{spoke.KubeconfigKey: data},
+	}
+	err = kubeClient.Create(context.Background(), secret)
+	if err != nil {
+		t.Fatalf("failed to create Secret of spoke cluster %q: %v", name, err)
+	}
+}
+
+// listSpokePods returns the pods of mr in the spoke cluster.
+func listSpokePods(spokeClient client.Client, mr *v1alpha1.MyResource) ([]corev1.Pod, error) {
+	pods := &corev1.PodList{}
+	err := spokeClient.List(context.Background(), pods, client.InNamespace(mr.Namespace),
+		client.MatchingLabels{labelHubUID: string(mr.UID)})
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+func TestMyResourcePlacementInSpokeCluster(t *testing.T) {
+	requireAPIServer(t)
+	spokeConfig, spokeClient := startSpokeCluster(t)
+	spokeNamespace := createNamespace(t)
+	registerSpokeCluster(t, spokeNamespace, "spoke", spokeConfig)
+	startMyResourceControllerWith(t, func(options *myresource.Options) {
+		options.SpokeNamespace = spokeNamespace
+	})
+
+	namespace := createNamespace(t)
+	replicas := int32(2)
+	mr := &v1alpha1.MyResource{
+		ObjectMeta: metav1.ObjectMeta{Name: "echo", Namespace: namespace},
+		Spec: v1alpha1.MyResourceSpec{
+			Message:   "hello",
+			Replicas:  &replicas,
+			Placement: &v1alpha1.Placement{Clusters: []string{"spoke"}},
+		},
+	}
+	err := kubeClient.Create(context.Background(), mr)
+	if err != nil {
+		t.Fatalf("failed to create MyResource: %v", err)
+	}
+	key := client.ObjectKeyFromObject(mr)
+
+	// the namespace doesn't exist in the spoke cluster, the controller creates it
+	eventually(t, func() error {
+		pods, err := listSpokePods(spokeClient, mr)
+		if err != nil {
+			return err
+		}
+		if len(pods) != int(replicas) {
+			return fmt.Errorf("spoke cluster holds %d pods, expected %d", len(pods), replicas)
+		}
+		for _, pod := range pods {
+			if value := envValue(&pod, "ECHO_MESSAGE"); value != "hello" {
+				return fmt.Errorf("pod %q serves message %q", pod.Name, value)
+			}
+		}
+		return nil
+	})
+	eventually(t, func() error {
+		mr := getMyResource(t, key)
+		if len(mr.Status.Clusters) != 1 || mr.Status.Clusters[0].Name != "spoke" || mr.Status.Clusters[0].Message != "" {
+			return fmt.Errorf("status.clusters is %+v", mr.Status.Clusters)
+		}
+		if mr.Status.ServiceName == "" {
+			return fmt.Errorf("status.serviceName is not set")
+		}
+		return nil
+	})
+	mr = getMyResource(t, key)
+
+	hubPods := &corev1.PodList{}
+	err = kubeClient.List(context.Background(), hubPods, client.InNamespace(namespace))
+	if err != nil {
+		t.Fatalf("failed to list pods of the hub cluster: %v", err)
+	}
+	if len(hubPods.Items) > 0 {
+		t.Errorf("placed MyResource got %d pods in the hub cluster", len(hubPods.Items))
+	}
+	service := &corev1.Service{}
+	err = spokeClient.Get(context.Background(), types.NamespacedName{Name: mr.Status.ServiceName, Namespace: namespace}, service)
+	if err != nil {
+		t.Errorf("failed to get Service %q from spoke cluster: %v", mr.Status.ServiceName, err)
+	}
+
+	err = kubeClient.Delete(context.Background(), mr)
+	if err != nil {
+		t.Fatalf("failed to delete MyResource: %v", err)
+	}
+	eventually(t, func() error {
+		pods, err := listSpokePods(spokeClient, mr)
+		if err != nil {
+			return err
+		}
+		if len(pods) > 0 {
+			return fmt.Errorf("spoke cluster still holds %d pods", len(pods))
+		}
+		err = kubeClient.Get(context.Background(), key, &v1alpha1.MyResource{})
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("MyResource wasn't released after its children were deleted from the spoke cluster: %v", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,150 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"os"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"testing"
+	"time"
+
+	"github.com/reshnm/k8s-sample-controller-crd/pkg/controllers/myresource"
+)
+
+const (
+	eventuallyTimeout  = 30 * time.Second
+	eventuallyInterval = 100 * time.Millisecond
+)
+
+var (
+	config     *rest.Config
+	scheme     = runtime.NewScheme()
+	kubeClient client.Client
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		// every test skips itself, run "make test" to install the envtest binaries
+		os.Exit(m.Run())
+	}
+
+	os.Exit(run(m))
+}
+
+// requireAPIServer skips the test if there is no test API server because KUBEBUILDER_ASSETS is not set.
+func requireAPIServer(t *testing.T) {
+	t.Helper()
+	if config == nil {
+		t.Skip("KUBEBUILDER_ASSETS is not set, run \"make test\" to run the integration tests")
+	}
+}
+
+func run(m *testing.M) int {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
+	myresource.Install(scheme)
+
+	testEnv := &envtest.Environment{}
+	var err error
+	config, err = testEnv.Start()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start test environment: %v\n", err)
+		return 1
+	}
+	defer func() {
+		err := testEnv.Stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to stop test environment: %v\n", err)
+		}
+	}()
+
+	kubeClient, err = client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create client: %v\n", err)
+		return 1
+	}
+
+	return m.Run()
+}
+
+// newManager returns a manager for the test API server that doesn't serve metrics.
+func newManager(t *testing.T) manager.Manager {
+	t.Helper()
+	mgr, err := manager.New(config, manager.Options{
+		Scheme:             scheme,
+		MetricsBindAddress: "0",
+	})
+	if err != nil {
+		t.Fatalf("failed to create manager: %v", err)
+	}
+	return mgr
+}
+
+// startManager starts mgr and returns a function that stops it and waits until it stopped.
+func startManager(t *testing.T, mgr manager.Manager) func() {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- mgr.Start(ctx)
+	}()
+
+	return func() {
+		cancel()
+		err := <-done
+		if err != nil {
+			t.Errorf("manager failed: %v", err)
+		}
+	}
+}
+
+// createNamespace creates a namespace with a generated name and deletes it at the end of the test.
+func createNamespace(t *testing.T) string {
+	t.Helper()
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{GenerateName: "integration-"}}
+	err := kubeClient.Create(context.Background(), namespace)
+	if err != nil {
+		t.Fatalf("failed to create namespace: %v", err)
+	}
+	t.Cleanup(func() {
+		// envtest doesn't run the namespace controller, the namespace is only marked as terminating
+		_ = kubeClient.Delete(context.Background(), namespace)
+	})
+	return namespace.Name
+}
+
+// eventually calls condition until it returns nil and fails the test with the last error on timeout.
+func eventually(t *testing.T, condition func() error) {
+	t.Helper()
+	var lastErr error
+	err := wait.PollImmediate(eventuallyInterval, eventuallyTimeout, func() (bool, error) {
+		lastErr = condition()
+		return lastErr == nil, nil
+	})
+	if err != nil {
+		t.Fatalf("condition not met within %s: %v", eventuallyTimeout, lastErr)
+	}
+}
+
+// consistently calls condition for duration and fails the test as soon as it returns an error.
+func consistently(t *testing.T, duration time.Duration, condition func() error) {
+	t.Helper()
+	deadline := time.Now().Add(duration)
+	for time.Now().Before(deadline) {
+		err := condition()
+		if err != nil {
+			t.Fatalf("condition violated: %v", err)
+		}
+		time.Sleep(eventuallyInterval)
+	}
+}